@@ -0,0 +1,126 @@
+// Command cachesim replays recorded order lookups through the cache
+// eviction policies and reports their hit ratios:
+//
+//	cachesim [-capacity 50,500] [-policies lru,arc] [trace ...]
+//
+// A trace is the service's request log or a list of order UIDs, one per
+// line; it is read from stdin if no files are given. From request log
+// lines only GET /order/{order_uid} requests are taken.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"wb-examples-l0/internal/storage/cache"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "cachesim:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("cachesim", flag.ContinueOnError)
+	capacities := fs.String("capacity", "50", "comma separated cache capacities")
+	policies := fs.String("policies", strings.Join(cache.Policies, ","), "comma separated eviction policies")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var caps []int
+	for _, s := range strings.Split(*capacities, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid capacity %q", s)
+		}
+		caps = append(caps, n)
+	}
+
+	var keys []string
+	if fs.NArg() == 0 {
+		var err error
+		if keys, err = readTrace(stdin, keys); err != nil {
+			return err
+		}
+	}
+	for _, path := range fs.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		keys, err = readTrace(f, keys)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("trace has no order lookups")
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%d lookups\n", len(keys))
+	fmt.Fprintln(w, "POLICY\tCAPACITY\tHITS\tMISSES\tHIT RATIO")
+	for _, capacity := range caps {
+		for _, policy := range strings.Split(*policies, ",") {
+			res, err := cache.Simulate(strings.TrimSpace(policy), capacity, slices.Values(keys))
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%.4f\n", res.Policy, res.Capacity, res.Hits, res.Misses, res.HitRatio())
+		}
+	}
+	return w.Flush()
+}
+
+// readTrace appends the order UIDs looked up in r to keys.
+func readTrace(r io.Reader, keys []string) ([]string, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		if key, ok := parseLine(sc.Text()); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys, sc.Err()
+}
+
+// parseLine returns the order UID of a trace line: the UID of a
+// GET /order/{order_uid} request log line or a line that is a bare UID.
+func parseLine(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return "", false
+	}
+
+	if !strings.ContainsAny(line, " \t/") {
+		return line, true
+	}
+
+	if !strings.Contains(line, "GET ") {
+		return "", false
+	}
+	_, rest, ok := strings.Cut(line, "/order/")
+	if !ok {
+		return "", false
+	}
+
+	end := strings.IndexAny(rest, " ?\"")
+	if end < 0 {
+		end = len(rest)
+	}
+	uid := rest[:end]
+	if uid == "" || strings.Contains(uid, "/") {
+		return "", false
+	}
+	return uid, true
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLine(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		line string
+		uid  string
+		ok   bool
+	}{
+		{
+			name: "request log",
+			line: `2025/03/01 12:00:00 [host/abc-000001] "GET http://localhost:8081/order/b563feb7b2b84b6test HTTP/1.1" from 127.0.0.1:51234 - 200 812B in 1.2ms`,
+			uid:  "b563feb7b2b84b6test",
+			ok:   true,
+		},
+		{name: "bare uid", line: "  b563feb7b2b84b6test\t", uid: "b563feb7b2b84b6test", ok: true},
+		{
+			name: "query string",
+			line: `"GET http://localhost:8081/order/b563feb7b2b84b6test?fields=items HTTP/1.1" from 127.0.0.1:51234 - 200`,
+			uid:  "b563feb7b2b84b6test",
+			ok:   true,
+		},
+		{
+			name: "timeline",
+			line: `"GET http://localhost:8081/order/b563feb7b2b84b6test/timeline HTTP/1.1" from 127.0.0.1:51234 - 200`,
+		},
+		{
+			name: "lookup by track",
+			line: `"GET http://localhost:8081/orders/by-track/WBILMTESTTRACK HTTP/1.1" from 127.0.0.1:51234 - 200`,
+		},
+		{
+			name: "not a GET",
+			line: `"DELETE http://localhost:8081/admin/cache/keys/b563feb7b2b84b6test HTTP/1.1" from 127.0.0.1:51234 - 200`,
+		},
+		{name: "empty", line: "   "},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			uid, ok := parseLine(tt.line)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.uid, uid)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"math/rand"
 	"os"
 	"time"
@@ -17,11 +18,11 @@ func main() {
 
 	log := sl.InitLogger(cfg.Env, os.Stdout)
 
-	log.Debug("config", cfg)
+	log.Debug("config", slog.Any("config", cfg))
 
 	producer, err := kafka.NewProducer(cfg.Kafka.Addresses)
 	if err != nil {
-		log.Error("Failed to create producer", err)
+		log.Error("Failed to create producer", sl.Err(err))
 		return
 	}
 	defer producer.Close()
@@ -29,7 +30,7 @@ func main() {
 	for i := 0; ; i++ {
 		message, err := generateTestOrderWithTimestamp()
 		if err != nil {
-			log.Error("error gen", err)
+			log.Error("error gen", sl.Err(err))
 			return
 		}
 		producer.Produce(message, cfg.Kafka.Consumer.OrderTopic, "0", time.Now())
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"wb-examples-l0/internal/config"
+	"wb-examples-l0/internal/http-server/handlers/admin/erase"
+	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/validator"
+)
+
+// runErase implements the `erase` subcommand:
+//
+//	wb-examples-l0 erase -customer <id> | -email <email> [-mode erase|pseudonymize] [-reason text]
+//
+// It works on the database only. Running instances keep cached copies of
+// the affected orders until they are evicted, so prefer
+// POST /admin/privacy/erase on a live system. The cache snapshot is
+// removed, so that a stopped instance doesn't restore the erased data.
+func runErase(cfg *config.Config, log *slog.Logger, args []string) error {
+	req := models.ErasureRequest{Actor: "cli"}
+
+	fs := flag.NewFlagSet("erase", flag.ContinueOnError)
+	fs.StringVar(&req.CustomerID, "customer", "", "customer_id of the data subject")
+	fs.StringVar(&req.Email, "email", "", "delivery email of the data subject")
+	fs.StringVar((*string)(&req.Mode), "mode", string(models.ErasureErase), "erase or pseudonymize")
+	fs.StringVar(&req.Reason, "reason", "", "reason recorded in the audit log")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	v := validator.New()
+	models.ValidateErasureRequest(v, &req)
+	if !v.Valid() {
+		return fmt.Errorf("invalid erase request: %v", v.Errors)
+	}
+
+	repo, err := newStorage(cfg, log)
+	if err != nil {
+		return err
+	}
+
+	eraser, ok := repo.(erase.PIIEraser)
+	if !ok {
+		return errors.New("storage driver does not support erasure")
+	}
+
+	result, err := eraser.ErasePII(context.Background(), req)
+	if err != nil {
+		return err
+	}
+
+	if path := cfg.Storage.LruCache.SnapshotPath; path != "" {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("remove cache snapshot: %w", err)
+		}
+	}
+
+	log.Warn("running instances may serve cached copies of the erased orders until they are evicted")
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
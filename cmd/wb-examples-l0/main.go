@@ -3,9 +3,12 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"log/slog"
 	"net/http"
@@ -14,12 +17,24 @@ import (
 	"syscall"
 	"time"
 	"wb-examples-l0/internal/config"
+	"wb-examples-l0/internal/http-server/handlers/admin/cacheadmin"
+	"wb-examples-l0/internal/http-server/handlers/admin/erase"
+	"wb-examples-l0/internal/http-server/handlers/customer/history"
 	"wb-examples-l0/internal/http-server/handlers/order/find"
+	"wb-examples-l0/internal/http-server/handlers/order/list"
+	"wb-examples-l0/internal/http-server/handlers/order/lookup"
+	"wb-examples-l0/internal/http-server/handlers/order/timeline"
+	"wb-examples-l0/internal/http-server/handlers/ready"
+	"wb-examples-l0/internal/http-server/handlers/search"
+	"wb-examples-l0/internal/http-server/middleware/adminauth"
 	log2 "wb-examples-l0/internal/http-server/middleware/logger"
 	"wb-examples-l0/internal/kafka"
+	"wb-examples-l0/internal/lib/fieldcrypt"
 	"wb-examples-l0/internal/lib/logger/sl"
+	"wb-examples-l0/internal/partitions"
+	"wb-examples-l0/internal/retention"
+	"wb-examples-l0/internal/storage"
 	"wb-examples-l0/internal/storage/cache"
-	"wb-examples-l0/internal/storage/postgres"
 
 	_ "wb-examples-l0/docs"
 )
@@ -39,21 +54,81 @@ import (
 // @host localhost:8081
 // @BasePath /
 // @schemes http
+
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name Authorization
+// @description Admin endpoints require "Bearer <admin.token>".
 func main() {
 	cfg := config.MustLoad()
+	resolveDriver(cfg)
 
 	log := sl.InitLogger(cfg.Env, os.Stdout)
 
-	log.Debug("config", cfg)
+	log.Debug("config", slog.Any("config", cfg))
+
+	if len(os.Args) > 1 {
+		if err := runCommand(cfg, log, os.Args[1:]); err != nil {
+			log.Error("command failed", sl.Err(err))
+			os.Exit(1)
+		}
+		return
+	}
 
-	storage, err := postgres.New(cfg)
+	repo, err := newStorage(cfg, log)
 	if err != nil {
 		log.Error("failed to init storage", sl.Err(err))
 		os.Exit(1)
 	}
 
-	/*cache := */
-	cache := cache.NewLRUCache(cfg.Storage.LruCache.Capacity, storage, log)
+	orderCache, err := cache.New(cfg.Storage.LruCache, repo, log)
+	if err != nil {
+		log.Error("failed to init cache", sl.Err(err))
+		os.Exit(1)
+	}
+
+	// The cache snapshot is sealed with the keyring of the delivery
+	// contacts, so it keeps them as protected as storage does.
+	enc := cfg.Storage.Postgres.Encryption
+	keys, err := fieldcrypt.Load(enc.Keys, enc.KeyFile)
+	if err != nil {
+		log.Error("failed to load encryption keyring", sl.Err(err))
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go orderCache.RunJanitor(ctx)
+
+	// The service reports not ready until the cache is warm, see /ready.
+	// A failed warm-up stops it under the "fail" policy.
+	warmup := cache.Preload(ctx, orderCache, repo, cfg.Storage.LruCache, keys, log)
+	warmupFailed := make(chan error, 1)
+	go func() {
+		<-warmup.Done()
+		err := warmup.Err()
+		switch {
+		case err == nil, ctx.Err() != nil:
+		case cfg.Storage.LruCache.Warmup.OnError == config.WarmupFail:
+			log.Error("cache warm-up failed, stopping", sl.Err(err))
+			warmupFailed <- err
+			cancel()
+		default:
+			log.Warn("cache warm-up failed, serving with a cold cache", sl.Err(err))
+		}
+	}()
+
+	if archiver, ok := repo.(retention.Archiver); ok && cfg.Retention.Days > 0 {
+		go retention.New(log, archiver, orderCache, cfg.Retention).Run(ctx)
+	} else if cfg.Retention.Days > 0 {
+		log.Warn("storage driver does not support retention, old orders are kept",
+			slog.String("driver", cfg.Storage.Driver))
+	}
+
+	if creator, ok := repo.(partitions.Creator); ok {
+		go partitions.New(log, creator, cfg.Partitions).Run(ctx)
+	}
 
 	router := chi.NewRouter()
 
@@ -77,30 +152,150 @@ func main() {
 		httpSwagger.URL("/swagger/doc.json"),
 	))
 
-	router.Get("/order/{order_uid}", find.New(log, storage, cache))
+	prometheus.MustRegister(orderCache)
+	if c, ok := repo.(prometheus.Collector); ok {
+		prometheus.MustRegister(c)
+	}
+	router.Handle("/metrics", promhttp.Handler())
+	router.Get("/ready", ready.New(log, warmup))
+
+	router.Get("/order/{order_uid}", find.New(log, repo, orderCache))
+	router.Get("/orders", list.New(log, repo))
+
+	// Backends are not required to support every query; routes for
+	// unsupported ones are not registered.
+	if s, ok := repo.(lookup.OrderLookuper); ok {
+		router.Get("/orders/by-track/{track_number}", lookup.NewByTrack(log, s))
+		router.Get("/orders/by-payment", lookup.NewByPayment(log, s))
+		router.Get("/orders/by-item", lookup.NewByItem(log, s))
+	}
+	if s, ok := repo.(history.CustomerHistory); ok {
+		router.Get("/customers/{customer_id}/orders", history.New(log, s))
+	}
+	if s, ok := repo.(search.OrderSearcher); ok {
+		router.Get("/search", search.New(log, s))
+	}
+	statusRepo, hasStatus := repo.(storage.StatusRepository)
+	if hasStatus {
+		router.Get("/order/{order_uid}/timeline", timeline.New(log, statusRepo))
+	}
+
+	if cfg.Admin.Token != "" {
+		router.Route("/admin", func(r chi.Router) {
+			r.Use(adminauth.New(log, cfg.Admin.Token))
+
+			if s, ok := repo.(erase.PIIEraser); ok {
+				r.Post("/privacy/erase", erase.New(log, s, orderCache))
+			}
+
+			r.Get("/cache/stats", cacheadmin.NewStats(log, orderCache))
+			r.Get("/cache/keys/{order_uid}", cacheadmin.NewInspect(log, orderCache))
+			r.Delete("/cache/keys/{order_uid}", cacheadmin.NewInvalidate(log, orderCache))
+			r.Delete("/cache", cacheadmin.NewPurge(log, orderCache))
+			r.Post("/cache/warm", cacheadmin.NewWarm(log, orderCache))
+		})
+	} else {
+		log.Warn("admin token is not set, admin endpoints are disabled")
+	}
 
 	orderConsumer, err := kafka.NewConsumer(
 		cfg.Kafka.Addresses,
 		cfg.Kafka.Consumer.OrderTopic,
 		cfg.Kafka.Consumer.OrderGroup,
-		kafka.NewOrderHandler(log, storage),
+		kafka.NewOrderHandler(log, repo, orderCache),
 	)
 	if err != nil {
-		log.Info(err.Error(), nil)
+		log.Error("failed to init consumer", sl.Err(err))
 		os.Exit(1)
 	}
 
 	go orderConsumer.Start()
 
-	err = serve(log, cfg, router)
-	log.Info("stop", err)
+	var statusConsumer *kafka.Consumer
+	switch {
+	case cfg.Kafka.Consumer.StatusTopic == "":
+	case !hasStatus:
+		log.Warn("storage driver does not support order statuses, status consumer is not started",
+			slog.String("driver", cfg.Storage.Driver))
+	default:
+		statusConsumer, err = kafka.NewConsumer(
+			cfg.Kafka.Addresses,
+			cfg.Kafka.Consumer.StatusTopic,
+			cfg.Kafka.Consumer.StatusGroup,
+			kafka.NewStatusHandler(log, statusRepo, orderCache),
+		)
+		if err != nil {
+			log.Error("failed to init status consumer", sl.Err(err))
+			os.Exit(1)
+		}
+
+		go statusConsumer.Start()
+	}
+
+	err = serve(ctx, log, cfg, router)
+	if err != nil {
+		log.Error("server stopped with error", sl.Err(err))
+	}
+	cancel()
 	if err := orderConsumer.Stop(); err != nil {
-		log.Info(err.Error(), nil)
+		log.Error("failed to stop consumer", sl.Err(err))
+	}
+	if statusConsumer != nil {
+		if err := statusConsumer.Stop(); err != nil {
+			log.Error("failed to stop status consumer", sl.Err(err))
+		}
+	}
+
+	if path := cfg.Storage.LruCache.SnapshotPath; path != "" {
+		saveSnapshot(log, orderCache, warmup, path, keys)
 	}
 
+	select {
+	case <-warmupFailed:
+		os.Exit(1)
+	default:
+	}
 }
 
-func serve(log *slog.Logger, cfg *config.Config, h http.Handler) error {
+// runCommand dispatches subcommands, e.g. `wb-examples-l0 migrate up`.
+func runCommand(cfg *config.Config, log *slog.Logger, args []string) error {
+	switch args[0] {
+	case "migrate":
+		return runMigrate(cfg, log, args[1:])
+	case "erase":
+		return runErase(cfg, log, args[1:])
+	case "reencrypt":
+		return runReencrypt(cfg, log, args[1:])
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// saveSnapshot saves the cache to path unless its warm-up was cut short or
+// failed, so that the next start preloads from storage rather than
+// restoring a partly warmed cache.
+func saveSnapshot(log *slog.Logger, c cache.Cache, warmup *cache.Warmup, path string, keys *fieldcrypt.Keyring) {
+	select {
+	case <-warmup.Done():
+	default:
+		log.Warn("cache warm-up has not finished, snapshot is not saved")
+		return
+	}
+	if err := warmup.Err(); err != nil {
+		log.Warn("cache warm-up failed, snapshot is not saved", sl.Err(err))
+		return
+	}
+
+	n, err := c.SaveSnapshot(path, keys)
+	if err != nil {
+		log.Error("failed to save cache snapshot", sl.Err(err))
+		return
+	}
+	log.Info("saved cache snapshot", slog.String("path", path), slog.Int("entries", n))
+}
+
+// serve runs the server until a termination signal or until ctx is done.
+func serve(ctx context.Context, log *slog.Logger, cfg *config.Config, h http.Handler) error {
 	srv := &http.Server{
 		Addr:         cfg.HTTPServer.Address,
 		Handler:      h,
@@ -116,11 +311,12 @@ func serve(log *slog.Logger, cfg *config.Config, h http.Handler) error {
 
 		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
-		s := <-quit
-
-		log.Info("caught signal", map[string]string{
-			"signal": s.String(),
-		})
+		select {
+		case s := <-quit:
+			log.Info("caught signal", slog.String("signal", s.String()))
+		case <-ctx.Done():
+			log.Info("shutting down")
+		}
 
 		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 		defer cancel()
@@ -143,9 +339,7 @@ func serve(log *slog.Logger, cfg *config.Config, h http.Handler) error {
 		return err
 	}
 
-	log.Info("stopped server", map[string]string{
-		"addr": srv.Addr,
-	})
+	log.Info("stopped server", slog.String("addr", srv.Addr))
 
 	return nil
 }
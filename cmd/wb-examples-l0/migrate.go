@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"wb-examples-l0/internal/config"
+	"wb-examples-l0/internal/storage/migrator"
+)
+
+var errMigrateUsage = errors.New("usage: wb-examples-l0 migrate up|down|status|version")
+
+// runMigrate implements the `migrate` subcommand. With the sharded driver
+// it runs on every shard in turn.
+func runMigrate(cfg *config.Config, log *slog.Logger, args []string) error {
+	if len(args) != 1 {
+		return errMigrateUsage
+	}
+
+	for i, target := range shardConfigs(cfg) {
+		log := log
+		if cfg.Storage.Driver == config.DriverSharded {
+			name := cfg.Storage.Sharded.Shards[i].Name
+			fmt.Printf("shard %s:\n", name)
+			log = log.With(slog.String("shard", name))
+		}
+		if err := migrate(target, log, args[0]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func migrate(cfg *config.Config, log *slog.Logger, command string) error {
+	m, err := migrator.New(cfg.Storage.Postgres.Dsn, log)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	switch command {
+	case "up":
+		return m.Up()
+	case "down":
+		return m.Down()
+	case "version":
+		version, dirty, err := m.Version()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("version: %d, dirty: %t\n", version, dirty)
+		return nil
+	case "status":
+		statuses, err := m.Status()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS")
+		for _, s := range statuses {
+			status := "pending"
+			if s.Applied {
+				status = "applied"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, status)
+		}
+		return w.Flush()
+	default:
+		return errMigrateUsage
+	}
+}
+
+// ensureSchema applies pending migrations when auto_migrate is enabled and
+// refuses to start against an outdated or dirty schema otherwise.
+func ensureSchema(cfg *config.Config, log *slog.Logger) error {
+	m, err := migrator.New(cfg.Storage.Postgres.Dsn, log)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if cfg.Storage.Postgres.AutoMigrate {
+		log.Info("applying migrations")
+		if err := m.Up(); err != nil {
+			return err
+		}
+	}
+
+	return m.CheckVersion()
+}
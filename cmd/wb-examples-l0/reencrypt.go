@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"wb-examples-l0/internal/config"
+)
+
+// reencrypter is implemented by storage backends that encrypt delivery data.
+type reencrypter interface {
+	ReencryptDeliveries(ctx context.Context, limit int) (int, error)
+	DecryptDeliveries(ctx context.Context, limit int) (int, error)
+}
+
+// runReencrypt implements the `reencrypt` subcommand:
+//
+//	wb-examples-l0 reencrypt [-batch 500] [-decrypt]
+//
+// It brings every delivery to the primary key of the configured keyring:
+// plain text rows are encrypted and rows sealed with older keys are
+// re-wrapped. Run it after enabling encryption or rotating the primary
+// key; old keys can be removed from the keyring once it has finished.
+// With -decrypt it writes every delivery back in plain text instead; run
+// it before removing the keyring or rolling back migration 000013.
+// It is safe to interrupt and to run alongside the service.
+func runReencrypt(cfg *config.Config, log *slog.Logger, args []string) error {
+	fs := flag.NewFlagSet("reencrypt", flag.ContinueOnError)
+	batch := fs.Int("batch", 500, "rows per transaction")
+	decrypt := fs.Bool("decrypt", false, "write deliveries back in plain text")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *batch <= 0 {
+		return errors.New("batch must be positive")
+	}
+
+	repo, err := newStorage(cfg, log)
+	if err != nil {
+		return err
+	}
+
+	r, ok := repo.(reencrypter)
+	if !ok {
+		return errors.New("storage driver does not support encryption")
+	}
+
+	step, name := r.ReencryptDeliveries, "re-encryption"
+	if *decrypt {
+		step, name = r.DecryptDeliveries, "decryption"
+	}
+
+	var total int
+	for {
+		n, err := step(context.Background(), *batch)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			break
+		}
+		total += n
+		log.Info(name+" batch done", slog.Int("batch", n), slog.Int("total", total))
+	}
+
+	log.Info(name+" finished", slog.Int("total", total))
+	return nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"wb-examples-l0/internal/config"
+	"wb-examples-l0/internal/storage"
+	"wb-examples-l0/internal/storage/memory"
+	"wb-examples-l0/internal/storage/postgres"
+	"wb-examples-l0/internal/storage/sharded"
+	"wb-examples-l0/internal/storage/sqlite"
+)
+
+// resolveDriver switches the default postgres driver to SQLite when the
+// configured DSN is a SQLite one, so that a DSN like "file:orders.db"
+// alone selects the backend.
+func resolveDriver(cfg *config.Config) {
+	if cfg.Storage.Driver == config.DriverPostgres && sqlite.IsDSN(cfg.Storage.Postgres.Dsn) {
+		cfg.Storage.Driver = config.DriverSQLite
+		cfg.Storage.SQLite.Dsn = cfg.Storage.Postgres.Dsn
+	}
+}
+
+// newStorage builds the backend selected by storage.driver.
+func newStorage(cfg *config.Config, log *slog.Logger) (storage.OrderRepository, error) {
+	switch cfg.Storage.Driver {
+	case config.DriverPostgres:
+		if err := ensureSchema(cfg, log); err != nil {
+			return nil, fmt.Errorf("database schema check failed: %w", err)
+		}
+		return postgres.New(cfg)
+	case config.DriverSharded:
+		return newShardedStorage(cfg, log)
+	case config.DriverSQLite:
+		return sqlite.New(cfg)
+	case config.DriverMemory:
+		log.Warn("using in-memory storage, orders are lost on restart")
+		return memory.New(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+	}
+}
+
+// newShardedStorage connects to every shard and checks its schema.
+func newShardedStorage(cfg *config.Config, log *slog.Logger) (storage.OrderRepository, error) {
+	shardCfg := cfg.Storage.Sharded
+	if len(shardCfg.Shards) == 0 {
+		return nil, errors.New("no shards configured")
+	}
+
+	shards := make([]sharded.Shard, 0, len(shardCfg.Shards))
+	repos := make([]*postgres.Storage, 0, len(shardCfg.Shards))
+	closeAll := func() {
+		for _, repo := range repos {
+			repo.Close()
+		}
+	}
+
+	var dir *postgres.Storage
+	for i, target := range shardConfigs(cfg) {
+		shard := shardCfg.Shards[i]
+		log := log.With(slog.String("shard", shard.Name))
+
+		if err := ensureSchema(target, log); err != nil {
+			closeAll()
+			return nil, fmt.Errorf("shard %s: database schema check failed: %w", shard.Name, err)
+		}
+
+		repo, err := postgres.New(target)
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("shard %s: %w", shard.Name, err)
+		}
+		repos = append(repos, repo)
+		shards = append(shards, sharded.Shard{Name: shard.Name, Repo: repo, Keys: shard.Keys})
+
+		if shard.Name == shardCfg.Directory || (shardCfg.Directory == "" && i == 0) {
+			dir = repo
+		}
+	}
+
+	if dir == nil {
+		closeAll()
+		return nil, fmt.Errorf("directory shard %q is not configured", shardCfg.Directory)
+	}
+
+	s, err := sharded.New(shards, dir)
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// shardConfigs returns a copy of cfg per shard with the shard's DSN and
+// without replicas. For other drivers it returns cfg itself.
+func shardConfigs(cfg *config.Config) []*config.Config {
+	if cfg.Storage.Driver != config.DriverSharded {
+		return []*config.Config{cfg}
+	}
+
+	configs := make([]*config.Config, 0, len(cfg.Storage.Sharded.Shards))
+	for _, shard := range cfg.Storage.Sharded.Shards {
+		c := *cfg
+		c.Storage.Postgres.Dsn = shard.Dsn
+		c.Storage.Postgres.Replicas.Dsns = nil
+		configs = append(configs, &c)
+	}
+
+	return configs
+}
@@ -9,12 +9,317 @@ const docTemplate = `{
     "info": {
         "description": "{{escape .Description}}",
         "title": "{{.Title}}",
-        "contact": {},
+        "termsOfService": "http://swagger.io/terms/",
+        "contact": {
+            "name": "API Support",
+            "url": "http://www.swagger.io/support",
+            "email": "support@swagger.io"
+        },
+        "license": {
+            "name": "Apache 2.0",
+            "url": "http://www.apache.org/licenses/LICENSE-2.0.html"
+        },
         "version": "{{.Version}}"
     },
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/admin/cache": {
+            "delete": {
+                "description": "Drop every cached order and cached absence; responds with the number of dropped orders",
+                "produces": [
+                    "application/json"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/cacheadmin.countResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/cacheadmin.errorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "summary": "Purge the cache",
+                "tags": [
+                    "admin"
+                ]
+            }
+        },
+        "/admin/cache/keys/{order_uid}": {
+            "get": {
+                "description": "Show whether an order or its absence is cached, without counting a lookup",
+                "produces": [
+                    "application/json"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/cacheadmin.entryResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/cacheadmin.errorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "summary": "Inspect a cache key",
+                "tags": [
+                    "admin"
+                ],
+                "parameters": [
+                    {
+                        "description": "Order UID",
+                        "in": "path",
+                        "name": "order_uid",
+                        "required": true,
+                        "type": "string"
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Drop an order or its cached absence from the cache; responds with what was cached",
+                "produces": [
+                    "application/json"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/cacheadmin.entryResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/cacheadmin.errorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "summary": "Invalidate a cache key",
+                "tags": [
+                    "admin"
+                ],
+                "parameters": [
+                    {
+                        "description": "Order UID",
+                        "in": "path",
+                        "name": "order_uid",
+                        "required": true,
+                        "type": "string"
+                    }
+                ]
+            }
+        },
+        "/admin/cache/stats": {
+            "get": {
+                "description": "Get lookup, eviction and expiry counters and the size of the order cache",
+                "produces": [
+                    "application/json"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/cacheadmin.statsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/cacheadmin.errorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "summary": "Get cache statistics",
+                "tags": [
+                    "admin"
+                ]
+            }
+        },
+        "/admin/cache/warm": {
+            "post": {
+                "description": "Load the newest orders from storage into the cache, up to its capacity; responds with the number of loaded orders",
+                "produces": [
+                    "application/json"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/cacheadmin.countResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/cacheadmin.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/cacheadmin.errorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "summary": "Re-warm the cache",
+                "tags": [
+                    "admin"
+                ]
+            }
+        },
+        "/admin/privacy/erase": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Erase or pseudonymize delivery personal data of all orders of a customer_id or email; payments and items are kept",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Erase customer personal data",
+                "parameters": [
+                    {
+                        "description": "Data subject and mode",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ErasureRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/erase.response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/erase.response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/erase.response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/erase.response"
+                        }
+                    }
+                }
+            }
+        },
+        "/customers/{customer_id}/orders": {
+            "get": {
+                "description": "Get a customer's orders newest-first with pagination, plus summary aggregates",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "customers"
+                ],
+                "summary": "Customer order history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customer_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/history.response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/history.response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/history.response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/history.response"
+                        }
+                    }
+                }
+            }
+        },
         "/order/{order_uid}": {
             "get": {
                 "description": "Get order details by order_uid",
@@ -55,12 +360,555 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/find.response"
                         }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/find.response"
+                        }
+                    }
+                }
+            }
+        },
+        "/order/{order_uid}/timeline": {
+            "get": {
+                "description": "Get the current status of an order and all status transitions, oldest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Order status timeline",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order UID",
+                        "name": "order_uid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/timeline.response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/timeline.response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/timeline.response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/timeline.response"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders": {
+            "get": {
+                "description": "List orders newest-first with cursor pagination and filters",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "List orders",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "customer_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Track number",
+                        "name": "track_number",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Delivery service",
+                        "name": "delivery_service",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Locale",
+                        "name": "locale",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "date_created lower bound (RFC3339 or YYYY-MM-DD), inclusive",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "date_created upper bound (RFC3339 or YYYY-MM-DD), exclusive",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Payment currency",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Payment provider",
+                        "name": "provider",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Payment bank",
+                        "name": "bank",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Item brand",
+                        "name": "brand",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/list.response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/list.response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/list.response"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/by-item": {
+            "get": {
+                "description": "Find all orders containing an item with the given rid and/or chrt_id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Find orders by item",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Item rid",
+                        "name": "rid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Item chrt_id",
+                        "name": "chrt_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lookup.response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/lookup.response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/lookup.response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/lookup.response"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/by-payment": {
+            "get": {
+                "description": "Find all orders by payment request_id and/or transaction",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Find orders by payment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Payment request ID",
+                        "name": "request_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Payment transaction",
+                        "name": "transaction",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lookup.response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/lookup.response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/lookup.response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/lookup.response"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/by-track/{track_number}": {
+            "get": {
+                "description": "Find all orders with the given order or item track number",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Find orders by track number",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Track number",
+                        "name": "track_number",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lookup.response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/lookup.response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/lookup.response"
+                        }
+                    }
+                }
+            }
+        },
+        "/ready": {
+            "get": {
+                "description": "Responds 503 while the order cache is warming up, until the preload finishes or times out, and 200 afterwards",
+                "produces": [
+                    "application/json"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ready.Response"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/ready.Response"
+                        }
+                    }
+                },
+                "summary": "Readiness probe",
+                "tags": [
+                    "health"
+                ]
+            }
+        },
+        "/search": {
+            "get": {
+                "description": "Full-text search over item names, brands and delivery name, city and address",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Search orders",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search text",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Max results (default 20, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/search.response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/search.response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/search.response"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "cache.EntryInfo": {
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "type": "integer"
+                },
+                "cached": {
+                    "type": "boolean"
+                },
+                "expired": {
+                    "description": "Expired is set for expired entries not removed yet; they may still\nbe served while they are revalidated.",
+                    "type": "boolean"
+                },
+                "expires_at": {
+                    "description": "ExpiresAt is the expiry of the entry or of the unknown key, unset\nfor entries without TTL.",
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "not_found": {
+                    "description": "NotFound is set while the key is remembered as unknown.",
+                    "type": "boolean"
+                }
+            }
+        },
+        "cache.Stats": {
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "type": "integer"
+                },
+                "capacity": {
+                    "type": "integer"
+                },
+                "entries": {
+                    "type": "integer"
+                },
+                "evictions": {
+                    "type": "integer"
+                },
+                "expirations": {
+                    "type": "integer"
+                },
+                "hits": {
+                    "type": "integer"
+                },
+                "max_bytes": {
+                    "type": "integer"
+                },
+                "misses": {
+                    "type": "integer"
+                },
+                "not_found_entries": {
+                    "type": "integer"
+                },
+                "not_found_hits": {
+                    "type": "integer"
+                },
+                "policy": {
+                    "type": "string"
+                }
+            }
+        },
+        "cache.WarmupStatus": {
+            "type": "object",
+            "properties": {
+                "done": {
+                    "type": "boolean"
+                },
+                "elapsed": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "loaded": {
+                    "description": "Loaded is the number of orders loaded so far, Target the capacity.",
+                    "type": "integer"
+                },
+                "ready": {
+                    "type": "boolean"
+                },
+                "source": {
+                    "description": "Source is \"snapshot\" or \"storage\", unset until the first orders\nare loaded.",
+                    "type": "string"
+                },
+                "target": {
+                    "type": "integer"
+                },
+                "timed_out": {
+                    "description": "TimedOut is set if the warm-up took longer than its timeout; the\nservice is ready then, while the preload goes on.",
+                    "type": "boolean"
+                }
+            }
+        },
+        "cacheadmin.countResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "cacheadmin.entryResponse": {
+            "type": "object",
+            "properties": {
+                "entry": {
+                    "$ref": "#/definitions/cache.EntryInfo"
+                }
+            }
+        },
+        "cacheadmin.errorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "cacheadmin.statsResponse": {
+            "type": "object",
+            "properties": {
+                "hit_ratio": {
+                    "type": "number"
+                },
+                "stats": {
+                    "$ref": "#/definitions/cache.Stats"
+                }
+            }
+        },
+        "erase.response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
                     }
+                },
+                "result": {
+                    "$ref": "#/definitions/models.ErasureResult"
                 }
             }
-        }
-    },
-    "definitions": {
+        },
         "find.response": {
             "type": "object",
             "properties": {
@@ -72,6 +920,102 @@ const docTemplate = `{
                 }
             }
         },
+        "history.response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "orders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Order"
+                    }
+                },
+                "summary": {
+                    "$ref": "#/definitions/models.CustomerSummary"
+                }
+            }
+        },
+        "list.response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "orders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Order"
+                    }
+                }
+            }
+        },
+        "lookup.response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "orders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Order"
+                    }
+                }
+            }
+        },
+        "models.BrandCount": {
+            "type": "object",
+            "properties": {
+                "brand": {
+                    "type": "string"
+                },
+                "count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CustomerSummary": {
+            "type": "object",
+            "properties": {
+                "avg_basket_size": {
+                    "description": "AvgBasketSize is the average number of items per order.",
+                    "type": "number"
+                },
+                "customer_id": {
+                    "type": "string"
+                },
+                "first_order_at": {
+                    "type": "string"
+                },
+                "last_order_at": {
+                    "type": "string"
+                },
+                "order_count": {
+                    "type": "integer"
+                },
+                "top_brands": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BrandCount"
+                    }
+                },
+                "total_spent": {
+                    "description": "TotalSpent is the sum of payment amounts grouped by currency.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
         "models.Delivery": {
             "type": "object",
             "properties": {
@@ -98,6 +1042,52 @@ const docTemplate = `{
                 }
             }
         },
+        "models.ErasureMode": {
+            "type": "string",
+            "enum": [
+                "erase",
+                "pseudonymize"
+            ],
+            "x-enum-comments": {
+                "ErasureErase": "ErasureErase replaces personal data with Redacted.",
+                "ErasurePseudonymize": "ErasurePseudonymize replaces the name and email with a pseudonym\nthat is stable per subject, so the orders stay linkable, and\nredacts the rest."
+            },
+            "x-enum-varnames": [
+                "ErasureErase",
+                "ErasurePseudonymize"
+            ]
+        },
+        "models.ErasureRequest": {
+            "type": "object",
+            "properties": {
+                "customer_id": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "mode": {
+                    "$ref": "#/definitions/models.ErasureMode"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ErasureResult": {
+            "type": "object",
+            "properties": {
+                "erased_at": {
+                    "type": "string"
+                },
+                "order_uids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
         "models.Item": {
             "type": "object",
             "properties": {
@@ -186,6 +1176,44 @@ const docTemplate = `{
                 }
             }
         },
+        "models.OrderStatus": {
+            "type": "string",
+            "enum": [
+                "created",
+                "paid",
+                "assembled",
+                "shipped",
+                "delivered",
+                "cancelled",
+                "returned"
+            ],
+            "x-enum-varnames": [
+                "StatusCreated",
+                "StatusPaid",
+                "StatusAssembled",
+                "StatusShipped",
+                "StatusDelivered",
+                "StatusCancelled",
+                "StatusReturned"
+            ]
+        },
+        "models.OrderTimeline": {
+            "type": "object",
+            "properties": {
+                "history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.StatusChange"
+                    }
+                },
+                "order_uid": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.OrderStatus"
+                }
+            }
+        },
         "models.Payment": {
             "type": "object",
             "properties": {
@@ -220,18 +1248,106 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "models.SearchResult": {
+            "type": "object",
+            "properties": {
+                "customer_id": {
+                    "type": "string"
+                },
+                "date_created": {
+                    "type": "string"
+                },
+                "highlights": {
+                    "description": "Highlights are matched item and delivery fragments with the\nmatching terms wrapped in \u003cb\u003e\u003c/b\u003e.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "order_uid": {
+                    "type": "string"
+                },
+                "rank": {
+                    "type": "number"
+                },
+                "track_number": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.StatusChange": {
+            "type": "object",
+            "properties": {
+                "changed_at": {
+                    "type": "string"
+                },
+                "chrt_id": {
+                    "type": "integer"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.OrderStatus"
+                }
+            }
+        },
+        "ready.Response": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string"
+                },
+                "warmup": {
+                    "$ref": "#/definitions/cache.WarmupStatus"
+                }
+            }
+        },
+        "search.response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SearchResult"
+                    }
+                }
+            }
+        },
+        "timeline.response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "timeline": {
+                    "$ref": "#/definitions/models.OrderTimeline"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "AdminToken": {
+            "description": "Admin endpoints require \"Bearer \u003cadmin.token\u003e\".",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
 
 // SwaggerInfo holds exported Swagger Info so clients can modify it
 var SwaggerInfo = &swag.Spec{
-	Version:          "",
-	Host:             "",
-	BasePath:         "",
-	Schemes:          []string{},
-	Title:            "",
-	Description:      "",
+	Version:          "1.0",
+	Host:             "localhost:8081",
+	BasePath:         "/",
+	Schemes:          []string{"http"},
+	Title:            "WB L0 Orders API",
+	Description:      "API для работы с заказами WB L0",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
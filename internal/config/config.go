@@ -12,6 +12,9 @@ type Config struct {
 	HTTPServer HTTPServer `yaml:"http_server"`
 	Storage    Storage    `yaml:"storage"`
 	Kafka      Kafka      `yaml:"kafka"`
+	Retention  Retention  `yaml:"retention"`
+	Partitions Partitions `yaml:"partitions"`
+	Admin      Admin      `yaml:"admin"`
 }
 
 type HTTPServer struct {
@@ -20,16 +23,157 @@ type HTTPServer struct {
 	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"60s"`
 }
 
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
+	DriverSharded  = "sharded"
+)
+
 type Storage struct {
+	// Driver selects the storage backend: "postgres", "sqlite", "memory"
+	// or "sharded". A postgres DSN with a file: or sqlite: scheme selects
+	// "sqlite".
+	Driver   string `yaml:"driver" env-default:"postgres"`
 	Postgres struct {
 		Dsn          string `yaml:"dsn"`
 		MaxOpenConns int    `yaml:"max_open_conns"`
-		MaxIdleConns int    `yaml:"max_idle_conns"`
-		MaxIdleTime  string `yaml:"max_idle_time"`
+		// MinConns is the number of connections the pool keeps open
+		// even when they are idle.
+		MinConns    int    `yaml:"min_conns"`
+		MaxIdleTime string `yaml:"max_idle_time"`
+		// AutoMigrate applies pending migrations on startup instead of
+		// refusing to start against an outdated schema.
+		AutoMigrate bool `yaml:"auto_migrate"`
+		Encryption  struct {
+			// KeyFile is a JSON keyring, see fieldcrypt.Load. Keys holds
+			// the same JSON inline and takes precedence. Delivery contact
+			// data is stored in plain text when neither is set.
+			KeyFile string `yaml:"key_file" env:"ENCRYPTION_KEY_FILE"`
+			Keys    string `yaml:"keys" env:"ENCRYPTION_KEYS"`
+		} `yaml:"encryption"`
+		Replicas struct {
+			// Dsns are read replicas; all reads go to the primary when
+			// there are none.
+			Dsns []string `yaml:"dsns"`
+			// MaxLag is the replication lag above which a replica gets
+			// no reads.
+			MaxLag        time.Duration `yaml:"max_lag" env-default:"5s"`
+			CheckInterval time.Duration `yaml:"check_interval" env-default:"2s"`
+			// ReadYourWrites reads orders this instance wrote less than
+			// MaxLag plus CheckInterval ago from the primary.
+			ReadYourWrites bool `yaml:"read_your_writes"`
+		} `yaml:"replicas"`
 	} `yaml:"postgres"`
-	LruCache struct {
-		Capacity int `yaml:"capacity"`
-	} `yaml:"lru_cache"`
+	// Sharded spreads orders over several Postgres databases; the
+	// settings of Postgres other than the DSN and replicas apply to each.
+	Sharded struct {
+		Shards []Shard `yaml:"shards"`
+		// Directory names the shard that holds the order_uid directory;
+		// the first shard by default.
+		Directory string `yaml:"directory"`
+	} `yaml:"sharded"`
+	SQLite struct {
+		// Dsn is a database file path or file: URI, e.g. "file:orders.db".
+		Dsn string `yaml:"dsn" env-default:"file:orders.db"`
+	} `yaml:"sqlite"`
+	LruCache LruCache `yaml:"lru_cache"`
+}
+
+// LruCache configures the in-memory order cache.
+type LruCache struct {
+	// Capacity is the maximum number of cached orders.
+	Capacity int `yaml:"capacity"`
+	// MaxBytes additionally bounds the estimated memory of the cached
+	// orders; 0 doesn't.
+	MaxBytes int64 `yaml:"max_bytes"`
+	// Shards splits the cache into independently locked segments, each
+	// holding an equal share of the capacity; 1 is a single LRU list.
+	Shards int `yaml:"shards" env-default:"1"`
+	// Policy picks the entries to evict: "lru", "lfu", "arc" or
+	// "w-tinylfu".
+	Policy string `yaml:"policy" env-default:"lru"`
+	// Population is "write_through" to cache orders as soon as the
+	// consumer saves them or "lazy" to cache them on the first lookup.
+	Population string `yaml:"population" env-default:"write_through"`
+	// TTL is how long an entry is served; 0 keeps entries until they are
+	// evicted.
+	TTL time.Duration `yaml:"ttl" env-default:"5m"`
+	// JanitorInterval is how often expired entries are swept.
+	JanitorInterval time.Duration `yaml:"janitor_interval" env-default:"1m"`
+	// StaleWhileRevalidate is how long after expiry an entry may still be
+	// served once while it is reloaded from storage in the background;
+	// 0 disables it.
+	StaleWhileRevalidate time.Duration `yaml:"stale_while_revalidate"`
+	// NegativeTTL is how long a lookup of an unknown order UID is answered
+	// from the cache; 0 disables negative caching.
+	NegativeTTL time.Duration `yaml:"negative_ttl" env-default:"30s"`
+	// NegativeCapacity bounds the number of remembered unknown UIDs.
+	NegativeCapacity int `yaml:"negative_capacity" env-default:"10000"`
+	// SnapshotPath is the file the cache is saved to on shutdown and
+	// restored from on start instead of preloading it from storage; empty
+	// disables snapshots.
+	SnapshotPath string `yaml:"snapshot_path"`
+	// SnapshotMaxAge is the age above which a snapshot is ignored; 0
+	// accepts any age.
+	SnapshotMaxAge time.Duration `yaml:"snapshot_max_age" env-default:"10m"`
+	// Warmup configures the preload of the cache on start.
+	Warmup struct {
+		// Timeout is how long the service reports not ready while the
+		// cache is preloaded; the preload goes on after it. 0 waits for
+		// the preload to finish.
+		Timeout time.Duration `yaml:"timeout" env-default:"1m"`
+		// OnError is "fail" to stop the service if the preload fails or
+		// "continue" to serve with a cold cache.
+		OnError string `yaml:"on_error" env-default:"continue"`
+	} `yaml:"warmup"`
+}
+
+const (
+	CacheWriteThrough = "write_through"
+	CacheLazy         = "lazy"
+)
+
+const (
+	WarmupFail     = "fail"
+	WarmupContinue = "continue"
+)
+
+const (
+	PolicyLRU     = "lru"
+	PolicyLFU     = "lfu"
+	PolicyARC     = "arc"
+	PolicyTinyLFU = "w-tinylfu"
+)
+
+// Shard is one database of the sharded storage.
+type Shard struct {
+	Name string `yaml:"name"`
+	Dsn  string `yaml:"dsn"`
+	// Keys are the shardkey values stored on this shard. Orders with
+	// unlisted keys are spread over all shards by a hash of the key.
+	Keys []string `yaml:"keys"`
+}
+
+// Admin protects the /admin endpoints; they are not served without a token.
+type Admin struct {
+	Token string `yaml:"token" env:"ADMIN_TOKEN"`
+}
+
+// Retention configures the background job that archives old orders.
+type Retention struct {
+	// Days is the age after which orders are archived; 0 keeps orders forever.
+	Days      int           `yaml:"days"`
+	BatchSize int           `yaml:"batch_size" env-default:"500"`
+	Interval  time.Duration `yaml:"interval" env-default:"1h"`
+}
+
+// Partitions configures the job that creates monthly partitions of the
+// orders and items tables in advance.
+type Partitions struct {
+	// Ahead is the number of months after the current one to prepare.
+	Ahead    int           `yaml:"ahead" env-default:"3"`
+	Interval time.Duration `yaml:"interval" env-default:"24h"`
 }
 
 type Kafka struct {
@@ -38,6 +182,10 @@ type Kafka struct {
 	Consumer struct {
 		OrderTopic string `yaml:"order_topic"`
 		OrderGroup string `yaml:"order_group"`
+		// StatusTopic carries order status changes; the status consumer
+		// is not started when it is empty.
+		StatusTopic string `yaml:"status_topic"`
+		StatusGroup string `yaml:"status_group"`
 	} `yaml:"consumer"`
 }
 
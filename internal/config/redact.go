@@ -0,0 +1,53 @@
+package config
+
+import (
+	"log/slog"
+	"net/url"
+	"regexp"
+	"slices"
+)
+
+const redacted = "[REDACTED]"
+
+// logConfig has the fields of Config without its LogValue method.
+type logConfig Config
+
+// LogValue logs the config with the encryption keys, the admin token and
+// the database passwords replaced by a placeholder.
+func (c Config) LogValue() slog.Value {
+	pg := &c.Storage.Postgres
+	pg.Dsn = redactDSN(pg.Dsn)
+	pg.Encryption.Keys = redactSecret(pg.Encryption.Keys)
+
+	pg.Replicas.Dsns = slices.Clone(pg.Replicas.Dsns)
+	for i, dsn := range pg.Replicas.Dsns {
+		pg.Replicas.Dsns[i] = redactDSN(dsn)
+	}
+
+	c.Storage.Sharded.Shards = slices.Clone(c.Storage.Sharded.Shards)
+	for i := range c.Storage.Sharded.Shards {
+		c.Storage.Sharded.Shards[i].Dsn = redactDSN(c.Storage.Sharded.Shards[i].Dsn)
+	}
+
+	c.Admin.Token = redactSecret(c.Admin.Token)
+
+	return slog.AnyValue(logConfig(c))
+}
+
+func redactSecret(s string) string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+var dsnPassword = regexp.MustCompile(`(password\s*=\s*)('(\\.|[^'])*'|\S+)`)
+
+// redactDSN hides the password of a URL or key=value connection string.
+// url.URL.Redacted marks a URL password with "xxxxx".
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.User != nil {
+		dsn = u.Redacted()
+	}
+	return dsnPassword.ReplaceAllString(dsn, "${1}"+redacted)
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+	"wb-examples-l0/internal/lib/logger/sl"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_LogValue(t *testing.T) {
+	var cfg Config
+	cfg.Storage.Postgres.Dsn = "postgres://app:pg-secret@db:5432/orders?sslmode=disable"
+	cfg.Storage.Postgres.Replicas.Dsns = []string{"host=replica user=app password='replica secret' dbname=orders"}
+	cfg.Storage.Postgres.Encryption.Keys = `{"primary":"k1","keys":{"k1":"key-secret"}}`
+	cfg.Storage.Postgres.Encryption.KeyFile = "/etc/wb/keys.json"
+	cfg.Storage.Sharded.Shards = []Shard{{Name: "a", Dsn: "host=a password=shard-secret"}}
+	cfg.Admin.Token = "admin-secret"
+
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("config", slog.Any("config", &cfg))
+	sl.SetupPrettySlog(&buf, slog.LevelInfo).Info("config", slog.Any("config", &cfg))
+	out := buf.String()
+
+	for _, secret := range []string{"pg-secret", "replica secret", "key-secret", "shard-secret", "admin-secret"} {
+		assert.NotContains(t, out, secret)
+	}
+	assert.Contains(t, out, "postgres://app:xxxxx@db:5432/orders?sslmode=disable")
+	assert.Contains(t, out, "host=a password=[REDACTED]")
+	assert.Contains(t, out, "/etc/wb/keys.json")
+
+	// The config itself is left alone.
+	require.Equal(t, "host=a password=shard-secret", cfg.Storage.Sharded.Shards[0].Dsn)
+	require.Equal(t, "admin-secret", cfg.Admin.Token)
+}
@@ -0,0 +1,156 @@
+package cacheadmin
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"wb-examples-l0/internal/storage/cache"
+)
+
+type statsResponse struct {
+	Stats    cache.Stats `json:"stats"`
+	HitRatio float64     `json:"hit_ratio"`
+}
+
+type entryResponse struct {
+	Entry cache.EntryInfo `json:"entry"`
+}
+
+type countResponse struct {
+	Count int `json:"count"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type OrderCache interface {
+	Stats() cache.Stats
+	Inspect(key string) cache.EntryInfo
+	Remove(key string)
+	Purge() int
+	Warm(ctx context.Context) (int, error)
+}
+
+// @Summary Get cache statistics
+// @Description Get lookup, eviction and expiry counters and the size of the order cache
+// @Tags admin
+// @Produce  json
+// @Security AdminToken
+// @Success 200 {object} cacheadmin.statsResponse
+// @Failure 401 {object} cacheadmin.errorResponse
+// @Router /admin/cache/stats [get]
+func NewStats(log *slog.Logger, orderCache OrderCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats := orderCache.Stats()
+
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, statsResponse{Stats: stats, HitRatio: stats.HitRatio()})
+	}
+}
+
+// @Summary Inspect a cache key
+// @Description Show whether an order or its absence is cached, without counting a lookup
+// @Tags admin
+// @Produce  json
+// @Security AdminToken
+// @Param order_uid path string true "Order UID"
+// @Success 200 {object} cacheadmin.entryResponse
+// @Failure 401 {object} cacheadmin.errorResponse
+// @Router /admin/cache/keys/{order_uid} [get]
+func NewInspect(log *slog.Logger, orderCache OrderCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uid := chi.URLParam(r, "order_uid")
+
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, entryResponse{Entry: orderCache.Inspect(uid)})
+	}
+}
+
+// @Summary Invalidate a cache key
+// @Description Drop an order or its cached absence from the cache; responds with what was cached
+// @Tags admin
+// @Produce  json
+// @Security AdminToken
+// @Param order_uid path string true "Order UID"
+// @Success 200 {object} cacheadmin.entryResponse
+// @Failure 401 {object} cacheadmin.errorResponse
+// @Router /admin/cache/keys/{order_uid} [delete]
+func NewInvalidate(log *slog.Logger, orderCache OrderCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.cacheadmin.NewInvalidate"
+
+		log := requestLogger(log, r, op)
+
+		uid := chi.URLParam(r, "order_uid")
+		entry := orderCache.Inspect(uid)
+		orderCache.Remove(uid)
+
+		log.Info("cache key invalidated", "order_uid", uid, "cached", entry.Cached, "not_found", entry.NotFound)
+
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, entryResponse{Entry: entry})
+	}
+}
+
+// @Summary Purge the cache
+// @Description Drop every cached order and cached absence; responds with the number of dropped orders
+// @Tags admin
+// @Produce  json
+// @Security AdminToken
+// @Success 200 {object} cacheadmin.countResponse
+// @Failure 401 {object} cacheadmin.errorResponse
+// @Router /admin/cache [delete]
+func NewPurge(log *slog.Logger, orderCache OrderCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.cacheadmin.NewPurge"
+
+		log := requestLogger(log, r, op)
+
+		n := orderCache.Purge()
+		log.Info("cache purged", "count", n)
+
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, countResponse{Count: n})
+	}
+}
+
+// @Summary Re-warm the cache
+// @Description Load the newest orders from storage into the cache, up to its capacity; responds with the number of loaded orders
+// @Tags admin
+// @Produce  json
+// @Security AdminToken
+// @Success 200 {object} cacheadmin.countResponse
+// @Failure 401 {object} cacheadmin.errorResponse
+// @Failure 500 {object} cacheadmin.errorResponse
+// @Router /admin/cache/warm [post]
+func NewWarm(log *slog.Logger, orderCache OrderCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.cacheadmin.NewWarm"
+
+		log := requestLogger(log, r, op)
+
+		n, err := orderCache.Warm(r.Context())
+		if err != nil {
+			log.Error("failed to warm cache", "error", err)
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, errorResponse{Error: "failed to warm cache"})
+			return
+		}
+
+		log.Info("cache warmed", "count", n)
+
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, countResponse{Count: n})
+	}
+}
+
+func requestLogger(log *slog.Logger, r *http.Request, op string) *slog.Logger {
+	return log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+}
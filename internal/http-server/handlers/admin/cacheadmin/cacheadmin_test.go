@@ -0,0 +1,119 @@
+package cacheadmin
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"wb-examples-l0/internal/config"
+	"wb-examples-l0/internal/storage"
+	"wb-examples-l0/internal/storage/cache"
+	"wb-examples-l0/internal/storage/memory"
+	"wb-examples-l0/internal/storage/storagetest"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingRepo fails to list orders, so warming the cache fails.
+type failingRepo struct {
+	storage.OrderRepository
+}
+
+func (failingRepo) ListOrders(context.Context, storage.OrderFilter) (*storage.OrderPage, error) {
+	return nil, errors.New("unavailable")
+}
+
+func newTestRouter(t *testing.T, repo storage.OrderRepository) (http.Handler, *cache.LRUCache) {
+	t.Helper()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	c, err := cache.NewLRUCache(config.LruCache{Capacity: 10, Policy: config.PolicyLRU}, repo, log)
+	require.NoError(t, err)
+
+	router := chi.NewRouter()
+	router.Get("/admin/cache/stats", NewStats(log, c))
+	router.Get("/admin/cache/keys/{order_uid}", NewInspect(log, c))
+	router.Delete("/admin/cache/keys/{order_uid}", NewInvalidate(log, c))
+	router.Delete("/admin/cache", NewPurge(log, c))
+	router.Post("/admin/cache/warm", NewWarm(log, c))
+	return router, c
+}
+
+func do(h http.Handler, method, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestNewStats(t *testing.T) {
+	h, c := newTestRouter(t, memory.New())
+	c.Put("a", storagetest.NewOrder("a", 1))
+	c.Get("a")
+	c.Get("a")
+	c.Get("b")
+
+	w := do(h, http.MethodGet, "/admin/cache/stats")
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"stats": {
+			"policy": "lru", "hits": 2, "misses": 1, "not_found_hits": 0,
+			"evictions": 0, "expirations": 0, "entries": 1, "capacity": 10,
+			"bytes": `+strconv.FormatInt(c.Bytes(), 10)+`, "max_bytes": 0, "not_found_entries": 0
+		},
+		"hit_ratio": 0.6666666666666666
+	}`, w.Body.String())
+}
+
+func TestNewInspectAndInvalidate(t *testing.T) {
+	h, c := newTestRouter(t, memory.New())
+	c.Put("a", storagetest.NewOrder("a", 1))
+
+	w := do(h, http.MethodGet, "/admin/cache/keys/a")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"cached":true`)
+	assert.Equal(t, 1, c.Len(), "inspecting doesn't change the cache")
+
+	w = do(h, http.MethodDelete, "/admin/cache/keys/a")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"cached":true`, "responds with what was cached")
+	assert.Zero(t, c.Len())
+
+	w = do(h, http.MethodGet, "/admin/cache/keys/a")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"cached":false`)
+}
+
+func TestNewPurge(t *testing.T) {
+	h, c := newTestRouter(t, memory.New())
+	c.Put("a", storagetest.NewOrder("a", 1))
+	c.Put("b", storagetest.NewOrder("b", 1))
+
+	w := do(h, http.MethodDelete, "/admin/cache")
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"count": 2}`, w.Body.String())
+	assert.Zero(t, c.Len())
+}
+
+func TestNewWarm(t *testing.T) {
+	repo := memory.New()
+	require.NoError(t, repo.SaveOrder(context.Background(), storagetest.NewOrder("a", 1)))
+	h, c := newTestRouter(t, repo)
+
+	w := do(h, http.MethodPost, "/admin/cache/warm")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"count": 1}`, w.Body.String())
+	assert.Equal(t, 1, c.Len())
+
+	h, _ = newTestRouter(t, failingRepo{OrderRepository: memory.New()})
+	w = do(h, http.MethodPost, "/admin/cache/warm")
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"error": "failed to warm cache"}`, w.Body.String())
+}
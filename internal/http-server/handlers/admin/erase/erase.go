@@ -0,0 +1,91 @@
+package erase
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/validator"
+)
+
+type response struct {
+	Result *models.ErasureResult `json:"result,omitempty"`
+	Error  string                `json:"error,omitempty"`
+	Errors map[string]string     `json:"errors,omitempty"`
+}
+
+type PIIEraser interface {
+	ErasePII(ctx context.Context, req models.ErasureRequest) (*models.ErasureResult, error)
+}
+
+// Evicter drops orders with redacted data from the cache.
+type Evicter interface {
+	Remove(key string)
+}
+
+// @Summary Erase customer personal data
+// @Description Erase or pseudonymize delivery personal data of all orders of a customer_id or email; payments and items are kept
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Security AdminToken
+// @Param request body models.ErasureRequest true "Data subject and mode"
+// @Success 200 {object} erase.response
+// @Failure 400 {object} erase.response
+// @Failure 401 {object} erase.response
+// @Failure 500 {object} erase.response
+// @Router /admin/privacy/erase [post]
+func New(log *slog.Logger, eraser PIIEraser, evicter Evicter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.erase.New"
+
+		ctx := r.Context()
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(ctx)),
+		)
+
+		var req models.ErasureRequest
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request", "error", err)
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response{Error: "invalid request body"})
+			return
+		}
+		if req.Mode == "" {
+			req.Mode = models.ErasureErase
+		}
+		req.Actor = "admin-api"
+
+		v := validator.New()
+		models.ValidateErasureRequest(v, &req)
+		if !v.Valid() {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response{Errors: v.Errors})
+			return
+		}
+
+		result, err := eraser.ErasePII(ctx, req)
+		if err != nil {
+			log.Error("failed to erase personal data", "error", err)
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response{Error: "failed to erase personal data"})
+			return
+		}
+
+		for _, uid := range result.OrderUIDs {
+			evicter.Remove(uid)
+		}
+
+		_, subject := req.Subject()
+		log.Info("personal data erased",
+			"subject_hash", subject,
+			"mode", req.Mode,
+			"orders", len(result.OrderUIDs))
+
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, response{Result: result})
+	}
+}
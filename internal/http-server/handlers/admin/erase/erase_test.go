@@ -0,0 +1,95 @@
+package erase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"wb-examples-l0/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeEraser struct {
+	got    *models.ErasureRequest
+	result *models.ErasureResult
+	err    error
+}
+
+func (e *fakeEraser) ErasePII(_ context.Context, req models.ErasureRequest) (*models.ErasureResult, error) {
+	e.got = &req
+	return e.result, e.err
+}
+
+type fakeEvicter map[string]bool
+
+func (e fakeEvicter) Remove(key string) {
+	delete(e, key)
+}
+
+func serve(t *testing.T, eraser PIIEraser, evicter Evicter, body string) (*httptest.ResponseRecorder, response) {
+	t.Helper()
+
+	h := New(slog.New(slog.NewTextHandler(io.Discard, nil)), eraser, evicter)
+	r := httptest.NewRequest(http.MethodPost, "/admin/privacy/erase", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	var resp response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+	return w, resp
+}
+
+func TestNew(t *testing.T) {
+	eraser := &fakeEraser{result: &models.ErasureResult{
+		OrderUIDs: []string{"a", "b"},
+		ErasedAt:  time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+	}}
+	cached := fakeEvicter{"a": true, "b": true, "c": true}
+
+	w, resp := serve(t, eraser, cached, `{"customer_id": "test"}`)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"a", "b"}, resp.Result.OrderUIDs)
+	require.NotNil(t, eraser.got)
+	assert.Equal(t, models.ErasureErase, eraser.got.Mode, "erase is the default mode")
+	assert.Equal(t, "admin-api", eraser.got.Actor)
+	assert.Equal(t, fakeEvicter{"c": true}, cached, "erased orders are evicted from the cache")
+}
+
+func TestNew_Invalid(t *testing.T) {
+	for name, body := range map[string]string{
+		"both subjects": `{"customer_id": "test", "email": "test@gmail.com"}`,
+		"no subject":    `{"mode": "erase"}`,
+		"unknown mode":  `{"customer_id": "test", "mode": "shred"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			eraser := &fakeEraser{}
+			w, resp := serve(t, eraser, fakeEvicter{}, body)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.NotEmpty(t, resp.Errors)
+			assert.Nil(t, eraser.got, "storage is not called")
+		})
+	}
+
+	w, resp := serve(t, &fakeEraser{}, fakeEvicter{}, `{`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "invalid request body", resp.Error)
+}
+
+func TestNew_StorageError(t *testing.T) {
+	cached := fakeEvicter{"a": true}
+	w, resp := serve(t, &fakeEraser{err: errors.New("unavailable")}, cached, `{"email": "test@gmail.com"}`)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "failed to erase personal data", resp.Error)
+	assert.Len(t, cached, 1)
+}
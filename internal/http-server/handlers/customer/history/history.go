@@ -0,0 +1,112 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/storage"
+)
+
+type response struct {
+	Summary    *models.CustomerSummary `json:"summary,omitempty"`
+	Orders     []*models.Order         `json:"orders,omitempty"`
+	NextCursor string                  `json:"next_cursor,omitempty"`
+	Error      string                  `json:"error,omitempty"`
+}
+
+type CustomerHistory interface {
+	ListOrders(ctx context.Context, filter storage.OrderFilter) (*storage.OrderPage, error)
+	GetCustomerSummary(ctx context.Context, customerID string) (*models.CustomerSummary, error)
+}
+
+// @Summary Customer order history
+// @Description Get a customer's orders newest-first with pagination, plus summary aggregates
+// @Tags customers
+// @Accept  json
+// @Produce  json
+// @Param customer_id path string true "Customer ID"
+// @Param cursor query string false "Cursor from the previous page"
+// @Param limit query int false "Page size (default 50, max 500)"
+// @Success 200 {object} history.response
+// @Failure 400 {object} history.response
+// @Failure 404 {object} history.response
+// @Failure 500 {object} history.response
+// @Router /customers/{customer_id}/orders [get]
+func New(log *slog.Logger, customerHistory CustomerHistory) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.customer.history.New"
+
+		ctx := r.Context()
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(ctx)),
+		)
+
+		customerID := chi.URLParam(r, "customer_id")
+		if customerID == "" {
+			log.Error("customer_id is required")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response{Error: "customer_id is required"})
+			return
+		}
+		log = log.With(slog.String("customer_id", customerID))
+
+		filter := storage.OrderFilter{
+			CustomerID: customerID,
+			Cursor:     r.URL.Query().Get("cursor"),
+		}
+		if v := r.URL.Query().Get("limit"); v != "" {
+			limit, err := strconv.Atoi(v)
+			if err != nil || limit <= 0 {
+				log.Error("invalid limit", "limit", v)
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, response{Error: "limit: must be a positive integer"})
+				return
+			}
+			filter.Limit = limit
+		}
+
+		summary, err := customerHistory.GetCustomerSummary(ctx, customerID)
+		if err != nil {
+			log.Error("failed to get customer summary", "error", err)
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response{Error: "failed to get customer orders"})
+			return
+		}
+
+		if summary.OrderCount == 0 {
+			log.Debug("customer has no orders")
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response{Error: "Customer not found"})
+			return
+		}
+
+		page, err := customerHistory.ListOrders(ctx, filter)
+		if errors.Is(err, storage.ErrInvalidCursor) {
+			log.Error("invalid cursor", "error", err)
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response{Error: "invalid cursor"})
+			return
+		}
+		if err != nil {
+			log.Error("failed to list customer orders", "error", err)
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response{Error: "failed to get customer orders"})
+			return
+		}
+
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, response{
+			Summary:    summary,
+			Orders:     page.Orders,
+			NextCursor: page.NextCursor,
+		})
+		log.Debug("customer orders listed", "count", len(page.Orders))
+	}
+}
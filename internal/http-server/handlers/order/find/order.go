@@ -1,12 +1,15 @@
 package find
 
 import (
+	"context"
+	"errors"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"log/slog"
 	"net/http"
 	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/storage"
 	"wb-examples-l0/internal/storage/cache"
 )
 
@@ -17,7 +20,11 @@ type response struct {
 
 //go:generate go
 type OrderFinder interface {
-	GetOrderByUID(orderUID string) (*models.Order, error)
+	GetOrderByUID(ctx context.Context, orderUID string) (*models.Order, error)
+}
+
+type OrderCache interface {
+	GetOrLoad(ctx context.Context, key string, loader cache.Loader) (*models.Order, error)
 }
 
 // @Summary Get order by UID
@@ -29,8 +36,9 @@ type OrderFinder interface {
 // @Success 200 {object} find.response
 // @Failure 400 {object} find.response
 // @Failure 404 {object} find.response
+// @Failure 500 {object} find.response
 // @Router /order/{order_uid} [get]
-func New(log *slog.Logger, orderFinder OrderFinder, cache *cache.LRUCache) http.HandlerFunc {
+func New(log *slog.Logger, orderFinder OrderFinder, orderCache OrderCache) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.order.find.New"
 
@@ -48,26 +56,21 @@ func New(log *slog.Logger, orderFinder OrderFinder, cache *cache.LRUCache) http.
 			return
 		}
 
-		if cachedOrder, exists := cache.Get(uid); exists {
-			log.Debug("order found in cache", "order_uid", uid)
-			render.Status(r, http.StatusOK)
-			render.JSON(w, r, response{Order: cachedOrder})
+		order, err := orderCache.GetOrLoad(ctx, uid, orderFinder.GetOrderByUID)
+		if errors.Is(err, storage.ErrOrderNotFound) {
+			log.Info("order not found", "order_uid", uid,
+				slog.Bool("cached", errors.Is(err, cache.ErrNotFoundCached)))
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response{Error: "Order not found"})
 			return
 		}
-
-		log.Debug("order not found in cache, querying database", "order_uid", uid)
-
-		order, err := orderFinder.GetOrderByUID(uid)
 		if err != nil {
-			log.Error("failed to get order from database", "error", err, "order_uid", uid)
-			render.Status(r, http.StatusNotFound)
-			render.JSON(w, r, response{Error: "Order not found"})
+			log.Error("failed to get order", "error", err, "order_uid", uid)
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response{Error: "failed to get order"})
 			return
 		}
 
-		cache.Put(uid, order)
-		log.Info("order added to cache", "order_uid", uid)
-
 		render.Status(r, http.StatusOK)
 		render.JSON(w, r, response{Order: order})
 		log.Info("order found successfully", "order_uid", uid)
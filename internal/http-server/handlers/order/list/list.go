@@ -0,0 +1,128 @@
+package list
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/storage"
+)
+
+type response struct {
+	Orders     []*models.Order `json:"orders"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+type OrderLister interface {
+	ListOrders(ctx context.Context, filter storage.OrderFilter) (*storage.OrderPage, error)
+}
+
+// @Summary List orders
+// @Description List orders newest-first with cursor pagination and filters
+// @Tags orders
+// @Accept  json
+// @Produce  json
+// @Param customer_id query string false "Customer ID"
+// @Param track_number query string false "Track number"
+// @Param delivery_service query string false "Delivery service"
+// @Param locale query string false "Locale"
+// @Param created_from query string false "date_created lower bound (RFC3339 or YYYY-MM-DD), inclusive"
+// @Param created_to query string false "date_created upper bound (RFC3339 or YYYY-MM-DD), exclusive"
+// @Param currency query string false "Payment currency"
+// @Param provider query string false "Payment provider"
+// @Param bank query string false "Payment bank"
+// @Param brand query string false "Item brand"
+// @Param cursor query string false "Cursor from the previous page"
+// @Param limit query int false "Page size (default 50, max 500)"
+// @Success 200 {object} list.response
+// @Failure 400 {object} list.response
+// @Failure 500 {object} list.response
+// @Router /orders [get]
+func New(log *slog.Logger, orderLister OrderLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.order.list.New"
+
+		ctx := r.Context()
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(ctx)),
+		)
+
+		filter, err := parseFilter(r.URL.Query())
+		if err != nil {
+			log.Error("invalid query", "error", err)
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response{Error: err.Error()})
+			return
+		}
+
+		page, err := orderLister.ListOrders(ctx, filter)
+		if errors.Is(err, storage.ErrInvalidCursor) {
+			log.Error("invalid cursor", "error", err)
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response{Error: "invalid cursor"})
+			return
+		}
+		if err != nil {
+			log.Error("failed to list orders", "error", err)
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response{Error: "failed to list orders"})
+			return
+		}
+
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, response{Orders: page.Orders, NextCursor: page.NextCursor})
+		log.Debug("orders listed", "count", len(page.Orders))
+	}
+}
+
+func parseFilter(q url.Values) (storage.OrderFilter, error) {
+	filter := storage.OrderFilter{
+		CustomerID:      q.Get("customer_id"),
+		TrackNumber:     q.Get("track_number"),
+		DeliveryService: q.Get("delivery_service"),
+		Locale:          q.Get("locale"),
+		Currency:        q.Get("currency"),
+		Provider:        q.Get("provider"),
+		Bank:            q.Get("bank"),
+		Brand:           q.Get("brand"),
+		Cursor:          q.Get("cursor"),
+	}
+
+	var err error
+
+	if v := q.Get("created_from"); v != "" {
+		if filter.CreatedFrom, err = parseDate(v); err != nil {
+			return filter, fmt.Errorf("created_from: must be RFC3339 or YYYY-MM-DD")
+		}
+	}
+
+	if v := q.Get("created_to"); v != "" {
+		if filter.CreatedTo, err = parseDate(v); err != nil {
+			return filter, fmt.Errorf("created_to: must be RFC3339 or YYYY-MM-DD")
+		}
+	}
+
+	if v := q.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil || filter.Limit <= 0 {
+			return filter, fmt.Errorf("limit: must be a positive integer")
+		}
+	}
+
+	return filter, nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
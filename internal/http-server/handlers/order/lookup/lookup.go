@@ -0,0 +1,153 @@
+package lookup
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"wb-examples-l0/internal/models"
+)
+
+type response struct {
+	Orders []*models.Order `json:"orders,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+type OrderLookuper interface {
+	GetOrdersByTrackNumber(ctx context.Context, trackNumber string) ([]*models.Order, error)
+	GetOrdersByPayment(ctx context.Context, requestID, transaction string) ([]*models.Order, error)
+	GetOrdersByItem(ctx context.Context, rid string, chrtID int) ([]*models.Order, error)
+}
+
+// @Summary Find orders by track number
+// @Description Find all orders with the given order or item track number
+// @Tags orders
+// @Accept  json
+// @Produce  json
+// @Param track_number path string true "Track number"
+// @Success 200 {object} lookup.response
+// @Failure 404 {object} lookup.response
+// @Failure 500 {object} lookup.response
+// @Router /orders/by-track/{track_number} [get]
+func NewByTrack(log *slog.Logger, lookuper OrderLookuper) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.order.lookup.NewByTrack"
+
+		log := requestLogger(log, r, op)
+
+		trackNumber := chi.URLParam(r, "track_number")
+		if trackNumber == "" {
+			badRequest(w, r, log, "track_number is required")
+			return
+		}
+
+		orders, err := lookuper.GetOrdersByTrackNumber(r.Context(), trackNumber)
+		respond(w, r, log.With("track_number", trackNumber), orders, err)
+	}
+}
+
+// @Summary Find orders by payment
+// @Description Find all orders by payment request_id and/or transaction
+// @Tags orders
+// @Accept  json
+// @Produce  json
+// @Param request_id query string false "Payment request ID"
+// @Param transaction query string false "Payment transaction"
+// @Success 200 {object} lookup.response
+// @Failure 400 {object} lookup.response
+// @Failure 404 {object} lookup.response
+// @Failure 500 {object} lookup.response
+// @Router /orders/by-payment [get]
+func NewByPayment(log *slog.Logger, lookuper OrderLookuper) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.order.lookup.NewByPayment"
+
+		log := requestLogger(log, r, op)
+
+		requestID := r.URL.Query().Get("request_id")
+		transaction := r.URL.Query().Get("transaction")
+		if requestID == "" && transaction == "" {
+			badRequest(w, r, log, "request_id or transaction is required")
+			return
+		}
+
+		orders, err := lookuper.GetOrdersByPayment(r.Context(), requestID, transaction)
+		respond(w, r, log.With("request_id", requestID, "transaction", transaction), orders, err)
+	}
+}
+
+// @Summary Find orders by item
+// @Description Find all orders containing an item with the given rid and/or chrt_id
+// @Tags orders
+// @Accept  json
+// @Produce  json
+// @Param rid query string false "Item rid"
+// @Param chrt_id query int false "Item chrt_id"
+// @Success 200 {object} lookup.response
+// @Failure 400 {object} lookup.response
+// @Failure 404 {object} lookup.response
+// @Failure 500 {object} lookup.response
+// @Router /orders/by-item [get]
+func NewByItem(log *slog.Logger, lookuper OrderLookuper) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.order.lookup.NewByItem"
+
+		log := requestLogger(log, r, op)
+
+		rid := r.URL.Query().Get("rid")
+
+		var chrtID int
+		if v := r.URL.Query().Get("chrt_id"); v != "" {
+			id, err := strconv.Atoi(v)
+			if err != nil || id <= 0 {
+				badRequest(w, r, log, "chrt_id must be a positive integer")
+				return
+			}
+			chrtID = id
+		}
+
+		if rid == "" && chrtID == 0 {
+			badRequest(w, r, log, "rid or chrt_id is required")
+			return
+		}
+
+		orders, err := lookuper.GetOrdersByItem(r.Context(), rid, chrtID)
+		respond(w, r, log.With("rid", rid, "chrt_id", chrtID), orders, err)
+	}
+}
+
+func requestLogger(log *slog.Logger, r *http.Request, op string) *slog.Logger {
+	return log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+}
+
+func badRequest(w http.ResponseWriter, r *http.Request, log *slog.Logger, msg string) {
+	log.Error(msg)
+	render.Status(r, http.StatusBadRequest)
+	render.JSON(w, r, response{Error: msg})
+}
+
+func respond(w http.ResponseWriter, r *http.Request, log *slog.Logger, orders []*models.Order, err error) {
+	if err != nil {
+		log.Error("failed to lookup orders", "error", err)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response{Error: "failed to lookup orders"})
+		return
+	}
+
+	if len(orders) == 0 {
+		log.Debug("no orders found")
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, response{Error: "Order not found"})
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response{Orders: orders})
+	log.Debug("orders found", "count", len(orders))
+}
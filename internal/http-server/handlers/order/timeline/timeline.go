@@ -0,0 +1,71 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/storage"
+)
+
+type response struct {
+	Timeline *models.OrderTimeline `json:"timeline,omitempty"`
+	Error    string                `json:"error,omitempty"`
+}
+
+type OrderTimelineGetter interface {
+	GetOrderTimeline(ctx context.Context, orderUID string) (*models.OrderTimeline, error)
+}
+
+// @Summary Order status timeline
+// @Description Get the current status of an order and all status transitions, oldest first
+// @Tags orders
+// @Accept  json
+// @Produce  json
+// @Param order_uid path string true "Order UID"
+// @Success 200 {object} timeline.response
+// @Failure 400 {object} timeline.response
+// @Failure 404 {object} timeline.response
+// @Failure 500 {object} timeline.response
+// @Router /order/{order_uid}/timeline [get]
+func New(log *slog.Logger, timelineGetter OrderTimelineGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.order.timeline.New"
+
+		ctx := r.Context()
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(ctx)),
+		)
+
+		uid := chi.URLParam(r, "order_uid")
+		if uid == "" {
+			log.Error("orderUID is required")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response{Error: "orderUID is required"})
+			return
+		}
+
+		timeline, err := timelineGetter.GetOrderTimeline(ctx, uid)
+		if errors.Is(err, storage.ErrOrderNotFound) {
+			log.Info("order not found", "order_uid", uid)
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response{Error: "Order not found"})
+			return
+		}
+		if err != nil {
+			log.Error("failed to get order timeline", "error", err, "order_uid", uid)
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response{Error: "failed to get order timeline"})
+			return
+		}
+
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, response{Timeline: timeline})
+		log.Debug("order timeline found", "order_uid", uid, "status", timeline.Status)
+	}
+}
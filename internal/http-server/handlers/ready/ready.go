@@ -0,0 +1,39 @@
+package ready
+
+import (
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"wb-examples-l0/internal/storage/cache"
+)
+
+type Response struct {
+	Status string             `json:"status"`
+	Warmup cache.WarmupStatus `json:"warmup"`
+}
+
+type Warmup interface {
+	Status() cache.WarmupStatus
+}
+
+// @Summary Readiness probe
+// @Description Responds 503 while the order cache is warming up, until the preload finishes or times out, and 200 afterwards
+// @Tags health
+// @Produce  json
+// @Success 200 {object} ready.Response
+// @Failure 503 {object} ready.Response
+// @Router /ready [get]
+func New(log *slog.Logger, warmup Warmup) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		st := warmup.Status()
+
+		if !st.Ready {
+			render.Status(r, http.StatusServiceUnavailable)
+			render.JSON(w, r, Response{Status: "warming_up", Warmup: st})
+			return
+		}
+
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, Response{Status: "ready", Warmup: st})
+	}
+}
@@ -0,0 +1,134 @@
+package ready
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"wb-examples-l0/internal/config"
+	"wb-examples-l0/internal/storage"
+	"wb-examples-l0/internal/storage/cache"
+	"wb-examples-l0/internal/storage/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingRepo lists orders once release is closed, failing with err.
+type blockingRepo struct {
+	storage.OrderRepository
+	release chan struct{}
+	err     error
+}
+
+func (r *blockingRepo) ListOrders(ctx context.Context, filter storage.OrderFilter) (*storage.OrderPage, error) {
+	<-r.release
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.OrderRepository.ListOrders(ctx, filter)
+}
+
+func preload(t *testing.T, repo storage.OrderRepository, cfg config.LruCache) *cache.Warmup {
+	t.Helper()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	c, err := cache.NewLRUCache(cfg, repo, log)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	return cache.Preload(ctx, c, repo, cfg, nil, log)
+}
+
+func probe(t *testing.T, warmup Warmup) (int, Response) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	New(slog.New(slog.NewTextHandler(io.Discard, nil)), warmup).
+		ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+	var resp Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func wait(t *testing.T, warmup *cache.Warmup) {
+	t.Helper()
+
+	select {
+	case <-warmup.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("warm-up did not finish")
+	}
+}
+
+func TestNew(t *testing.T) {
+	repo := &blockingRepo{OrderRepository: memory.New(), release: make(chan struct{})}
+	warmup := preload(t, repo, config.LruCache{Capacity: 10})
+
+	code, resp := probe(t, warmup)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "warming_up", resp.Status)
+	assert.False(t, resp.Warmup.Done)
+
+	close(repo.release)
+	wait(t, warmup)
+
+	code, resp = probe(t, warmup)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ready", resp.Status)
+	assert.True(t, resp.Warmup.Done)
+}
+
+func TestNew_Timeout(t *testing.T) {
+	repo := &blockingRepo{OrderRepository: memory.New(), release: make(chan struct{})}
+	defer close(repo.release)
+
+	cfg := config.LruCache{Capacity: 10}
+	cfg.Warmup.Timeout = 10 * time.Millisecond
+	warmup := preload(t, repo, cfg)
+
+	require.Eventually(t, func() bool {
+		code, _ := probe(t, warmup)
+		return code == http.StatusOK
+	}, 5*time.Second, time.Millisecond)
+
+	_, resp := probe(t, warmup)
+	assert.True(t, resp.Warmup.TimedOut)
+	assert.False(t, resp.Warmup.Done, "the preload goes on")
+}
+
+func TestNew_Failed(t *testing.T) {
+	for _, tt := range []struct {
+		onError string
+		want    int
+	}{
+		{config.WarmupFail, http.StatusServiceUnavailable},
+		{config.WarmupContinue, http.StatusOK},
+	} {
+		t.Run(tt.onError, func(t *testing.T) {
+			repo := &blockingRepo{
+				OrderRepository: memory.New(),
+				release:         make(chan struct{}),
+				err:             errors.New("unavailable"),
+			}
+			close(repo.release)
+
+			cfg := config.LruCache{Capacity: 10}
+			cfg.Warmup.OnError = tt.onError
+			warmup := preload(t, repo, cfg)
+			wait(t, warmup)
+
+			code, resp := probe(t, warmup)
+			assert.Equal(t, tt.want, code)
+			assert.True(t, resp.Warmup.Done)
+			assert.NotEmpty(t, resp.Warmup.Error)
+		})
+	}
+}
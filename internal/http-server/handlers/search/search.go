@@ -0,0 +1,79 @@
+package search
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/storage"
+)
+
+const defaultLimit = 20
+
+type response struct {
+	Results []models.SearchResult `json:"results"`
+	Error   string                `json:"error,omitempty"`
+}
+
+type OrderSearcher interface {
+	SearchOrders(ctx context.Context, query string, limit int) ([]models.SearchResult, error)
+}
+
+// @Summary Search orders
+// @Description Full-text search over item names, brands and delivery name, city and address
+// @Tags orders
+// @Accept  json
+// @Produce  json
+// @Param q query string true "Search text"
+// @Param limit query int false "Max results (default 20, max 500)"
+// @Success 200 {object} search.response
+// @Failure 400 {object} search.response
+// @Failure 500 {object} search.response
+// @Router /search [get]
+func New(log *slog.Logger, searcher OrderSearcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.search.New"
+
+		ctx := r.Context()
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(ctx)),
+		)
+
+		query := strings.TrimSpace(r.URL.Query().Get("q"))
+		if query == "" {
+			log.Error("q is required")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response{Error: "q is required"})
+			return
+		}
+
+		limit := defaultLimit
+		if v := r.URL.Query().Get("limit"); v != "" {
+			l, err := strconv.Atoi(v)
+			if err != nil || l <= 0 {
+				log.Error("invalid limit", "limit", v)
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, response{Error: "limit: must be a positive integer"})
+				return
+			}
+			limit = min(l, storage.MaxPageLimit)
+		}
+
+		results, err := searcher.SearchOrders(ctx, query, limit)
+		if err != nil {
+			log.Error("failed to search orders", "error", err, "q", query)
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response{Error: "failed to search orders"})
+			return
+		}
+
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, response{Results: results})
+		log.Debug("orders searched", "q", query, "count", len(results))
+	}
+}
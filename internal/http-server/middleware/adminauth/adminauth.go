@@ -0,0 +1,40 @@
+package adminauth
+
+import (
+	"crypto/subtle"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+type response struct {
+	Error string `json:"error"`
+}
+
+// New returns a middleware that only admits requests with the header
+// "Authorization: Bearer <token>".
+func New(log *slog.Logger, token string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/adminauth"),
+		)
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				log.Warn("unauthorized admin request",
+					slog.String("path", r.URL.Path),
+					slog.String("remote_addr", r.RemoteAddr),
+				)
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, response{Error: "unauthorized"})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
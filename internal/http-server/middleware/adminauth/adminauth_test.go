@@ -0,0 +1,44 @@
+package adminauth
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"missing header", "", http.StatusUnauthorized},
+		{"wrong scheme", "Basic secret", http.StatusUnauthorized},
+		{"wrong token", "Bearer guess", http.StatusUnauthorized},
+		{"token prefix", "Bearer secre", http.StatusUnauthorized},
+		{"correct token", "Bearer secret", http.StatusNoContent},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			})
+			h := New(slog.New(slog.NewTextHandler(io.Discard, nil)), "secret")(next)
+
+			r := httptest.NewRequest(http.MethodPost, "/admin/privacy/erase", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.want, w.Code)
+			if tt.want == http.StatusUnauthorized {
+				assert.JSONEq(t, `{"error":"unauthorized"}`, w.Body.String())
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package kafka
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
@@ -10,18 +11,28 @@ import (
 )
 
 type OrderSaver interface {
-	SaveOrder(order *models.Order) error
+	SaveOrder(ctx context.Context, order *models.Order) error
+}
+
+// OrderCache is told about every saved order, so that it can cache it or
+// drop what it remembers about its UID.
+type OrderCache interface {
+	OrderSaved(order *models.Order)
 }
 
 type OrderHandler struct {
 	log        *slog.Logger
 	orderSaver OrderSaver
+	cache      OrderCache
 }
 
-func NewOrderHandler(logger *slog.Logger, orderSaver OrderSaver) *OrderHandler {
+// NewOrderHandler creates a handler that saves orders with orderSaver and,
+// if cache is not nil, passes every saved order on to cache.
+func NewOrderHandler(logger *slog.Logger, orderSaver OrderSaver, cache OrderCache) *OrderHandler {
 	return &OrderHandler{
 		log:        logger,
 		orderSaver: orderSaver,
+		cache:      cache,
 	}
 }
 
@@ -40,11 +51,15 @@ func (h *OrderHandler) HandleMessage(message []byte, offset kafka.Offset) error
 		return fmt.Errorf("order validation failed: %v", v.Errors)
 	}
 
-	if err := h.orderSaver.SaveOrder(&order); err != nil {
+	if err := h.orderSaver.SaveOrder(context.Background(), &order); err != nil {
 		h.log.Error("failed to save order", "error", err, "order_uid", order.OrderUID)
 		return fmt.Errorf("failed to save order: %w", err)
 	}
 
+	if h.cache != nil {
+		h.cache.OrderSaved(&order)
+	}
+
 	h.log.Debug("order processed successfully",
 		"order_uid", order.OrderUID,
 		"offset", offset,
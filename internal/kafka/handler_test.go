@@ -0,0 +1,92 @@
+package kafka
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/storage/memory"
+	"wb-examples-l0/internal/storage/storagetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCache map[string]*models.Order
+
+func (f fakeCache) OrderSaved(order *models.Order) {
+	f[order.OrderUID] = order
+}
+
+func orderMessage(t *testing.T, uid string) []byte {
+	t.Helper()
+
+	msg, err := json.Marshal(storagetest.NewOrder(uid, 1))
+	require.NoError(t, err)
+	return msg
+}
+
+func TestOrderHandler_Cache(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cache := fakeCache{}
+	h := NewOrderHandler(log, memory.New(), cache)
+
+	require.NoError(t, h.HandleMessage(orderMessage(t, "order-1"), 0))
+	require.Contains(t, cache, "order-1")
+	assert.Equal(t, "order-1", cache["order-1"].OrderUID)
+
+	// A duplicate is not saved and leaves the cache alone.
+	cache["order-1"] = nil
+	require.Error(t, h.HandleMessage(orderMessage(t, "order-1"), 1))
+	assert.Nil(t, cache["order-1"])
+
+	// Invalid messages are not cached.
+	require.Error(t, h.HandleMessage([]byte(`{"order_uid": "order-2"}`), 2))
+	assert.NotContains(t, cache, "order-2")
+}
+
+func TestOrderHandler_NoCache(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo := memory.New()
+	h := NewOrderHandler(log, repo, nil)
+
+	require.NoError(t, h.HandleMessage(orderMessage(t, "order-1"), 0))
+
+	ok, err := repo.OrderExists(t.Context(), "order-1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+type removedKeys []string
+
+func (r *removedKeys) Remove(key string) {
+	*r = append(*r, key)
+}
+
+func TestStatusHandler_Cache(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo := memory.New()
+	order := storagetest.NewOrder("order-1", 1)
+	require.NoError(t, repo.SaveOrder(t.Context(), order))
+
+	var removed removedKeys
+	h := NewStatusHandler(log, repo, &removed)
+
+	// The order status is not cached, so an order update keeps the entry.
+	msg := `{"order_uid": "order-1", "status": "paid", "source": "test", "changed_at": "2025-03-01T12:00:00Z"}`
+	require.NoError(t, h.HandleMessage([]byte(msg), 0))
+	assert.Empty(t, removed)
+
+	msg = fmt.Sprintf(`{"order_uid": "order-1", "chrt_id": %d, "status": "paid", "source": "test", "changed_at": "2025-03-01T12:00:00Z"}`,
+		order.Items[0].ChrtID)
+	require.NoError(t, h.HandleMessage([]byte(msg), 1))
+	assert.Equal(t, removedKeys{"order-1"}, removed)
+
+	// A rejected item update leaves the cache alone.
+	msg = fmt.Sprintf(`{"order_uid": "order-1", "chrt_id": %d, "status": "created", "source": "test", "changed_at": "2025-03-01T12:00:00Z"}`,
+		order.Items[0].ChrtID)
+	require.Error(t, h.HandleMessage([]byte(msg), 2))
+	assert.Len(t, removed, 1)
+}
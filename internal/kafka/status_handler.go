@@ -0,0 +1,88 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"log/slog"
+	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/storage"
+	"wb-examples-l0/internal/validator"
+)
+
+type OrderStatusUpdater interface {
+	UpdateOrderStatus(ctx context.Context, update models.StatusUpdate) error
+}
+
+// StatusCache drops orders whose items changed status, so that the
+// next read returns the new items[].status.
+type StatusCache interface {
+	Remove(key string)
+}
+
+// StatusHandler applies status change messages. Transitions the order
+// lifecycle does not allow are rejected and logged.
+type StatusHandler struct {
+	log           *slog.Logger
+	statusUpdater OrderStatusUpdater
+	cache         StatusCache
+}
+
+// NewStatusHandler creates a handler that applies updates with
+// statusUpdater and, if cache is not nil, evicts orders from cache after
+// an item update.
+func NewStatusHandler(logger *slog.Logger, statusUpdater OrderStatusUpdater, cache StatusCache) *StatusHandler {
+	return &StatusHandler{
+		log:           logger,
+		statusUpdater: statusUpdater,
+		cache:         cache,
+	}
+}
+
+func (h *StatusHandler) HandleMessage(message []byte, offset kafka.Offset) error {
+	var update models.StatusUpdate
+
+	if err := json.Unmarshal(message, &update); err != nil {
+		h.log.Error("json unmarshal failed", "error", err, "offset", offset)
+		return fmt.Errorf("json unmarshal failed: %w", err)
+	}
+
+	v := validator.New()
+	models.ValidateStatusUpdate(v, &update)
+	if !v.Valid() {
+		h.log.Error("status update validation failed", "errors", v.Errors, "order_uid", update.OrderUID)
+		return fmt.Errorf("status update validation failed: %v", v.Errors)
+	}
+
+	err := h.statusUpdater.UpdateOrderStatus(context.Background(), update)
+	if errors.Is(err, storage.ErrIllegalTransition) {
+		h.log.Warn("illegal status transition rejected",
+			"error", err,
+			"order_uid", update.OrderUID,
+			"chrt_id", update.ChrtID,
+			"status", update.Status,
+			"source", update.Source,
+			"offset", offset)
+		return fmt.Errorf("status update rejected: %w", err)
+	}
+	if err != nil {
+		h.log.Error("failed to update order status", "error", err,
+			"order_uid", update.OrderUID, "chrt_id", update.ChrtID)
+		return fmt.Errorf("failed to update order status: %w", err)
+	}
+
+	// The order status is not part of the cached order, item statuses are.
+	if h.cache != nil && update.ChrtID != 0 {
+		h.cache.Remove(update.OrderUID)
+	}
+
+	h.log.Debug("order status updated",
+		"order_uid", update.OrderUID,
+		"chrt_id", update.ChrtID,
+		"status", update.Status,
+		"offset", offset)
+
+	return nil
+}
@@ -0,0 +1,260 @@
+// Package fieldcrypt implements envelope encryption of individual fields.
+//
+// Every record gets a fresh random data key. The fields are encrypted with
+// the data key, and the data key is wrapped with a key-encryption key from
+// the Keyring, identified by its key ID. Rotating keys therefore only
+// requires re-wrapping data keys, and records written under old keys stay
+// readable as long as those keys remain in the keyring.
+package fieldcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const keySize = 32 // AES-256
+
+var (
+	ErrUnknownKey = errors.New("unknown encryption key")
+	ErrDecrypt    = errors.New("decryption failed")
+)
+
+// Keyring holds the key-encryption keys by ID. New records are sealed
+// with the primary key.
+type Keyring struct {
+	primary  string
+	keys     map[string]cipher.AEAD
+	indexKey []byte
+}
+
+// keyringFile is the JSON form of a Keyring. Keys are base64-encoded
+// 32-byte values, e.g. from `openssl rand -base64 32`:
+//
+//	{"primary": "2025-03", "keys": {"2025-01": "...", "2025-03": "..."}, "index_key": "..."}
+//
+// index_key is used for blind indexes and must not change on rotation.
+type keyringFile struct {
+	Primary  string            `json:"primary"`
+	Keys     map[string]string `json:"keys"`
+	IndexKey string            `json:"index_key"`
+}
+
+// Load reads a keyring from inline JSON or, if it is empty, from the file
+// at path. It returns nil without error when both are empty.
+func Load(inline, path string) (*Keyring, error) {
+	data := []byte(inline)
+	if inline == "" {
+		if path == "" {
+			return nil, nil
+		}
+
+		var err error
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read keyring: %w", err)
+		}
+	}
+
+	var f keyringFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse keyring: %w", err)
+	}
+
+	return New(f.Primary, f.Keys, f.IndexKey)
+}
+
+// New builds a keyring from base64-encoded keys.
+func New(primary string, keys map[string]string, indexKey string) (*Keyring, error) {
+	k := &Keyring{
+		primary: primary,
+		keys:    make(map[string]cipher.AEAD, len(keys)),
+	}
+
+	for id, encoded := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid key id %q", id)
+		}
+
+		key, err := decodeKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		k.keys[id] = aead
+	}
+
+	if _, ok := k.keys[primary]; !ok {
+		return nil, fmt.Errorf("primary key %q: %w", primary, ErrUnknownKey)
+	}
+
+	var err error
+	k.indexKey, err = decodeKey(indexKey)
+	if err != nil {
+		return nil, fmt.Errorf("index key: %w", err)
+	}
+
+	return k, nil
+}
+
+// Primary returns the ID of the key new records are sealed with.
+func (k *Keyring) Primary() string {
+	return k.primary
+}
+
+// Envelope is the wrapped data key of one record.
+type Envelope struct {
+	KeyID      string
+	WrappedKey []byte
+}
+
+// Seal encrypts values under a new data key wrapped with the primary key.
+// aad binds the ciphertexts to their record, e.g. the order UID, so they
+// can't be moved to another one. Every value is also bound to its position.
+func (k *Keyring) Seal(aad string, values ...string) (Envelope, []string, error) {
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return Envelope{}, nil, err
+	}
+
+	wrapped, err := seal(k.keys[k.primary], dataKey, []byte(aad))
+	if err != nil {
+		return Envelope{}, nil, err
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return Envelope{}, nil, err
+	}
+
+	sealed := make([]string, len(values))
+	for i, v := range values {
+		ct, err := seal(aead, []byte(v), fieldAAD(aad, i))
+		if err != nil {
+			return Envelope{}, nil, err
+		}
+		sealed[i] = base64.StdEncoding.EncodeToString(ct)
+	}
+
+	return Envelope{KeyID: k.primary, WrappedKey: wrapped}, sealed, nil
+}
+
+// Open decrypts values sealed by Seal with the same aad.
+func (k *Keyring) Open(env Envelope, aad string, sealed ...string) ([]string, error) {
+	kek, ok := k.keys[env.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("key %q: %w", env.KeyID, ErrUnknownKey)
+	}
+
+	dataKey, err := open(kek, env.WrappedKey, []byte(aad))
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]string, len(sealed))
+	for i, s := range sealed {
+		ct, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDecrypt, err)
+		}
+
+		pt, err := open(aead, ct, fieldAAD(aad, i))
+		if err != nil {
+			return nil, err
+		}
+		values[i] = string(pt)
+	}
+
+	return values, nil
+}
+
+// Rewrap re-wraps the data key of env with the primary key. The sealed
+// values stay valid, so rotating a record doesn't touch its fields.
+func (k *Keyring) Rewrap(env Envelope, aad string) (Envelope, error) {
+	kek, ok := k.keys[env.KeyID]
+	if !ok {
+		return Envelope{}, fmt.Errorf("key %q: %w", env.KeyID, ErrUnknownKey)
+	}
+
+	dataKey, err := open(kek, env.WrappedKey, []byte(aad))
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	wrapped, err := seal(k.keys[k.primary], dataKey, []byte(aad))
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	return Envelope{KeyID: k.primary, WrappedKey: wrapped}, nil
+}
+
+// BlindIndex returns a keyed hash of the case-folded value, which allows
+// exact-match lookups of encrypted values.
+func (k *Keyring) BlindIndex(value string) string {
+	mac := hmac.New(sha256.New, k.indexKey)
+	mac.Write([]byte(strings.ToLower(value)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func decodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", keySize, len(key))
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal returns nonce || ciphertext.
+func seal(aead cipher.AEAD, plaintext, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(aead cipher.AEAD, data, aad []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+
+	pt, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], aad)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return pt, nil
+}
+
+func fieldAAD(aad string, i int) []byte {
+	return []byte(fmt.Sprintf("%s:%d", aad, i))
+}
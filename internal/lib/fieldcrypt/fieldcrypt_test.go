@@ -0,0 +1,108 @@
+package fieldcrypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, keySize))
+}
+
+func newTestKeyring(t *testing.T, primary string) *Keyring {
+	t.Helper()
+
+	k, err := New(primary, map[string]string{"k1": testKey(1), "k2": testKey(2)}, testKey(9))
+	require.NoError(t, err)
+	return k
+}
+
+func TestSealOpen(t *testing.T) {
+	k := newTestKeyring(t, "k1")
+
+	env, sealed, err := k.Seal("order-1", "+9720000000", "test@gmail.com", "Ploshad Mira 15")
+	require.NoError(t, err)
+	require.Equal(t, "k1", env.KeyID)
+	require.NotContains(t, sealed, "test@gmail.com")
+
+	values, err := k.Open(env, "order-1", sealed...)
+	require.NoError(t, err)
+	require.Equal(t, []string{"+9720000000", "test@gmail.com", "Ploshad Mira 15"}, values)
+}
+
+func TestOpen_WrongContext(t *testing.T) {
+	k := newTestKeyring(t, "k1")
+
+	env, sealed, err := k.Seal("order-1", "a", "b")
+	require.NoError(t, err)
+
+	// Ciphertexts can't be moved to another record...
+	_, err = k.Open(env, "order-2", sealed...)
+	require.ErrorIs(t, err, ErrDecrypt)
+
+	// ...or to another field.
+	_, err = k.Open(env, "order-1", sealed[1], sealed[0])
+	require.ErrorIs(t, err, ErrDecrypt)
+}
+
+func TestRotation(t *testing.T) {
+	old := newTestKeyring(t, "k1")
+	env, sealed, err := old.Seal("order-1", "secret")
+	require.NoError(t, err)
+
+	rotated := newTestKeyring(t, "k2")
+
+	// Records sealed with a non-primary key stay readable.
+	values, err := rotated.Open(env, "order-1", sealed...)
+	require.NoError(t, err)
+	require.Equal(t, []string{"secret"}, values)
+
+	env, err = rotated.Rewrap(env, "order-1")
+	require.NoError(t, err)
+	require.Equal(t, "k2", env.KeyID)
+
+	// After re-wrapping the old key is no longer needed.
+	k2only, err := New("k2", map[string]string{"k2": testKey(2)}, testKey(9))
+	require.NoError(t, err)
+
+	values, err = k2only.Open(env, "order-1", sealed...)
+	require.NoError(t, err)
+	require.Equal(t, []string{"secret"}, values)
+
+	_, err = k2only.Open(Envelope{KeyID: "k1"}, "order-1")
+	require.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestBlindIndex(t *testing.T) {
+	k1 := newTestKeyring(t, "k1")
+	k2 := newTestKeyring(t, "k2")
+
+	require.Equal(t, k1.BlindIndex("Test@Gmail.com"), k2.BlindIndex("test@gmail.com"))
+	require.NotEqual(t, k1.BlindIndex("test@gmail.com"), k1.BlindIndex("other@gmail.com"))
+}
+
+func TestLoad(t *testing.T) {
+	k, err := Load("", "")
+	require.NoError(t, err)
+	require.Nil(t, k)
+
+	data := `{"primary": "k1", "keys": {"k1": "` + testKey(1) + `"}, "index_key": "` + testKey(9) + `"}`
+	path := filepath.Join(t.TempDir(), "keys.json")
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+	k, err = Load("", path)
+	require.NoError(t, err)
+	require.Equal(t, "k1", k.Primary())
+
+	// Inline keys take precedence over the file.
+	_, err = Load(`{"primary": "missing"}`, path)
+	require.ErrorIs(t, err, ErrUnknownKey)
+
+	_, err = New("k1", map[string]string{"k1": "c2hvcnQ="}, testKey(9))
+	require.Error(t, err)
+}
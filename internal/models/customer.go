@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// CustomerSummary holds aggregates over all orders of one customer.
+type CustomerSummary struct {
+	CustomerID string `json:"customer_id"`
+	OrderCount int    `json:"order_count"`
+	// TotalSpent is the sum of payment amounts grouped by currency.
+	TotalSpent map[string]int `json:"total_spent"`
+	// AvgBasketSize is the average number of items per order.
+	AvgBasketSize float64      `json:"avg_basket_size"`
+	TopBrands     []BrandCount `json:"top_brands"`
+	FirstOrderAt  time.Time    `json:"first_order_at"`
+	LastOrderAt   time.Time    `json:"last_order_at"`
+}
+
+type BrandCount struct {
+	Brand string `json:"brand"`
+	Count int    `json:"count"`
+}
@@ -67,7 +67,8 @@ type Item struct {
 	TotalPrice  int    `json:"total_price"`
 	NmID        int    `json:"nm_id"`
 	Brand       string `json:"brand"`
-	Status      int    `json:"status"`
+	// Status is the item status code of the order feed, see ItemStatus.
+	Status int `json:"status"`
 }
 
 func ValidateOrder(v *validator.Validator, order *Order) {
@@ -161,7 +162,8 @@ func ValidateItem(v *validator.Validator, item *Item, index int) {
 
 	v.Check(item.Brand != "", prefix("brand"), "must be provided")
 
-	v.Check(item.Status >= 0, prefix("status"), "must be non-negative")
+	_, known := ItemStatus(item.Status)
+	v.Check(known, prefix("status"), "must be a known item status code")
 
 	//if item.Sale > 0 {
 	//	expectedPrice := item.Price * (100 - item.Sale) / 100
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+	"wb-examples-l0/internal/validator"
+)
+
+// ErasureMode selects how personal data is removed.
+type ErasureMode string
+
+const (
+	// ErasureErase replaces personal data with Redacted.
+	ErasureErase ErasureMode = "erase"
+	// ErasurePseudonymize replaces the name and email with a pseudonym
+	// that is stable per subject, so the orders stay linkable, and
+	// redacts the rest.
+	ErasurePseudonymize ErasureMode = "pseudonymize"
+)
+
+// Redacted replaces erased personal data.
+const Redacted = "[redacted]"
+
+// ErasureRequest identifies the data subject by CustomerID or Email.
+type ErasureRequest struct {
+	CustomerID string      `json:"customer_id,omitempty"`
+	Email      string      `json:"email,omitempty"`
+	Mode       ErasureMode `json:"mode"`
+	Reason     string      `json:"reason,omitempty"`
+	// Actor is who requested the erasure, e.g. "admin-api" or "cli".
+	Actor string `json:"-"`
+}
+
+// ErasureResult lists the orders whose delivery data was redacted,
+// archived ones included.
+type ErasureResult struct {
+	OrderUIDs []string  `json:"order_uids"`
+	ErasedAt  time.Time `json:"erased_at"`
+}
+
+// Subject returns the kind of identifier and its SHA-256 hash. Audit
+// records keep only the hash, so they hold no personal data themselves.
+func (r *ErasureRequest) Subject() (kind, hash string) {
+	kind, value := "customer_id", r.CustomerID
+	if value == "" {
+		kind, value = "email", strings.ToLower(r.Email)
+	}
+
+	sum := sha256.Sum256([]byte(kind + ":" + value))
+	return kind, hex.EncodeToString(sum[:])
+}
+
+// RedactDelivery removes the personal data from d: name, phone, zip,
+// address and email. City and region are kept for statistics.
+func (r *ErasureRequest) RedactDelivery(d *Delivery) {
+	d.Name = Redacted
+	d.Phone = Redacted
+	d.Zip = Redacted
+	d.Address = Redacted
+	d.Email = Redacted
+
+	if r.Mode == ErasurePseudonymize {
+		_, hash := r.Subject()
+		d.Name = "anon-" + hash[:12]
+		d.Email = "anon-" + hash[:12] + "@anonymized.invalid"
+	}
+}
+
+func ValidateErasureRequest(v *validator.Validator, r *ErasureRequest) {
+	v.Check(r.CustomerID != "" || r.Email != "", "customer_id", "customer_id or email must be provided")
+	v.Check(r.CustomerID == "" || r.Email == "", "customer_id", "only one of customer_id and email may be provided")
+
+	v.Check(validator.PermittedValue(r.Mode, ErasureErase, ErasurePseudonymize), "mode", "must be erase or pseudonymize")
+}
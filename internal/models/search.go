@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// SearchResult is an order summary matched by full-text search.
+type SearchResult struct {
+	OrderUID    string    `json:"order_uid"`
+	TrackNumber string    `json:"track_number"`
+	CustomerID  string    `json:"customer_id"`
+	DateCreated time.Time `json:"date_created"`
+	Rank        float64   `json:"rank"`
+	// Highlights are matched item and delivery fragments with the
+	// matching terms wrapped in <b></b>.
+	Highlights []string `json:"highlights"`
+}
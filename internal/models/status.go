@@ -0,0 +1,116 @@
+package models
+
+import (
+	"time"
+	"wb-examples-l0/internal/validator"
+)
+
+// OrderStatus is a stage of the order lifecycle.
+type OrderStatus string
+
+const (
+	StatusCreated   OrderStatus = "created"
+	StatusPaid      OrderStatus = "paid"
+	StatusAssembled OrderStatus = "assembled"
+	StatusShipped   OrderStatus = "shipped"
+	StatusDelivered OrderStatus = "delivered"
+	StatusCancelled OrderStatus = "cancelled"
+	StatusReturned  OrderStatus = "returned"
+)
+
+// StatusSourceOrder is the source of the initial "created" status
+// recorded when an order is saved.
+const StatusSourceOrder = "order"
+
+// statusTransitions lists the statuses reachable from each status.
+// An order can be cancelled until it is shipped and returned after it
+// is shipped; cancelled and returned are final.
+var statusTransitions = map[OrderStatus][]OrderStatus{
+	StatusCreated:   {StatusPaid, StatusCancelled},
+	StatusPaid:      {StatusAssembled, StatusCancelled},
+	StatusAssembled: {StatusShipped, StatusCancelled},
+	StatusShipped:   {StatusDelivered, StatusReturned},
+	StatusDelivered: {StatusReturned},
+	StatusCancelled: nil,
+	StatusReturned:  nil,
+}
+
+// Valid reports whether s is a known status.
+func (s OrderStatus) Valid() bool {
+	_, ok := statusTransitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether an order in status s may move to next.
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	return validator.PermittedValue(next, statusTransitions[s]...)
+}
+
+// itemStatusCodes maps the item status codes of the order feed
+// (items[].status) onto the lifecycle. Items follow the same transitions
+// as orders; 202 is the code of a new item.
+var itemStatusCodes = map[int]OrderStatus{
+	202: StatusCreated,
+	203: StatusPaid,
+	204: StatusAssembled,
+	205: StatusShipped,
+	206: StatusDelivered,
+	207: StatusCancelled,
+	208: StatusReturned,
+}
+
+// ItemStatus returns the lifecycle status of an item status code.
+func ItemStatus(code int) (OrderStatus, bool) {
+	status, ok := itemStatusCodes[code]
+	return status, ok
+}
+
+// ItemCode returns the item status code of s, or 0 for an unknown status.
+func (s OrderStatus) ItemCode() int {
+	for code, status := range itemStatusCodes {
+		if status == s {
+			return code
+		}
+	}
+	return 0
+}
+
+// StatusChange is one transition in the order timeline. ChrtID is set
+// for a transition of the order's items with that chrt_id.
+type StatusChange struct {
+	Status    OrderStatus `json:"status"`
+	Source    string      `json:"source"`
+	ChangedAt time.Time   `json:"changed_at"`
+	ChrtID    int         `json:"chrt_id,omitempty"`
+}
+
+// StatusUpdate is a status change message for one order, e.g. from Kafka.
+// With ChrtID set it moves the order's items with that chrt_id instead of
+// the order itself.
+type StatusUpdate struct {
+	OrderUID  string      `json:"order_uid"`
+	ChrtID    int         `json:"chrt_id,omitempty"`
+	Status    OrderStatus `json:"status"`
+	Source    string      `json:"source"`
+	ChangedAt time.Time   `json:"changed_at"`
+}
+
+// OrderTimeline is the current status of an order and all its
+// transitions, oldest first.
+type OrderTimeline struct {
+	OrderUID string         `json:"order_uid"`
+	Status   OrderStatus    `json:"status"`
+	History  []StatusChange `json:"history"`
+}
+
+func ValidateStatusUpdate(v *validator.Validator, update *StatusUpdate) {
+	v.Check(update.OrderUID != "", "order_uid", "must be provided")
+
+	v.Check(update.ChrtID >= 0, "chrt_id", "must be non-negative")
+
+	v.Check(update.Status.Valid(), "status", "must be a known status")
+
+	v.Check(update.Source != "", "source", "must be provided")
+
+	v.Check(!update.ChangedAt.IsZero(), "changed_at", "must be provided")
+}
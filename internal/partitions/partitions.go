@@ -0,0 +1,82 @@
+// Package partitions runs the background job that creates upcoming
+// monthly partitions of the orders tables.
+package partitions
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"wb-examples-l0/internal/config"
+	"wb-examples-l0/internal/lib/logger/sl"
+)
+
+// Creator is implemented by storage backends with partitioned tables.
+// CreatePartitions makes sure partitions exist for months months starting
+// with the month of since and returns how many were created.
+type Creator interface {
+	CreatePartitions(ctx context.Context, since time.Time, months int) (int, error)
+}
+
+const (
+	defaultAhead    = 3
+	defaultInterval = 24 * time.Hour
+)
+
+type Job struct {
+	log      *slog.Logger
+	creator  Creator
+	ahead    int
+	interval time.Duration
+	now      func() time.Time
+}
+
+func New(log *slog.Logger, creator Creator, cfg config.Partitions) *Job {
+	if cfg.Ahead <= 0 {
+		cfg.Ahead = defaultAhead
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultInterval
+	}
+
+	return &Job{
+		log:      log.With(slog.String("component", "partitions")),
+		creator:  creator,
+		ahead:    cfg.Ahead,
+		interval: cfg.Interval,
+		now:      time.Now,
+	}
+}
+
+// Run runs the job right away and then every interval until ctx is done.
+func (j *Job) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := j.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			j.log.Error("partition maintenance failed", sl.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce creates the partitions of the current month and the next ahead
+// months. Orders arriving for months without a partition land in the
+// default partition, so it is run well in advance.
+func (j *Job) RunOnce(ctx context.Context) error {
+	created, err := j.creator.CreatePartitions(ctx, j.now(), j.ahead+1)
+	if err != nil {
+		return err
+	}
+
+	if created > 0 {
+		j.log.Info("partitions created", slog.Int("count", created))
+	}
+
+	return nil
+}
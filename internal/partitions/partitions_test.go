@@ -0,0 +1,51 @@
+package partitions
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+	"wb-examples-l0/internal/config"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCreator struct {
+	since  time.Time
+	months int
+	err    error
+}
+
+func (f *fakeCreator) CreatePartitions(_ context.Context, since time.Time, months int) (int, error) {
+	f.since, f.months = since, months
+	return months, f.err
+}
+
+func newTestJob(creator Creator, cfg config.Partitions) *Job {
+	j := New(slog.New(slog.NewTextHandler(io.Discard, nil)), creator, cfg)
+	j.now = func() time.Time { return time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC) }
+	return j
+}
+
+func TestRunOnce(t *testing.T) {
+	creator := &fakeCreator{}
+
+	require.NoError(t, newTestJob(creator, config.Partitions{Ahead: 2}).RunOnce(context.Background()))
+	require.Equal(t, time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC), creator.since)
+	require.Equal(t, 3, creator.months)
+}
+
+func TestRunOnce_Defaults(t *testing.T) {
+	creator := &fakeCreator{}
+
+	require.NoError(t, newTestJob(creator, config.Partitions{}).RunOnce(context.Background()))
+	require.Equal(t, defaultAhead+1, creator.months)
+}
+
+func TestRunOnce_Error(t *testing.T) {
+	creator := &fakeCreator{err: errors.New("connection reset")}
+
+	require.ErrorIs(t, newTestJob(creator, config.Partitions{}).RunOnce(context.Background()), creator.err)
+}
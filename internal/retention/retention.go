@@ -0,0 +1,118 @@
+// Package retention runs the background job that archives old orders.
+package retention
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"wb-examples-l0/internal/config"
+	"wb-examples-l0/internal/lib/logger/sl"
+)
+
+// Archiver is implemented by storage backends that support retention.
+// SoftDeleteExpired hides up to limit orders created before cutoff and
+// returns their UIDs; ArchiveDeleted moves up to limit hidden orders to
+// archive storage and returns how many were moved. Both must be safe to
+// interrupt and to run alongside writers.
+type Archiver interface {
+	SoftDeleteExpired(ctx context.Context, cutoff time.Time, limit int) ([]string, error)
+	ArchiveDeleted(ctx context.Context, limit int) (int, error)
+}
+
+// Evicter drops soft-deleted orders from a cache.
+type Evicter interface {
+	Remove(key string)
+}
+
+const (
+	defaultBatchSize = 500
+	defaultInterval  = time.Hour
+)
+
+type Job struct {
+	log       *slog.Logger
+	archiver  Archiver
+	evicter   Evicter
+	maxAge    time.Duration
+	batchSize int
+	interval  time.Duration
+	now       func() time.Time
+}
+
+func New(log *slog.Logger, archiver Archiver, evicter Evicter, cfg config.Retention) *Job {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultInterval
+	}
+
+	return &Job{
+		log:       log.With(slog.String("component", "retention")),
+		archiver:  archiver,
+		evicter:   evicter,
+		maxAge:    time.Duration(cfg.Days) * 24 * time.Hour,
+		batchSize: cfg.BatchSize,
+		interval:  cfg.Interval,
+		now:       time.Now,
+	}
+}
+
+// Run runs the job right away and then every interval until ctx is done.
+func (j *Job) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := j.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			j.log.Error("retention run failed", sl.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce soft-deletes every expired order, evicting it from the cache,
+// and then archives soft-deleted orders batch by batch. Orders hidden by
+// an interrupted run are archived by the next one.
+func (j *Job) RunOnce(ctx context.Context) error {
+	cutoff := j.now().Add(-j.maxAge)
+
+	var deleted, archived int
+	for {
+		uids, err := j.archiver.SoftDeleteExpired(ctx, cutoff, j.batchSize)
+		if err != nil {
+			return err
+		}
+		for _, uid := range uids {
+			j.evicter.Remove(uid)
+		}
+		deleted += len(uids)
+		if len(uids) < j.batchSize {
+			break
+		}
+	}
+
+	for {
+		n, err := j.archiver.ArchiveDeleted(ctx, j.batchSize)
+		if err != nil {
+			return err
+		}
+		archived += n
+		if n < j.batchSize {
+			break
+		}
+	}
+
+	j.log.Info("retention run finished",
+		slog.Time("cutoff", cutoff),
+		slog.Int("soft_deleted", deleted),
+		slog.Int("archived", archived),
+	)
+
+	return nil
+}
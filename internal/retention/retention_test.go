@@ -0,0 +1,111 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sort"
+	"testing"
+	"time"
+	"wb-examples-l0/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeArchiver keeps order creation times by UID.
+type fakeArchiver struct {
+	live     map[string]time.Time
+	deleted  []string
+	archived []string
+	// failArchive makes ArchiveDeleted fail once.
+	failArchive bool
+}
+
+func (f *fakeArchiver) SoftDeleteExpired(_ context.Context, cutoff time.Time, limit int) ([]string, error) {
+	var uids []string
+	for uid, created := range f.live {
+		if created.Before(cutoff) {
+			uids = append(uids, uid)
+		}
+	}
+	sort.Strings(uids)
+	if len(uids) > limit {
+		uids = uids[:limit]
+	}
+
+	for _, uid := range uids {
+		delete(f.live, uid)
+	}
+	f.deleted = append(f.deleted, uids...)
+
+	return uids, nil
+}
+
+func (f *fakeArchiver) ArchiveDeleted(_ context.Context, limit int) (int, error) {
+	if f.failArchive {
+		f.failArchive = false
+		return 0, errors.New("connection reset")
+	}
+
+	n := min(limit, len(f.deleted))
+	f.archived = append(f.archived, f.deleted[:n]...)
+	f.deleted = f.deleted[n:]
+
+	return n, nil
+}
+
+type fakeEvicter struct {
+	removed []string
+}
+
+func (f *fakeEvicter) Remove(key string) {
+	f.removed = append(f.removed, key)
+}
+
+func newTestJob(archiver Archiver, evicter Evicter, now time.Time) *Job {
+	job := New(slog.New(slog.NewTextHandler(io.Discard, nil)), archiver, evicter, config.Retention{
+		Days:      30,
+		BatchSize: 2,
+	})
+	job.now = func() time.Time { return now }
+	return job
+}
+
+func TestJob_RunOnce(t *testing.T) {
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	old := now.AddDate(0, 0, -31)
+
+	archiver := &fakeArchiver{live: map[string]time.Time{
+		"old-1": old, "old-2": old, "old-3": old, "old-4": old, "old-5": old,
+		"new-1": now.AddDate(0, 0, -29),
+	}}
+	evicter := &fakeEvicter{}
+
+	require.NoError(t, newTestJob(archiver, evicter, now).RunOnce(context.Background()))
+
+	want := []string{"old-1", "old-2", "old-3", "old-4", "old-5"}
+	assert.Equal(t, want, evicter.removed)
+	assert.Equal(t, want, archiver.archived)
+	assert.Empty(t, archiver.deleted)
+	assert.Contains(t, archiver.live, "new-1")
+}
+
+func TestJob_RunOnceResumes(t *testing.T) {
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	old := now.AddDate(0, 0, -31)
+
+	archiver := &fakeArchiver{
+		live:        map[string]time.Time{"old-1": old, "old-2": old, "old-3": old},
+		failArchive: true,
+	}
+	job := newTestJob(archiver, &fakeEvicter{}, now)
+
+	assert.Error(t, job.RunOnce(context.Background()))
+	assert.Len(t, archiver.deleted, 3, "expired orders stay hidden after a failure")
+
+	require.NoError(t, job.RunOnce(context.Background()))
+	assert.ElementsMatch(t, []string{"old-1", "old-2", "old-3"}, archiver.archived)
+	assert.Empty(t, archiver.deleted)
+}
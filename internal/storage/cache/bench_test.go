@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"testing"
+	"wb-examples-l0/internal/config"
+	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/storage/memory"
+	"wb-examples-l0/internal/storage/storagetest"
+)
+
+// Run with, e.g.:
+//
+//	go test -run '^$' -bench . -cpu 1,4,16 ./internal/storage/cache
+//
+// and with -race to check the segments under contention.
+
+const benchCapacity = 4096
+
+// benchShards are the configurations compared; 1 is the single LRUCache.
+var benchShards = []int{1, 4, 16, 64}
+
+func newBenchCache(b *testing.B, shards int) Cache {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := config.LruCache{Capacity: benchCapacity, Shards: shards}
+
+	var c Cache
+	var err error
+	if shards == 1 {
+		c, err = NewLRUCache(cfg, memory.New(), log)
+	} else {
+		c, err = NewShardedCache(cfg, memory.New(), log)
+	}
+	if err != nil {
+		b.Fatal(err)
+	}
+	return c
+}
+
+func benchName(shards int) string {
+	if shards == 1 {
+		return "lru"
+	}
+	return fmt.Sprintf("sharded-%d", shards)
+}
+
+func benchOrders(n int) ([]string, []*models.Order) {
+	keys := make([]string, n)
+	orders := make([]*models.Order, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("order-%d", i)
+		orders[i] = storagetest.NewOrder(keys[i], i)
+	}
+	return keys, orders
+}
+
+// BenchmarkCache_Get measures parallel lookups of cached keys.
+func BenchmarkCache_Get(b *testing.B) {
+	keys, orders := benchOrders(benchCapacity)
+
+	for _, shards := range benchShards {
+		c := newBenchCache(b, shards)
+		for i, key := range keys {
+			c.Put(key, orders[i])
+		}
+
+		b.Run(benchName(shards), func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				r := rand.New(rand.NewPCG(rand.Uint64(), 0))
+				for pb.Next() {
+					c.Get(keys[r.IntN(len(keys))])
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkCache_Mixed measures parallel lookups with one put in ten over
+// twice as many keys as the cache holds.
+func BenchmarkCache_Mixed(b *testing.B) {
+	keys, orders := benchOrders(2 * benchCapacity)
+
+	for _, shards := range benchShards {
+		c := newBenchCache(b, shards)
+
+		b.Run(benchName(shards), func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				r := rand.New(rand.NewPCG(rand.Uint64(), 0))
+				for pb.Next() {
+					i := r.IntN(len(keys))
+					if r.IntN(10) == 0 {
+						c.Put(keys[i], orders[i])
+					} else {
+						c.Get(keys[i])
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// Package cache keeps recently requested orders in memory.
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"time"
+	"wb-examples-l0/internal/config"
+	"wb-examples-l0/internal/lib/fieldcrypt"
+	"wb-examples-l0/internal/lib/logger/sl"
+	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/storage"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Cache is an in-memory order cache keyed by order UID.
+type Cache interface {
+	Get(key string) (*models.Order, bool)
+	GetOrLoad(ctx context.Context, key string, loader Loader) (*models.Order, error)
+	Put(key string, val *models.Order)
+	PutWithTTL(key string, val *models.Order, ttl time.Duration)
+	Remove(key string)
+	OrderSaved(order *models.Order)
+	Len() int
+	// Bytes returns the estimated memory of the cached orders.
+	Bytes() int64
+	Stats() Stats
+	Inspect(key string) EntryInfo
+	Purge() int
+	Warm(ctx context.Context) (int, error)
+	SaveSnapshot(path string, keys *fieldcrypt.Keyring) (int, error)
+	LoadSnapshot(path string, maxAge time.Duration, keys *fieldcrypt.Keyring) (int, error)
+	RunJanitor(ctx context.Context)
+	prometheus.Collector
+
+	// startWarm starts tracking the keys invalidated during a warm-up, see
+	// warm. The returned function caches the orders that were not and
+	// returns their number.
+	startWarm() func(orders []*models.Order) int
+}
+
+// EntryInfo describes what a cache holds for a key.
+type EntryInfo struct {
+	Key    string `json:"key"`
+	Cached bool   `json:"cached"`
+	// NotFound is set while the key is remembered as unknown.
+	NotFound bool `json:"not_found"`
+	// ExpiresAt is the expiry of the entry or of the unknown key, unset
+	// for entries without TTL.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Expired is set for expired entries not removed yet; they may still
+	// be served while they are revalidated.
+	Expired bool  `json:"expired"`
+	Bytes   int64 `json:"bytes"`
+}
+
+// New creates the cache configured by cfg: a ShardedCache with more than
+// one shard, an LRUCache otherwise. It starts empty, see Preload.
+func New(cfg config.LruCache, storage storage.OrderRepository, logger *slog.Logger) (Cache, error) {
+	switch cfg.Population {
+	case config.CacheWriteThrough, config.CacheLazy, "":
+	default:
+		return nil, fmt.Errorf("unknown cache population mode %q", cfg.Population)
+	}
+
+	switch cfg.Warmup.OnError {
+	case config.WarmupFail, config.WarmupContinue, "":
+	default:
+		return nil, fmt.Errorf("unknown cache warm-up error policy %q", cfg.Warmup.OnError)
+	}
+
+	if cfg.Shards > 1 {
+		return NewShardedCache(cfg, storage, logger)
+	}
+	return NewLRUCache(cfg, storage, logger)
+}
+
+// preload fills c from the snapshot, if one is configured and fresh, or
+// from storage, and reports the progress to w.
+func preload(ctx context.Context, c Cache, repo storage.OrderRepository, cfg config.LruCache, keys *fieldcrypt.Keyring, logger *slog.Logger, w *Warmup) error {
+	if cfg.SnapshotPath != "" {
+		n, err := c.LoadSnapshot(cfg.SnapshotPath, cfg.SnapshotMaxAge, keys)
+		if err == nil {
+			w.progress(SourceSnapshot, n)
+			logger.Info("Cache loaded from snapshot", "items_loaded", n, "path", cfg.SnapshotPath)
+			// A later start must not restore orders that may have changed
+			// since from the same snapshot.
+			if err := os.Remove(cfg.SnapshotPath); err != nil {
+				logger.Warn("failed to remove cache snapshot", sl.Err(err))
+			}
+			return nil
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			logger.Info("No cache snapshot, preloading from storage", "path", cfg.SnapshotPath)
+		} else {
+			logger.Warn("Cache snapshot not loaded, preloading from storage", "path", cfg.SnapshotPath, sl.Err(err))
+		}
+	}
+
+	n, err := warm(ctx, c, repo, cfg.Capacity, func(fetched int) {
+		w.progress(SourceStorage, fetched)
+		logger.Debug("Preloading cache", "items_fetched", fetched, "capacity", cfg.Capacity)
+	})
+	if err != nil {
+		return err
+	}
+	logger.Info("Cache preloaded", "items_loaded", n)
+	return nil
+}
+
+// warm puts the newest capacity orders into the cache, oldest first, so
+// that the newest are the most recently used, and returns how many it put.
+// Orders removed, saved or purged while they were fetched are skipped, as
+// the fetched copy may predate the change. If progress is not nil it is
+// called with the number of orders fetched so far after every page.
+func warm(ctx context.Context, c Cache, repo storage.OrderRepository, capacity int, progress func(fetched int)) (int, error) {
+	var orders []*models.Order
+
+	finish := c.startWarm()
+
+	filter := storage.OrderFilter{Limit: capacity}
+	for len(orders) < capacity {
+		page, err := repo.ListOrders(ctx, filter)
+		if err != nil {
+			finish(nil)
+			return 0, err
+		}
+
+		orders = append(orders, page.Orders...)
+		if progress != nil {
+			progress(len(orders))
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+		filter.Limit = capacity - len(orders)
+	}
+
+	return finish(orders), nil
+}
@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"unsafe"
+	"wb-examples-l0/internal/models"
+)
+
+// entryOverhead approximates the memory a cache entry takes besides the
+// order and its key: the map entries, the cacheItem and the list element
+// of the policy.
+const entryOverhead = 160
+
+// OrderCost estimates the memory an order takes in bytes: the size of its
+// structs plus the bytes of its strings. It ignores allocator rounding and
+// strings shared with other orders, so it is only good for bounding the
+// cache, not for exact accounting.
+func OrderCost(order *models.Order) int64 {
+	n := int64(unsafe.Sizeof(*order))
+	n += strLen(order.OrderUID, order.TrackNumber, order.Entry, order.Locale,
+		order.InternalSignature, order.CustomerID, order.DeliveryService,
+		order.Shardkey, order.OofShard)
+
+	d := &order.Delivery
+	n += strLen(d.OrderUID, d.Name, d.Phone, d.Zip, d.City, d.Address, d.Region, d.Email)
+
+	p := &order.Payment
+	n += strLen(p.OrderUID, p.Transaction, p.RequestID, p.Currency, p.Provider, p.Bank)
+
+	n += int64(cap(order.Items)) * int64(unsafe.Sizeof(models.Item{}))
+	for i := range order.Items {
+		item := &order.Items[i]
+		n += strLen(item.OrderUID, item.TrackNumber, item.Rid, item.Name, item.Size, item.Brand)
+	}
+
+	return n
+}
+
+func strLen(ss ...string) int64 {
+	var n int64
+	for _, s := range ss {
+		n += int64(len(s))
+	}
+	return n
+}
+
+// entryCost is the estimated memory of caching val under key.
+func (c *LRUCache) entryCost(key string, val *models.Order) int64 {
+	return entryOverhead + 2*int64(len(key)) + c.cost(val)
+}
@@ -2,101 +2,583 @@ package cache
 
 import (
 	"container/list"
+	"context"
+	"errors"
 	"log/slog"
 	"sync"
+	"sync/atomic"
+	"time"
+	"wb-examples-l0/internal/config"
+	"wb-examples-l0/internal/lib/logger/sl"
 	"wb-examples-l0/internal/models"
-	"wb-examples-l0/internal/storage/postgres"
+	"wb-examples-l0/internal/storage"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultJanitorInterval = time.Minute
+	refreshTimeout         = 5 * time.Second
 )
 
 type cacheItem struct {
-	key   string
 	value *models.Order
+	// cost is the estimated memory of the entry in bytes.
+	cost int64
+	// expiresAt is zero for entries that never expire.
+	expiresAt time.Time
+	// refreshing is set while a stale entry is reloaded from storage.
+	refreshing bool
+}
+
+func (i *cacheItem) expired(now time.Time) bool {
+	return !i.expiresAt.IsZero() && !now.Before(i.expiresAt)
 }
 
+// LRUCache is a Cache guarded by a single lock. Its entries are evicted
+// by the configured policy, which is LRU unless set otherwise.
 type LRUCache struct {
 	capacity int
-	storage  *postgres.Storage
-	list     *list.List
-	cache    map[string]*list.Element
-	mu       sync.Mutex
-	logger   *slog.Logger
+	storage  storage.OrderRepository
+	items    map[string]*cacheItem
+	policy   policy
+	// policyName is kept to start over with a new policy on Purge.
+	policyName string
+	mu         sync.Mutex
+	logger     *slog.Logger
+
+	ttl             time.Duration
+	stale           time.Duration
+	janitorInterval time.Duration
+	now             func() time.Time
+	// refreshes tracks background reloads of stale entries.
+	refreshes sync.WaitGroup
+	// loads coalesces concurrent GetOrLoad misses by key.
+	loads singleflight.Group
+	// inflight holds the keys of running loads, see startLoad.
+	inflight map[string]*inflightLoad
+	// warms holds the running warm-ups, see startWarm.
+	warms map[*warmRun]struct{}
+
+	// writeThrough caches the orders passed to OrderSaved.
+	writeThrough bool
+
+	notFound         *list.List
+	notFoundKeys     map[string]*list.Element
+	negativeTTL      time.Duration
+	negativeCapacity int
+
+	// bytes is the estimated memory of all entries. With maxBytes set
+	// entries are evicted until it is at most maxBytes.
+	bytes    int64
+	maxBytes int64
+	cost     func(*models.Order) int64
+
+	hits         atomic.Uint64
+	misses       atomic.Uint64
+	notFoundHits atomic.Uint64
+	// evictions and expirations are guarded by mu.
+	evictions   uint64
+	expirations uint64
 }
 
-func NewLRUCache(capacity int, storage *postgres.Storage, logger *slog.Logger) *LRUCache {
-	cache := &LRUCache{
-		capacity: capacity,
-		storage:  storage,
-		list:     list.New(),
-		cache:    make(map[string]*list.Element),
-		mu:       sync.Mutex{},
-		logger:   logger,
+// inflightLoad tracks the loads of a key. Its generation is bumped when
+// the key is invalidated, so that loads started before don't cache what
+// they read.
+type inflightLoad struct {
+	gen   uint64
+	loads int
+}
+
+// warmRun collects the keys invalidated during a warm-up.
+type warmRun struct {
+	invalidated map[string]struct{}
+	purged      bool
+}
+
+// Loader loads an order missing from the cache.
+type Loader func(ctx context.Context, key string) (*models.Order, error)
+
+func NewLRUCache(cfg config.LruCache, storage storage.OrderRepository, logger *slog.Logger) (*LRUCache, error) {
+	if cfg.JanitorInterval <= 0 {
+		cfg.JanitorInterval = defaultJanitorInterval
 	}
 
-	go cache.preloadCache()
+	if cfg.Policy == "" {
+		cfg.Policy = config.PolicyLRU
+	}
+	policy, err := newPolicy(cfg.Policy, cfg.Capacity)
+	if err != nil {
+		return nil, err
+	}
 
-	return cache
+	return &LRUCache{
+		capacity:         cfg.Capacity,
+		storage:          storage,
+		items:            make(map[string]*cacheItem),
+		policy:           policy,
+		policyName:       cfg.Policy,
+		mu:               sync.Mutex{},
+		logger:           logger,
+		ttl:              max(cfg.TTL, 0),
+		stale:            max(cfg.StaleWhileRevalidate, 0),
+		janitorInterval:  cfg.JanitorInterval,
+		now:              time.Now,
+		writeThrough:     cfg.Population != config.CacheLazy,
+		notFound:         list.New(),
+		notFoundKeys:     make(map[string]*list.Element),
+		negativeTTL:      cfg.NegativeTTL,
+		negativeCapacity: cfg.NegativeCapacity,
+		maxBytes:         max(cfg.MaxBytes, 0),
+		cost:             OrderCost,
+		inflight:         make(map[string]*inflightLoad),
+		warms:            make(map[*warmRun]struct{}),
+	}, nil
 }
 
+// Get returns the order cached under key. An expired entry is a miss,
+// unless stale-while-revalidate is on and the entry expired less than the
+// stale window ago: then it is served once more while it is reloaded
+// from storage in the background.
 func (c *LRUCache) Get(key string) (*models.Order, bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if elem, exists := c.cache[key]; exists {
-		c.list.MoveToFront(elem)
-		item := elem.Value.(*cacheItem)
-		return item.value, true
+	return c.get(key)
+}
+
+// get looks key up and counts the hit or miss. c.mu must be held.
+func (c *LRUCache) get(key string) (*models.Order, bool) {
+	order, ok := c.lookup(key)
+	if ok {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
 	}
-	return nil, false
+	return order, ok
 }
 
+func (c *LRUCache) lookup(key string) (*models.Order, bool) {
+	item, exists := c.items[key]
+	if !exists {
+		return nil, false
+	}
+
+	now := c.now()
+	if item.expired(now) {
+		if item.refreshing {
+			return nil, false
+		}
+		if !now.Before(item.expiresAt.Add(c.stale)) {
+			c.remove(key)
+			c.expirations++
+			c.logger.Debug("Removed expired key from cache", "key", key)
+			return nil, false
+		}
+
+		item.refreshing = true
+		c.refresh(key)
+		c.logger.Debug("Serving stale key while refreshing", "key", key)
+	}
+
+	c.policy.Hit(key)
+	return item.value, true
+}
+
+// GetOrLoad returns the order cached under key or loads it with loader
+// and caches it. Concurrent misses for the same key share one load, which
+// is not canceled when some of the callers give up waiting.
+//
+// A storage.ErrOrderNotFound from loader is remembered for the negative
+// TTL; lookups of the key return ErrNotFoundCached until then. A load the
+// key was removed or saved during is returned but not cached.
+func (c *LRUCache) GetOrLoad(ctx context.Context, key string, loader Loader) (*models.Order, error) {
+	c.mu.Lock()
+	if order, ok := c.lookup(key); ok {
+		c.mu.Unlock()
+		c.hits.Add(1)
+		return order, nil
+	}
+	if c.isNotFound(key) {
+		c.mu.Unlock()
+		c.notFoundHits.Add(1)
+		c.logger.Debug("Key is cached as not found", "key", key)
+		return nil, ErrNotFoundCached
+	}
+	c.mu.Unlock()
+	c.misses.Add(1)
+
+	ch := c.loads.DoChan(key, func() (any, error) {
+		gen := c.startLoad(key)
+		order, err := loader(context.WithoutCancel(ctx), key)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		current := c.finishLoad(key, gen)
+		if errors.Is(err, storage.ErrOrderNotFound) && current {
+			c.putNotFound(key)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if current {
+			c.putWithTTL(key, order, c.ttl)
+		} else {
+			c.logger.Debug("Key was invalidated while loading, not caching it", "key", key)
+		}
+		return order, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*models.Order), nil
+	}
+}
+
+// OrderSaved is called for orders saved by the consumer. It drops the key
+// from the unknown keys and, in write-through mode, caches the order. Like
+// Remove, it keeps running loads of the key from caching what they read.
+func (c *LRUCache) OrderSaved(order *models.Order) {
+	c.loads.Forget(order.OrderUID)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.invalidateLoads(order.OrderUID)
+	if c.writeThrough {
+		c.putWithTTL(order.OrderUID, order, c.ttl)
+		return
+	}
+
+	c.forgetNotFound(order.OrderUID)
+}
+
+// Put caches val under key for the default TTL.
 func (c *LRUCache) Put(key string, val *models.Order) {
+	c.PutWithTTL(key, val, c.ttl)
+}
+
+// PutWithTTL caches val under key for ttl; a ttl of 0 never expires.
+func (c *LRUCache) PutWithTTL(key string, val *models.Order, ttl time.Duration) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if elem, exists := c.cache[key]; exists {
-		item := elem.Value.(*cacheItem)
-		item.value = val
-		c.list.MoveToFront(elem)
+	c.putWithTTL(key, val, ttl)
+}
+
+// putWithTTL is PutWithTTL with c.mu held.
+func (c *LRUCache) putWithTTL(key string, val *models.Order, ttl time.Duration) {
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = c.now().Add(ttl)
+	}
+
+	c.forgetNotFound(key)
+
+	cost := c.entryCost(key, val)
+	if c.maxBytes > 0 && cost > c.maxBytes {
+		if _, exists := c.items[key]; exists {
+			c.remove(key)
+		}
+		c.logger.Debug("Order is too large to cache", "key", key, "bytes", cost)
+		return
+	}
+
+	if item, exists := c.items[key]; exists {
+		c.setValue(item, val, cost)
+		item.expiresAt = expiresAt
+		item.refreshing = false
+		c.policy.Hit(key)
+		c.evictOverBudget()
 		c.logger.Debug("Updated existing key in cache", "key", key)
 		return
 	}
 
-	if c.list.Len() >= c.capacity {
-		c.removeOldest()
+	if victim, evicted := c.policy.Add(key); evicted {
+		c.drop(victim)
+		c.evictions++
+		c.logger.Debug("Evicted key from cache", "key", victim)
 	}
 
-	item := &cacheItem{key: key, value: val}
-	elem := c.list.PushFront(item)
-	c.cache[key] = elem
-	c.logger.Debug("Added new key to cache", "key", key, "cache_size", c.list.Len())
+	c.items[key] = &cacheItem{value: val, cost: cost, expiresAt: expiresAt}
+	c.bytes += cost
+	c.evictOverBudget()
+	c.logger.Debug("Added new key to cache", "key", key, "cache_size", len(c.items), "cache_bytes", c.bytes)
 }
 
-func (c *LRUCache) removeOldest() {
-	elem := c.list.Back()
-	if elem == nil {
-		return
+// Remove drops key from the cache, as an order or an unknown key. Loads of
+// key running at the time are not cached, and later lookups start a new
+// load rather than wait for them.
+func (c *LRUCache) Remove(key string) {
+	c.loads.Forget(key)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.invalidateLoads(key)
+	c.forgetNotFound(key)
+	if _, exists := c.items[key]; exists {
+		c.remove(key)
+		c.logger.Debug("Removed key from cache", "key", key)
 	}
+}
 
-	c.list.Remove(elem)
+// Purge drops all entries and unknown keys and returns the number of
+// entries dropped. The counters are kept.
+func (c *LRUCache) Purge() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	item := elem.Value.(*cacheItem)
-	delete(c.cache, item.key)
-	c.logger.Debug("Removed oldest key from cache", "key", item.key)
+	n := len(c.items)
+	for _, load := range c.inflight {
+		load.gen++
+	}
+	for run := range c.warms {
+		run.purged = true
+	}
+	c.policy, _ = newPolicy(c.policyName, c.capacity)
+	c.items = make(map[string]*cacheItem)
+	c.bytes = 0
+	c.notFound.Init()
+	c.notFoundKeys = make(map[string]*list.Element)
+
+	c.logger.Debug("Purged cache", "count", n)
+	return n
 }
 
-func (c *LRUCache) preloadCache() {
-	uids, err := c.storage.GetAllLimitOrderUIDs(c.capacity)
-	if err != nil {
-		c.logger.Error("error load cache", "error", err)
+// Inspect describes what the cache holds for key without counting it as
+// a lookup or changing its eviction order.
+func (c *LRUCache) Inspect(key string) EntryInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	info := EntryInfo{Key: key}
+	if item, exists := c.items[key]; exists {
+		info.Cached = true
+		info.Bytes = item.cost
+		if !item.expiresAt.IsZero() {
+			expiresAt := item.expiresAt
+			info.ExpiresAt = &expiresAt
+			info.Expired = item.expired(c.now())
+		}
+	}
+	if elem, exists := c.notFoundKeys[key]; exists {
+		expiresAt := elem.Value.(*notFoundItem).expiresAt
+		info.NotFound = c.now().Before(expiresAt)
+		if info.NotFound {
+			info.ExpiresAt = &expiresAt
+		}
+	}
+
+	return info
+}
+
+// Warm loads the newest orders from storage into the cache, up to its
+// capacity, and returns how many were loaded.
+func (c *LRUCache) Warm(ctx context.Context) (int, error) {
+	return warm(ctx, c, c.storage, c.capacity, nil)
+}
+
+// RunJanitor removes expired entries every janitor interval until ctx is
+// done. Entries within the stale window are kept for Get to revalidate.
+func (c *LRUCache) RunJanitor(ctx context.Context) {
+	if c.ttl == 0 && c.negativeTTL <= 0 {
 		return
 	}
 
-	for _, uid := range uids {
-		if order, err := c.storage.GetOrderByUID(uid); err == nil {
-			c.Put(uid, order)
+	ticker := time.NewTicker(c.janitorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n := c.removeExpired(); n > 0 {
+				c.logger.Debug("Removed expired keys from cache", "count", n, "cache_size", c.Len())
+			}
+		}
+	}
+}
+
+// Len returns the number of cached entries, expired ones included.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.items)
+}
+
+func (c *LRUCache) removeExpired() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	var removed int
+	for key, item := range c.items {
+		if item.expired(now) && !item.refreshing && !now.Before(item.expiresAt.Add(c.stale)) {
+			c.remove(key)
+			removed++
+		}
+	}
+	c.expirations += uint64(removed)
+
+	return removed + c.removeExpiredNotFound(now)
+}
+
+// refresh reloads a stale entry in the background. The result is dropped
+// if the entry was put or removed in the meantime.
+func (c *LRUCache) refresh(key string) {
+	c.refreshes.Add(1)
+	go func() {
+		defer c.refreshes.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), refreshTimeout)
+		defer cancel()
+
+		order, err := c.storage.GetOrderByUID(ctx, key)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		item, exists := c.items[key]
+		if !exists || !item.refreshing {
+			return
+		}
+
+		if err != nil {
+			c.remove(key)
+			if !errors.Is(err, storage.ErrOrderNotFound) {
+				c.logger.Warn("failed to refresh cached order", "key", key, sl.Err(err))
+			}
+			return
+		}
+
+		c.setValue(item, order, c.entryCost(key, order))
+		item.refreshing = false
+		if c.ttl > 0 {
+			item.expiresAt = c.now().Add(c.ttl)
 		} else {
-			c.logger.Error("error loading order for cache", "uid", uid, "error", err)
+			item.expiresAt = time.Time{}
 		}
+		c.evictOverBudget()
+		c.logger.Debug("Refreshed stale key in cache", "key", key)
+	}()
+}
+
+// startLoad registers a load of key and returns the generation of the key
+// to pass to finishLoad.
+func (c *LRUCache) startLoad(key string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	load, exists := c.inflight[key]
+	if !exists {
+		load = &inflightLoad{}
+		c.inflight[key] = load
+	}
+	load.loads++
+	return load.gen
+}
+
+// finishLoad unregisters a load of key started at generation gen and
+// reports whether the key has not been invalidated since, i.e. whether
+// the result may be cached. c.mu must be held.
+func (c *LRUCache) finishLoad(key string, gen uint64) bool {
+	load := c.inflight[key]
+	load.loads--
+	if load.loads == 0 {
+		delete(c.inflight, key)
+	}
+	return load.gen == gen
+}
+
+// invalidateLoads keeps the running loads and warm-ups of key from
+// caching their result. c.mu must be held.
+func (c *LRUCache) invalidateLoads(key string) {
+	if load, exists := c.inflight[key]; exists {
+		load.gen++
+	}
+	for run := range c.warms {
+		run.invalidated[key] = struct{}{}
+	}
+}
+
+func (c *LRUCache) startWarm() func(orders []*models.Order) int {
+	run := &warmRun{invalidated: make(map[string]struct{})}
+
+	c.mu.Lock()
+	c.warms[run] = struct{}{}
+	c.mu.Unlock()
+
+	return func(orders []*models.Order) int {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		delete(c.warms, run)
+		if run.purged {
+			c.logger.Debug("Cache was purged while warming, not caching the orders")
+			return 0
+		}
+
+		var n int
+		for i := len(orders) - 1; i >= 0; i-- {
+			key := orders[i].OrderUID
+			if _, skip := run.invalidated[key]; skip {
+				c.logger.Debug("Key was invalidated while warming, not caching it", "key", key)
+				continue
+			}
+			c.putWithTTL(key, orders[i], c.ttl)
+			n++
+		}
+		return n
+	}
+}
+
+// Bytes returns the estimated memory of the cached entries.
+func (c *LRUCache) Bytes() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.bytes
+}
+
+func (c *LRUCache) setValue(item *cacheItem, val *models.Order, cost int64) {
+	c.bytes += cost - item.cost
+	item.value = val
+	item.cost = cost
+}
+
+// evictOverBudget evicts entries until they fit into maxBytes.
+func (c *LRUCache) evictOverBudget() {
+	for c.maxBytes > 0 && c.bytes > c.maxBytes {
+		victim, evicted := c.policy.Evict()
+		if !evicted {
+			return
+		}
+		c.drop(victim)
+		c.evictions++
+		c.logger.Debug("Evicted key from cache to free memory", "key", victim, "cache_bytes", c.bytes)
+	}
+}
+
+// remove drops key from the cache and the policy.
+func (c *LRUCache) remove(key string) {
+	c.policy.Remove(key)
+	c.drop(key)
+}
+
+// drop deletes the entry of a key the policy no longer holds.
+func (c *LRUCache) drop(key string) {
+	if item, exists := c.items[key]; exists {
+		c.bytes -= item.cost
+		delete(c.items, key)
 	}
-	c.logger.Info("Cache preloaded", "items_loaded", len(uids))
 }
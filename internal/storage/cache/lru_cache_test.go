@@ -0,0 +1,529 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"wb-examples-l0/internal/config"
+	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/storage"
+	"wb-examples-l0/internal/storage/memory"
+	"wb-examples-l0/internal/storage/storagetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clock is a manually advanced time source.
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time { return c.now }
+
+func (c *clock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestCache(repo storage.OrderRepository, cfg config.LruCache) (*LRUCache, *clock) {
+	clk := &clock{now: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)}
+	c, err := NewLRUCache(cfg, repo, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		panic(err)
+	}
+	c.now = clk.Now
+	return c, clk
+}
+
+func TestLRUCache_Evict(t *testing.T) {
+	c, _ := newTestCache(memory.New(), config.LruCache{Capacity: 2})
+
+	c.Put("a", storagetest.NewOrder("a", 1))
+	c.Put("b", storagetest.NewOrder("b", 1))
+	_, ok := c.Get("a")
+	require.True(t, ok)
+	c.Put("c", storagetest.NewOrder("c", 1))
+
+	_, ok = c.Get("b")
+	assert.False(t, ok, "least recently used key is evicted")
+	_, ok = c.Get("a")
+	assert.True(t, ok)
+	_, ok = c.Get("c")
+	assert.True(t, ok)
+}
+
+func TestLRUCache_TTL(t *testing.T) {
+	c, clk := newTestCache(memory.New(), config.LruCache{Capacity: 10, TTL: time.Minute})
+
+	c.Put("a", storagetest.NewOrder("a", 1))
+	c.PutWithTTL("b", storagetest.NewOrder("b", 1), time.Hour)
+	c.PutWithTTL("c", storagetest.NewOrder("c", 1), 0)
+
+	clk.Advance(59 * time.Second)
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+
+	clk.Advance(time.Second)
+	_, ok = c.Get("a")
+	assert.False(t, ok, "entry expires after the default TTL")
+	_, ok = c.Get("b")
+	assert.True(t, ok, "per-entry TTL overrides the default")
+
+	clk.Advance(24 * time.Hour)
+	_, ok = c.Get("c")
+	assert.True(t, ok, "zero TTL never expires")
+
+	// Putting a key again renews it.
+	c.Put("a", storagetest.NewOrder("a", 1))
+	clk.Advance(30 * time.Second)
+	_, ok = c.Get("a")
+	assert.True(t, ok)
+}
+
+func TestLRUCache_RemoveExpired(t *testing.T) {
+	c, clk := newTestCache(memory.New(), config.LruCache{Capacity: 10, TTL: time.Minute})
+
+	c.Put("a", storagetest.NewOrder("a", 1))
+	c.Put("b", storagetest.NewOrder("b", 1))
+	clk.Advance(30 * time.Second)
+	c.Put("c", storagetest.NewOrder("c", 1))
+	clk.Advance(30 * time.Second)
+
+	assert.Equal(t, 2, c.removeExpired())
+	assert.Equal(t, 1, c.Len())
+	_, ok := c.Get("c")
+	assert.True(t, ok)
+}
+
+func TestLRUCache_StaleWhileRevalidate(t *testing.T) {
+	repo := memory.New()
+	c, clk := newTestCache(repo, config.LruCache{
+		Capacity:             10,
+		TTL:                  time.Minute,
+		StaleWhileRevalidate: time.Minute,
+	})
+
+	stale := storagetest.NewOrder("a", 1)
+	c.Put("a", stale)
+
+	fresh := storagetest.NewOrder("a", 1)
+	fresh.TrackNumber = "WBILMFRESHTRACK"
+	require.NoError(t, repo.SaveOrder(context.Background(), fresh))
+
+	clk.Advance(90 * time.Second)
+	assert.Zero(t, c.removeExpired(), "janitor keeps entries within the stale window")
+
+	got, ok := c.Get("a")
+	require.True(t, ok, "stale entry is served once")
+	assert.Same(t, stale, got)
+
+	c.refreshes.Wait()
+
+	got, ok = c.Get("a")
+	require.True(t, ok)
+	assert.Equal(t, "WBILMFRESHTRACK", got.TrackNumber, "entry is refreshed from storage")
+
+	// A stale entry whose order is gone is dropped by the refresh.
+	c.Put("gone", storagetest.NewOrder("gone", 1))
+	clk.Advance(90 * time.Second)
+	_, ok = c.Get("gone")
+	require.True(t, ok)
+	c.refreshes.Wait()
+	_, ok = c.Get("gone")
+	assert.False(t, ok)
+
+	// Past the stale window an entry is a plain miss.
+	c.Put("old", storagetest.NewOrder("old", 1))
+	clk.Advance(2 * time.Minute)
+	_, ok = c.Get("old")
+	assert.False(t, ok)
+}
+
+type blockingRepo struct {
+	storage.OrderRepository
+	release chan struct{}
+}
+
+func (r *blockingRepo) GetOrderByUID(ctx context.Context, orderUID string) (*models.Order, error) {
+	<-r.release
+	return nil, errors.New("unavailable")
+}
+
+func TestLRUCache_StaleServedOnce(t *testing.T) {
+	repo := &blockingRepo{OrderRepository: memory.New(), release: make(chan struct{})}
+	c, clk := newTestCache(repo, config.LruCache{
+		Capacity:             10,
+		TTL:                  time.Minute,
+		StaleWhileRevalidate: time.Minute,
+	})
+
+	c.Put("a", storagetest.NewOrder("a", 1))
+	clk.Advance(90 * time.Second)
+
+	_, ok := c.Get("a")
+	require.True(t, ok)
+	_, ok = c.Get("a")
+	assert.False(t, ok, "entry is not served again while it is refreshed")
+
+	close(repo.release)
+	c.refreshes.Wait()
+	assert.Equal(t, 0, c.Len(), "failed refresh drops the entry")
+}
+
+func TestLRUCache_GetOrLoad(t *testing.T) {
+	c, _ := newTestCache(memory.New(), config.LruCache{Capacity: 10})
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	loader := func(ctx context.Context, key string) (*models.Order, error) {
+		calls.Add(1)
+		<-release
+		return storagetest.NewOrder(key, 1), nil
+	}
+
+	const n = 20
+	var wg sync.WaitGroup
+	orders := make([]*models.Order, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			order, err := c.GetOrLoad(context.Background(), "a", loader)
+			assert.NoError(t, err)
+			orders[i] = order
+		}()
+	}
+
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load(), "concurrent misses share one load")
+	for _, order := range orders {
+		assert.Same(t, orders[0], order)
+	}
+
+	// The loaded order is cached.
+	_, err := c.GetOrLoad(context.Background(), "a", loader)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestLRUCache_GetOrLoadError(t *testing.T) {
+	c, _ := newTestCache(memory.New(), config.LruCache{Capacity: 10})
+
+	_, err := c.GetOrLoad(context.Background(), "a", memory.New().GetOrderByUID)
+	require.ErrorIs(t, err, storage.ErrOrderNotFound)
+	assert.Equal(t, 0, c.Len(), "errors are not cached")
+
+	// A caller that gives up doesn't cancel the load.
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := c.GetOrLoad(ctx, "b", func(ctx context.Context, key string) (*models.Order, error) {
+			<-release
+			return storagetest.NewOrder(key, 1), ctx.Err()
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+	}()
+
+	cancel()
+	<-done
+	close(release)
+
+	require.Eventually(t, func() bool {
+		_, ok := c.Get("b")
+		return ok
+	}, time.Second, time.Millisecond)
+}
+
+func TestLRUCache_GetOrLoadInvalidated(t *testing.T) {
+	for name, invalidate := range map[string]func(c *LRUCache){
+		"remove": func(c *LRUCache) { c.Remove("a") },
+		"saved":  func(c *LRUCache) { c.OrderSaved(storagetest.NewOrder("a", 2)) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			c, _ := newTestCache(memory.New(), config.LruCache{Capacity: 10, Population: config.CacheLazy})
+
+			started := make(chan struct{})
+			release := make(chan struct{})
+			stale := storagetest.NewOrder("a", 1)
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				order, err := c.GetOrLoad(context.Background(), "a", func(ctx context.Context, key string) (*models.Order, error) {
+					close(started)
+					<-release
+					return stale, nil
+				})
+				assert.NoError(t, err)
+				assert.Same(t, stale, order, "the caller still gets the load")
+			}()
+
+			<-started
+			invalidate(c)
+
+			// A lookup after the invalidation doesn't join the old load.
+			fresh := storagetest.NewOrder("a", 3)
+			order, err := c.GetOrLoad(context.Background(), "a", func(ctx context.Context, key string) (*models.Order, error) {
+				return fresh, nil
+			})
+			require.NoError(t, err)
+			assert.Same(t, fresh, order)
+
+			close(release)
+			<-done
+
+			got, ok := c.Get("a")
+			require.True(t, ok)
+			assert.Same(t, fresh, got, "the load started before the invalidation is not cached")
+			assert.Empty(t, c.inflight)
+		})
+	}
+}
+
+func TestLRUCache_NotFound(t *testing.T) {
+	repo := memory.New()
+	c, clk := newTestCache(repo, config.LruCache{
+		Capacity:         10,
+		NegativeTTL:      time.Minute,
+		NegativeCapacity: 2,
+	})
+
+	var calls int
+	loader := func(ctx context.Context, key string) (*models.Order, error) {
+		calls++
+		return repo.GetOrderByUID(ctx, key)
+	}
+
+	_, err := c.GetOrLoad(context.Background(), "a", loader)
+	require.ErrorIs(t, err, storage.ErrOrderNotFound)
+	require.NotErrorIs(t, err, ErrNotFoundCached)
+
+	_, err = c.GetOrLoad(context.Background(), "a", loader)
+	require.ErrorIs(t, err, ErrNotFoundCached)
+	require.ErrorIs(t, err, storage.ErrOrderNotFound)
+	assert.Equal(t, 1, calls, "unknown key is answered from the cache")
+	assert.Equal(t, uint64(1), c.notFoundHits.Load())
+
+	// Saving the order drops the unknown key even in lazy mode.
+	c.writeThrough = false
+	order := storagetest.NewOrder("a", 1)
+	require.NoError(t, repo.SaveOrder(context.Background(), order))
+	c.OrderSaved(order)
+	got, err := c.GetOrLoad(context.Background(), "a", loader)
+	require.NoError(t, err)
+	assert.Equal(t, "a", got.OrderUID)
+	assert.Equal(t, 2, calls)
+
+	// Unknown keys expire.
+	_, err = c.GetOrLoad(context.Background(), "b", loader)
+	require.ErrorIs(t, err, storage.ErrOrderNotFound)
+	clk.Advance(time.Minute)
+	_, err = c.GetOrLoad(context.Background(), "b", loader)
+	require.NotErrorIs(t, err, ErrNotFoundCached)
+	assert.Equal(t, 4, calls)
+
+	// Unknown keys are bounded separately and don't evict orders.
+	for _, key := range []string{"c", "d", "e"} {
+		_, err = c.GetOrLoad(context.Background(), key, loader)
+		require.ErrorIs(t, err, storage.ErrOrderNotFound)
+	}
+	assert.Equal(t, 2, c.notFound.Len())
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+}
+
+func TestLRUCache_OrderSaved(t *testing.T) {
+	c, _ := newTestCache(memory.New(), config.LruCache{Capacity: 10, Population: config.CacheWriteThrough})
+	c.OrderSaved(storagetest.NewOrder("a", 1))
+	_, ok := c.Get("a")
+	assert.True(t, ok, "write-through caches saved orders")
+
+	c, _ = newTestCache(memory.New(), config.LruCache{Capacity: 10, Population: config.CacheLazy})
+	c.OrderSaved(storagetest.NewOrder("a", 1))
+	_, ok = c.Get("a")
+	assert.False(t, ok, "lazy mode doesn't")
+}
+
+func TestOrderCost(t *testing.T) {
+	small := storagetest.NewOrder("a", 1)
+	large := storagetest.NewOrder("b", 1)
+	for range 199 {
+		large.Items = append(large.Items, large.Items[0])
+	}
+
+	assert.Greater(t, OrderCost(small), int64(0))
+	assert.Greater(t, OrderCost(large), 100*OrderCost(small)/2, "cost grows with the items")
+}
+
+func TestLRUCache_MaxBytes(t *testing.T) {
+	c, _ := newTestCache(memory.New(), config.LruCache{Capacity: 100})
+	one := c.entryCost("order-0", storagetest.NewOrder("order-0", 1))
+
+	c, _ = newTestCache(memory.New(), config.LruCache{Capacity: 100, MaxBytes: 3 * one})
+	for _, key := range []string{"order-0", "order-1", "order-2", "order-3"} {
+		c.Put(key, storagetest.NewOrder(key, 1))
+	}
+
+	assert.Equal(t, 3, c.Len(), "entries are evicted to stay within the budget")
+	assert.Equal(t, 3*one, c.Bytes())
+	_, ok := c.Get("order-0")
+	assert.False(t, ok, "the least recently used entry is evicted")
+
+	// An order larger than the whole budget is not cached.
+	large := storagetest.NewOrder("order-4", 1)
+	for range 100 {
+		large.Items = append(large.Items, large.Items[0])
+	}
+	c.Put("order-4", large)
+	_, ok = c.Get("order-4")
+	assert.False(t, ok)
+	assert.Equal(t, 3, c.Len())
+
+	c.Remove("order-1")
+	assert.Equal(t, 2*one, c.Bytes(), "removed entries free their bytes")
+}
+
+func TestLRUCache_Stats(t *testing.T) {
+	c, clk := newTestCache(memory.New(), config.LruCache{
+		Capacity:         2,
+		TTL:              time.Minute,
+		NegativeTTL:      time.Minute,
+		NegativeCapacity: 10,
+	})
+
+	c.Put("a", storagetest.NewOrder("a", 1))
+	c.Put("b", storagetest.NewOrder("b", 1))
+	c.Put("c", storagetest.NewOrder("c", 1))
+	c.Get("c")
+	c.Get("a")
+	_, err := c.GetOrLoad(context.Background(), "x", memory.New().GetOrderByUID)
+	require.ErrorIs(t, err, storage.ErrOrderNotFound)
+	_, err = c.GetOrLoad(context.Background(), "x", memory.New().GetOrderByUID)
+	require.ErrorIs(t, err, ErrNotFoundCached)
+
+	clk.Advance(time.Minute)
+	c.Get("b")
+
+	st := c.Stats()
+	assert.Equal(t, config.PolicyLRU, st.Policy)
+	assert.Equal(t, uint64(1), st.Hits)
+	assert.Equal(t, uint64(3), st.Misses)
+	assert.Equal(t, uint64(1), st.NotFoundHits)
+	assert.Equal(t, uint64(1), st.Evictions)
+	assert.Equal(t, uint64(1), st.Expirations)
+	assert.Equal(t, 1, st.Entries)
+	assert.Equal(t, 2, st.Capacity)
+	assert.Equal(t, 1, st.NotFound)
+	assert.InDelta(t, 0.4, st.HitRatio(), 0.001)
+}
+
+func TestLRUCache_Inspect(t *testing.T) {
+	c, clk := newTestCache(memory.New(), config.LruCache{
+		Capacity:         10,
+		TTL:              time.Minute,
+		NegativeTTL:      time.Minute,
+		NegativeCapacity: 10,
+	})
+
+	c.Put("a", storagetest.NewOrder("a", 1))
+	c.putNotFound("x")
+
+	info := c.Inspect("a")
+	assert.True(t, info.Cached)
+	assert.False(t, info.NotFound)
+	require.NotNil(t, info.ExpiresAt)
+	assert.Equal(t, clk.Now().Add(time.Minute), *info.ExpiresAt)
+	assert.Positive(t, info.Bytes)
+
+	info = c.Inspect("x")
+	assert.False(t, info.Cached)
+	assert.True(t, info.NotFound)
+
+	assert.Equal(t, EntryInfo{Key: "y"}, c.Inspect("y"))
+	assert.Zero(t, c.Stats().Hits+c.Stats().Misses, "inspecting is not a lookup")
+
+	clk.Advance(time.Minute)
+	info = c.Inspect("a")
+	assert.True(t, info.Cached)
+	assert.True(t, info.Expired)
+
+	c.Remove("x")
+	assert.False(t, c.Inspect("x").NotFound, "Remove drops unknown keys too")
+}
+
+func TestLRUCache_PurgeAndWarm(t *testing.T) {
+	repo := memory.New()
+	for _, uid := range []string{"a", "b", "c"} {
+		require.NoError(t, repo.SaveOrder(context.Background(), storagetest.NewOrder(uid, 1)))
+	}
+
+	c, _ := newTestCache(repo, config.LruCache{Capacity: 2, Policy: config.PolicyARC, NegativeTTL: time.Minute, NegativeCapacity: 10})
+	c.Put("x", storagetest.NewOrder("x", 1))
+	c.putNotFound("y")
+
+	assert.Equal(t, 1, c.Purge())
+	assert.Equal(t, 0, c.Len())
+	assert.Zero(t, c.Bytes())
+	assert.False(t, c.Inspect("y").NotFound)
+
+	n, err := c.Warm(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 2, c.Len())
+}
+
+// hookRepo calls afterList once the orders are listed, before warm caches
+// them.
+type hookRepo struct {
+	storage.OrderRepository
+	afterList func()
+}
+
+func (r *hookRepo) ListOrders(ctx context.Context, filter storage.OrderFilter) (*storage.OrderPage, error) {
+	page, err := r.OrderRepository.ListOrders(ctx, filter)
+	r.afterList()
+	return page, err
+}
+
+func TestWarm_Invalidated(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for _, tt := range []struct {
+		name       string
+		shards     int
+		invalidate func(c Cache)
+		want       []string
+	}{
+		{"remove", 1, func(c Cache) { c.Remove("a") }, []string{"b", "c"}},
+		{"saved", 1, func(c Cache) { c.OrderSaved(storagetest.NewOrder("b", 2)) }, []string{"a", "c"}},
+		{"purge", 1, func(c Cache) { c.Purge() }, nil},
+		{"sharded remove", 4, func(c Cache) { c.Remove("a") }, []string{"b", "c"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &hookRepo{OrderRepository: memory.New()}
+			for _, uid := range []string{"a", "b", "c"} {
+				require.NoError(t, repo.SaveOrder(context.Background(), storagetest.NewOrder(uid, 1)))
+			}
+
+			cfg := config.LruCache{Capacity: 10, Shards: tt.shards, Population: config.CacheLazy}
+			c, err := New(cfg, repo, logger)
+			require.NoError(t, err)
+			repo.afterList = func() { tt.invalidate(c) }
+
+			n, err := c.Warm(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, len(tt.want), n)
+			assert.Equal(t, len(tt.want), c.Len())
+			for _, uid := range tt.want {
+				_, ok := c.Get(uid)
+				assert.True(t, ok, uid)
+			}
+		})
+	}
+}
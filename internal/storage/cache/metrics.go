@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	lookupsTotal = prometheus.NewDesc(
+		"orders_cache_lookups_total",
+		"Number of order lookups by result: hit, miss or not_found_hit for unknown UIDs answered from the cache.",
+		[]string{"result"}, nil,
+	)
+	evictionsTotal = prometheus.NewDesc(
+		"orders_cache_evictions_total",
+		"Number of entries evicted to make room for others.",
+		nil, nil,
+	)
+	expirationsTotal = prometheus.NewDesc(
+		"orders_cache_expirations_total",
+		"Number of entries removed because their TTL ran out.",
+		nil, nil,
+	)
+	cacheEntries = prometheus.NewDesc(
+		"orders_cache_entries",
+		"Number of cached orders.",
+		nil, nil,
+	)
+	cacheCapacity = prometheus.NewDesc(
+		"orders_cache_capacity",
+		"Maximum number of cached orders.",
+		nil, nil,
+	)
+	cacheBytes = prometheus.NewDesc(
+		"orders_cache_bytes",
+		"Estimated memory of the cached orders.",
+		nil, nil,
+	)
+	cacheMaxBytes = prometheus.NewDesc(
+		"orders_cache_max_bytes",
+		"Memory limit of the cached orders, 0 if there is none.",
+		nil, nil,
+	)
+	notFoundEntries = prometheus.NewDesc(
+		"orders_cache_not_found_entries",
+		"Number of remembered unknown order UIDs.",
+		nil, nil,
+	)
+)
+
+// Stats is a snapshot of the cache counters and size.
+type Stats struct {
+	Policy       string `json:"policy"`
+	Hits         uint64 `json:"hits"`
+	Misses       uint64 `json:"misses"`
+	NotFoundHits uint64 `json:"not_found_hits"`
+	Evictions    uint64 `json:"evictions"`
+	Expirations  uint64 `json:"expirations"`
+	Entries      int    `json:"entries"`
+	Capacity     int    `json:"capacity"`
+	Bytes        int64  `json:"bytes"`
+	MaxBytes     int64  `json:"max_bytes"`
+	NotFound     int    `json:"not_found_entries"`
+}
+
+// HitRatio returns the share of lookups answered from the cache.
+func (s Stats) HitRatio() float64 {
+	total := s.Hits + s.Misses + s.NotFoundHits
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits+s.NotFoundHits) / float64(total)
+}
+
+func (s *Stats) add(other Stats) {
+	s.Policy = other.Policy
+	s.Hits += other.Hits
+	s.Misses += other.Misses
+	s.NotFoundHits += other.NotFoundHits
+	s.Evictions += other.Evictions
+	s.Expirations += other.Expirations
+	s.Entries += other.Entries
+	s.Capacity += other.Capacity
+	s.Bytes += other.Bytes
+	s.MaxBytes += other.MaxBytes
+	s.NotFound += other.NotFound
+}
+
+func (s Stats) collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(lookupsTotal, prometheus.CounterValue, float64(s.Hits), "hit")
+	ch <- prometheus.MustNewConstMetric(lookupsTotal, prometheus.CounterValue, float64(s.Misses), "miss")
+	ch <- prometheus.MustNewConstMetric(lookupsTotal, prometheus.CounterValue, float64(s.NotFoundHits), "not_found_hit")
+	ch <- prometheus.MustNewConstMetric(evictionsTotal, prometheus.CounterValue, float64(s.Evictions))
+	ch <- prometheus.MustNewConstMetric(expirationsTotal, prometheus.CounterValue, float64(s.Expirations))
+	ch <- prometheus.MustNewConstMetric(cacheEntries, prometheus.GaugeValue, float64(s.Entries))
+	ch <- prometheus.MustNewConstMetric(cacheCapacity, prometheus.GaugeValue, float64(s.Capacity))
+	ch <- prometheus.MustNewConstMetric(cacheBytes, prometheus.GaugeValue, float64(s.Bytes))
+	ch <- prometheus.MustNewConstMetric(cacheMaxBytes, prometheus.GaugeValue, float64(s.MaxBytes))
+	ch <- prometheus.MustNewConstMetric(notFoundEntries, prometheus.GaugeValue, float64(s.NotFound))
+}
+
+func describe(ch chan<- *prometheus.Desc) {
+	ch <- lookupsTotal
+	ch <- evictionsTotal
+	ch <- expirationsTotal
+	ch <- cacheEntries
+	ch <- cacheCapacity
+	ch <- cacheBytes
+	ch <- cacheMaxBytes
+	ch <- notFoundEntries
+}
+
+// Stats returns the current counters and size of the cache.
+func (c *LRUCache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return Stats{
+		Policy:       c.policyName,
+		Hits:         c.hits.Load(),
+		Misses:       c.misses.Load(),
+		NotFoundHits: c.notFoundHits.Load(),
+		Evictions:    c.evictions,
+		Expirations:  c.expirations,
+		Entries:      len(c.items),
+		Capacity:     c.capacity,
+		Bytes:        c.bytes,
+		MaxBytes:     c.maxBytes,
+		NotFound:     c.notFound.Len(),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *LRUCache) Describe(ch chan<- *prometheus.Desc) {
+	describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *LRUCache) Collect(ch chan<- prometheus.Metric) {
+	c.Stats().collect(ch)
+}
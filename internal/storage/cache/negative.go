@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"container/list"
+	"fmt"
+	"time"
+	"wb-examples-l0/internal/storage"
+)
+
+// ErrNotFoundCached is returned by GetOrLoad for keys storage recently had
+// no order for. It wraps storage.ErrOrderNotFound.
+var ErrNotFoundCached = fmt.Errorf("cached: %w", storage.ErrOrderNotFound)
+
+// notFoundItem is a remembered unknown key. Unknown keys are kept in a
+// list of their own, so a client asking for random UIDs evicts only other
+// unknown keys and never cached orders.
+type notFoundItem struct {
+	key       string
+	expiresAt time.Time
+}
+
+// isNotFound reports whether key is a remembered unknown key and drops it
+// if it has expired. c.mu must be held.
+func (c *LRUCache) isNotFound(key string) bool {
+	elem, exists := c.notFoundKeys[key]
+	if !exists {
+		return false
+	}
+
+	if !c.now().Before(elem.Value.(*notFoundItem).expiresAt) {
+		c.removeNotFound(elem)
+		return false
+	}
+
+	c.notFound.MoveToFront(elem)
+	return true
+}
+
+// putNotFound remembers key as unknown for the negative TTL. c.mu must be
+// held.
+func (c *LRUCache) putNotFound(key string) {
+	if c.negativeTTL <= 0 || c.negativeCapacity <= 0 {
+		return
+	}
+
+	expiresAt := c.now().Add(c.negativeTTL)
+	if elem, exists := c.notFoundKeys[key]; exists {
+		elem.Value.(*notFoundItem).expiresAt = expiresAt
+		c.notFound.MoveToFront(elem)
+		return
+	}
+
+	if c.notFound.Len() >= c.negativeCapacity {
+		c.removeNotFound(c.notFound.Back())
+	}
+
+	c.notFoundKeys[key] = c.notFound.PushFront(&notFoundItem{key: key, expiresAt: expiresAt})
+	c.logger.Debug("Added not found key to cache", "key", key, "not_found_size", c.notFound.Len())
+}
+
+// forgetNotFound drops key from the unknown keys. c.mu must be held.
+func (c *LRUCache) forgetNotFound(key string) {
+	if elem, exists := c.notFoundKeys[key]; exists {
+		c.removeNotFound(elem)
+		c.logger.Debug("Removed not found key from cache", "key", key)
+	}
+}
+
+func (c *LRUCache) removeNotFound(elem *list.Element) {
+	c.notFound.Remove(elem)
+	delete(c.notFoundKeys, elem.Value.(*notFoundItem).key)
+}
+
+// removeExpiredNotFound drops expired unknown keys. c.mu must be held.
+func (c *LRUCache) removeExpiredNotFound(now time.Time) int {
+	var removed int
+	for elem := c.notFound.Back(); elem != nil; {
+		prev := elem.Prev()
+		if !now.Before(elem.Value.(*notFoundItem).expiresAt) {
+			c.removeNotFound(elem)
+			removed++
+		}
+		elem = prev
+	}
+
+	return removed
+}
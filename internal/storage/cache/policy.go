@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"container/list"
+	"fmt"
+	"iter"
+	"wb-examples-l0/internal/config"
+)
+
+// policy decides which keys a cache keeps. The cache stores the values and
+// tells the policy about every key it adds, hits and removes; the policy
+// holds up to its capacity keys and picks the victims. Policies are not
+// safe for concurrent use, the cache calls them under its lock.
+type policy interface {
+	// Add records a key that is not cached yet. If the policy is over
+	// capacity it evicts a key, which may be an older key but never key
+	// itself, and returns it.
+	Add(key string) (victim string, evicted bool)
+	// Hit records an access to a cached key.
+	Hit(key string)
+	// Remove forgets a key the cache dropped on its own.
+	Remove(key string)
+	// Evict drops the key the policy values least and returns it.
+	Evict() (victim string, evicted bool)
+	// Keys yields the cached keys from the one the policy values least to
+	// the one it values most, so adding them in this order to an empty
+	// policy approximately restores it.
+	Keys() iter.Seq[string]
+}
+
+// Policies are the names of the eviction policies, see newPolicy.
+var Policies = []string{config.PolicyLRU, config.PolicyLFU, config.PolicyARC, config.PolicyTinyLFU}
+
+func newPolicy(name string, capacity int) (policy, error) {
+	capacity = max(capacity, 1)
+
+	switch name {
+	case config.PolicyLRU, "":
+		return newLRUPolicy(capacity), nil
+	case config.PolicyLFU:
+		return newLFUPolicy(capacity), nil
+	case config.PolicyARC:
+		return newARCPolicy(capacity), nil
+	case config.PolicyTinyLFU:
+		return newTinyLFUPolicy(capacity), nil
+	default:
+		return nil, fmt.Errorf("unknown cache policy %q", name)
+	}
+}
+
+// backToFront yields the keys of the elements of l from the back, the
+// least recently used end, to the front.
+func backToFront(l *list.List, key func(*list.Element) string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for elem := l.Back(); elem != nil; elem = elem.Prev() {
+			if !yield(key(elem)) {
+				return
+			}
+		}
+	}
+}
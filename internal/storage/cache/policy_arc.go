@@ -0,0 +1,189 @@
+package cache
+
+import (
+	"container/list"
+	"iter"
+)
+
+type arcList int
+
+const (
+	arcT1 arcList = iota // cached, seen once recently
+	arcT2                // cached, seen at least twice recently
+	arcB1                // evicted from t1, key only
+	arcB2                // evicted from t2, key only
+)
+
+type arcEntry struct {
+	key  string
+	list arcList
+}
+
+// arcPolicy is the Adaptive Replacement Cache of Megiddo and Modha. It
+// splits the cache between recently and frequently used keys and adapts
+// the split, the target size p of t1, to hits on recently evicted keys
+// remembered in the ghost lists b1 and b2. A scan only passes through t1
+// and leaves the frequently used keys in t2 alone.
+type arcPolicy struct {
+	capacity int
+	p        int
+	lists    [4]*list.List
+	elems    map[string]*list.Element
+}
+
+func newARCPolicy(capacity int) *arcPolicy {
+	p := &arcPolicy{
+		capacity: capacity,
+		elems:    make(map[string]*list.Element),
+	}
+	for i := range p.lists {
+		p.lists[i] = list.New()
+	}
+	return p
+}
+
+func (p *arcPolicy) len(l arcList) int {
+	return p.lists[l].Len()
+}
+
+func (p *arcPolicy) cached() int {
+	return p.len(arcT1) + p.len(arcT2)
+}
+
+func (p *arcPolicy) Add(key string) (string, bool) {
+	var victim string
+	var evicted bool
+
+	if elem, ok := p.elems[key]; ok {
+		// A ghost hit: grow the side the key was evicted from.
+		inB2 := elem.Value.(*arcEntry).list == arcB2
+		if inB2 {
+			p.p = max(p.p-max(p.len(arcB1)/p.len(arcB2), 1), 0)
+		} else {
+			p.p = min(p.p+max(p.len(arcB2)/p.len(arcB1), 1), p.capacity)
+		}
+
+		p.unlink(elem)
+		if p.cached() >= p.capacity {
+			victim, evicted = p.replace(inB2)
+		}
+		p.push(key, arcT2)
+		return victim, evicted
+	}
+
+	switch {
+	case p.len(arcT1)+p.len(arcB1) >= p.capacity:
+		if p.len(arcT1) < p.capacity {
+			p.dropLRU(arcB1)
+			if p.cached() >= p.capacity {
+				victim, evicted = p.replace(false)
+			}
+		} else {
+			victim, evicted = p.evictLRU(arcT1)
+		}
+	case p.cached()+p.len(arcB1)+p.len(arcB2) >= p.capacity:
+		if p.cached()+p.len(arcB1)+p.len(arcB2) >= 2*p.capacity {
+			p.dropLRU(arcB2)
+		}
+		if p.cached() >= p.capacity {
+			victim, evicted = p.replace(false)
+		}
+	}
+
+	p.push(key, arcT1)
+	return victim, evicted
+}
+
+func (p *arcPolicy) Hit(key string) {
+	elem, ok := p.elems[key]
+	if !ok {
+		return
+	}
+
+	if l := elem.Value.(*arcEntry).list; l == arcT1 || l == arcT2 {
+		p.unlink(elem)
+		p.push(key, arcT2)
+	}
+}
+
+func (p *arcPolicy) Remove(key string) {
+	if elem, ok := p.elems[key]; ok {
+		p.unlink(elem)
+	}
+}
+
+func (p *arcPolicy) Evict() (string, bool) {
+	if p.cached() == 0 {
+		return "", false
+	}
+	return p.replace(false)
+}
+
+// Keys yields the cached keys of t1 and then of t2, each from the LRU end.
+// The ghost lists are not cached and not yielded.
+func (p *arcPolicy) Keys() iter.Seq[string] {
+	key := func(elem *list.Element) string {
+		return elem.Value.(*arcEntry).key
+	}
+	return func(yield func(string) bool) {
+		for k := range backToFront(p.lists[arcT1], key) {
+			if !yield(k) {
+				return
+			}
+		}
+		for k := range backToFront(p.lists[arcT2], key) {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// replace evicts the LRU key of t1 or t2, depending on the target p, into
+// its ghost list.
+func (p *arcPolicy) replace(inB2 bool) (string, bool) {
+	t1 := p.len(arcT1)
+	if t1 > 0 && (t1 > p.p || (inB2 && t1 == p.p) || p.len(arcT2) == 0) {
+		return p.demote(arcT1, arcB1)
+	}
+	return p.demote(arcT2, arcB2)
+}
+
+func (p *arcPolicy) demote(from, to arcList) (string, bool) {
+	elem := p.lists[from].Back()
+	if elem == nil {
+		return "", false
+	}
+
+	key := elem.Value.(*arcEntry).key
+	p.unlink(elem)
+	p.push(key, to)
+	return key, true
+}
+
+func (p *arcPolicy) evictLRU(l arcList) (string, bool) {
+	elem := p.lists[l].Back()
+	if elem == nil {
+		return "", false
+	}
+
+	key := elem.Value.(*arcEntry).key
+	p.unlink(elem)
+	return key, true
+}
+
+func (p *arcPolicy) dropLRU(l arcList) {
+	if elem := p.lists[l].Back(); elem != nil {
+		p.unlink(elem)
+	}
+}
+
+func (p *arcPolicy) push(key string, l arcList) {
+	p.elems[key] = p.lists[l].PushFront(&arcEntry{key: key, list: l})
+}
+
+func (p *arcPolicy) unlink(elem *list.Element) {
+	e := elem.Value.(*arcEntry)
+	p.lists[e.list].Remove(elem)
+	delete(p.elems, e.key)
+}
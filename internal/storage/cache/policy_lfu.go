@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"container/list"
+	"iter"
+	"maps"
+	"slices"
+)
+
+type lfuEntry struct {
+	key  string
+	freq int
+	elem *list.Element
+}
+
+// lfuPolicy evicts the least frequently used key, the least recently used
+// one among keys with the same count. Keys with equal counts share a list,
+// so every operation is O(1) except finding the next lowest count after
+// the last key with the lowest one is removed.
+type lfuPolicy struct {
+	capacity int
+	entries  map[string]*lfuEntry
+	freqs    map[int]*list.List
+	minFreq  int
+}
+
+func newLFUPolicy(capacity int) *lfuPolicy {
+	return &lfuPolicy{
+		capacity: capacity,
+		entries:  make(map[string]*lfuEntry),
+		freqs:    make(map[int]*list.List),
+	}
+}
+
+func (p *lfuPolicy) Add(key string) (string, bool) {
+	var victim string
+	var evicted bool
+	if len(p.entries) >= p.capacity {
+		victim, evicted = p.Evict()
+	}
+
+	e := &lfuEntry{key: key, freq: 1}
+	e.elem = p.list(1).PushFront(e)
+	p.entries[key] = e
+	p.minFreq = 1
+
+	return victim, evicted
+}
+
+func (p *lfuPolicy) Hit(key string) {
+	e, ok := p.entries[key]
+	if !ok {
+		return
+	}
+
+	p.unlink(e)
+	e.freq++
+	e.elem = p.list(e.freq).PushFront(e)
+}
+
+func (p *lfuPolicy) Remove(key string) {
+	if e, ok := p.entries[key]; ok {
+		p.unlink(e)
+		delete(p.entries, key)
+	}
+}
+
+func (p *lfuPolicy) Evict() (string, bool) {
+	if len(p.entries) == 0 {
+		return "", false
+	}
+
+	if _, ok := p.freqs[p.minFreq]; !ok {
+		p.minFreq = 0
+		for freq := range p.freqs {
+			if p.minFreq == 0 || freq < p.minFreq {
+				p.minFreq = freq
+			}
+		}
+	}
+
+	e := p.freqs[p.minFreq].Back().Value.(*lfuEntry)
+	p.unlink(e)
+	delete(p.entries, e.key)
+	return e.key, true
+}
+
+// Keys yields the keys by ascending count, least recently used first
+// among keys with the same count.
+func (p *lfuPolicy) Keys() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for _, freq := range slices.Sorted(maps.Keys(p.freqs)) {
+			for elem := p.freqs[freq].Back(); elem != nil; elem = elem.Prev() {
+				if !yield(elem.Value.(*lfuEntry).key) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (p *lfuPolicy) list(freq int) *list.List {
+	l, ok := p.freqs[freq]
+	if !ok {
+		l = list.New()
+		p.freqs[freq] = l
+	}
+	return l
+}
+
+// unlink takes e out of the list of its count. The lowest count is fixed
+// up on the next Evict if its list is gone.
+func (p *lfuPolicy) unlink(e *lfuEntry) {
+	l := p.freqs[e.freq]
+	l.Remove(e.elem)
+	if l.Len() == 0 {
+		delete(p.freqs, e.freq)
+		if p.minFreq == e.freq {
+			p.minFreq = e.freq + 1
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"container/list"
+	"iter"
+)
+
+// lruPolicy evicts the least recently used key.
+type lruPolicy struct {
+	capacity int
+	list     *list.List
+	elems    map[string]*list.Element
+}
+
+func newLRUPolicy(capacity int) *lruPolicy {
+	return &lruPolicy{
+		capacity: capacity,
+		list:     list.New(),
+		elems:    make(map[string]*list.Element),
+	}
+}
+
+func (p *lruPolicy) Add(key string) (string, bool) {
+	var victim string
+	var evicted bool
+	if p.list.Len() >= p.capacity {
+		victim, evicted = p.Evict()
+	}
+
+	p.elems[key] = p.list.PushFront(key)
+	return victim, evicted
+}
+
+func (p *lruPolicy) Hit(key string) {
+	if elem, ok := p.elems[key]; ok {
+		p.list.MoveToFront(elem)
+	}
+}
+
+func (p *lruPolicy) Remove(key string) {
+	if elem, ok := p.elems[key]; ok {
+		p.list.Remove(elem)
+		delete(p.elems, key)
+	}
+}
+
+func (p *lruPolicy) Evict() (string, bool) {
+	elem := p.list.Back()
+	if elem == nil {
+		return "", false
+	}
+
+	key := elem.Value.(string)
+	p.list.Remove(elem)
+	delete(p.elems, key)
+	return key, true
+}
+
+func (p *lruPolicy) Keys() iter.Seq[string] {
+	return backToFront(p.list, func(elem *list.Element) string {
+		return elem.Value.(string)
+	})
+}
@@ -0,0 +1,170 @@
+package cache
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"wb-examples-l0/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tracked replays keys through p like a cache does and returns the keys
+// it holds in the end.
+func tracked(t *testing.T, p policy, keys ...string) map[string]bool {
+	t.Helper()
+
+	cached := make(map[string]bool)
+	for _, key := range keys {
+		if cached[key] {
+			p.Hit(key)
+			continue
+		}
+
+		victim, evicted := p.Add(key)
+		if evicted {
+			require.NotEqual(t, key, victim, "a policy never evicts the key it adds")
+			require.True(t, cached[victim], "victim %s is not cached", victim)
+			delete(cached, victim)
+		}
+		cached[key] = true
+	}
+	return cached
+}
+
+func keys(prefix string, n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("%s-%d", prefix, i)
+	}
+	return keys
+}
+
+func TestPolicies_Capacity(t *testing.T) {
+	for _, name := range Policies {
+		t.Run(name, func(t *testing.T) {
+			p, err := newPolicy(name, 10)
+			require.NoError(t, err)
+
+			cached := tracked(t, p, keys("k", 100)...)
+			assert.LessOrEqual(t, len(cached), 10)
+
+			// Removed keys free their slot and can be added again.
+			for key := range cached {
+				p.Remove(key)
+			}
+			cached = tracked(t, p, keys("k", 5)...)
+			assert.Len(t, cached, 5)
+
+			// Evict drains the policy.
+			for range 5 {
+				victim, ok := p.Evict()
+				require.True(t, ok)
+				assert.True(t, cached[victim])
+				delete(cached, victim)
+			}
+			_, ok := p.Evict()
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestPolicies_Keys(t *testing.T) {
+	for _, name := range Policies {
+		t.Run(name, func(t *testing.T) {
+			p, err := newPolicy(name, 10)
+			require.NoError(t, err)
+
+			cached := tracked(t, p, append(keys("k", 30), "k-29", "k-28", "k-29")...)
+
+			got := slices.Collect(p.Keys())
+			assert.Len(t, got, len(cached))
+			for _, key := range got {
+				assert.True(t, cached[key], key)
+			}
+		})
+	}
+
+	p := newLRUPolicy(3)
+	tracked(t, p, "a", "b", "c", "a")
+	assert.Equal(t, []string{"b", "c", "a"}, slices.Collect(p.Keys()), "least recently used first")
+}
+
+func TestPolicies_Unknown(t *testing.T) {
+	_, err := newPolicy("fifo", 10)
+	require.Error(t, err)
+}
+
+func TestLRUPolicy(t *testing.T) {
+	p := newLRUPolicy(3)
+	cached := tracked(t, p, "a", "b", "c", "a", "d")
+
+	assert.Equal(t, map[string]bool{"a": true, "c": true, "d": true}, cached)
+}
+
+func TestLFUPolicy(t *testing.T) {
+	p := newLFUPolicy(3)
+	cached := tracked(t, p, "a", "a", "a", "b", "b", "c", "d", "e")
+
+	assert.Equal(t, map[string]bool{"a": true, "b": true, "e": true}, cached)
+
+	victim, ok := p.Evict()
+	require.True(t, ok)
+	assert.Equal(t, "e", victim)
+}
+
+// hotAndScan accesses a small hot set repeatedly with a long scan of
+// one-off keys in between.
+func hotAndScan() []string {
+	hot := keys("hot", 5)
+
+	var trace []string
+	for range 20 {
+		trace = append(trace, hot...)
+	}
+	for i, key := range keys("scan", 100) {
+		trace = append(trace, key)
+		if i%10 == 0 {
+			trace = append(trace, hot...)
+		}
+	}
+	return append(trace, hot...)
+}
+
+func TestPolicies_ScanResistance(t *testing.T) {
+	for _, name := range []string{config.PolicyLFU, config.PolicyARC, config.PolicyTinyLFU} {
+		t.Run(name, func(t *testing.T) {
+			p, err := newPolicy(name, 10)
+			require.NoError(t, err)
+
+			cached := tracked(t, p, hotAndScan()...)
+			for _, key := range keys("hot", 5) {
+				assert.True(t, cached[key], "hot key %s was evicted by the scan", key)
+			}
+		})
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	trace := hotAndScan()
+
+	results := make(map[string]SimResult)
+	for _, name := range Policies {
+		res, err := Simulate(name, 10, slices.Values(trace))
+		require.NoError(t, err)
+		assert.Equal(t, len(trace), res.Hits+res.Misses)
+		results[name] = res
+	}
+
+	for name, res := range results {
+		t.Logf("%s: %.3f", name, res.HitRatio())
+		assert.GreaterOrEqual(t, res.Misses, 105, "every distinct key misses once")
+	}
+	for _, name := range []string{config.PolicyLFU, config.PolicyARC, config.PolicyTinyLFU} {
+		assert.Greater(t, results[name].HitRatio(), results[config.PolicyLRU].HitRatio(), name)
+	}
+
+	_, err := Simulate("fifo", 10, slices.Values(trace))
+	require.Error(t, err)
+}
@@ -0,0 +1,213 @@
+package cache
+
+import (
+	"container/list"
+	"hash/fnv"
+	"iter"
+)
+
+// tinyLFUPolicy is W-TinyLFU as used by Caffeine: new keys enter a small
+// LRU window, and a key leaving the window only replaces the victim of the
+// main cache if it was seen more often than the victim. Frequencies are
+// estimated by a count-min sketch that is halved periodically, so one-off
+// keys of a scan never push out popular ones. The main cache is a
+// segmented LRU: keys hit in probation move to protected.
+type tinyLFUPolicy struct {
+	window     *list.List
+	probation  *list.List
+	protected  *list.List
+	elems      map[string]*list.Element
+	windowCap  int
+	mainCap    int
+	protectCap int
+	sketch     *sketch
+}
+
+type tinyLFUEntry struct {
+	key  string
+	list *list.List
+}
+
+func newTinyLFUPolicy(capacity int) *tinyLFUPolicy {
+	windowCap := max(capacity/100, 1)
+	mainCap := capacity - windowCap
+
+	return &tinyLFUPolicy{
+		window:     list.New(),
+		probation:  list.New(),
+		protected:  list.New(),
+		elems:      make(map[string]*list.Element),
+		windowCap:  windowCap,
+		mainCap:    mainCap,
+		protectCap: mainCap * 8 / 10,
+		sketch:     newSketch(capacity),
+	}
+}
+
+func (p *tinyLFUPolicy) Add(key string) (string, bool) {
+	p.sketch.increment(key)
+	p.push(key, p.window)
+
+	if p.window.Len() <= p.windowCap {
+		return "", false
+	}
+
+	candidate := p.window.Back().Value.(*tinyLFUEntry).key
+	if p.probation.Len()+p.protected.Len() < p.mainCap {
+		p.move(candidate, p.probation)
+		return "", false
+	}
+
+	victim := p.mainVictim()
+	if victim == nil || p.sketch.estimate(candidate) <= p.sketch.estimate(victim.Value.(*tinyLFUEntry).key) {
+		p.Remove(candidate)
+		return candidate, true
+	}
+
+	evictedKey := victim.Value.(*tinyLFUEntry).key
+	p.Remove(evictedKey)
+	p.move(candidate, p.probation)
+	return evictedKey, true
+}
+
+func (p *tinyLFUPolicy) Hit(key string) {
+	elem, ok := p.elems[key]
+	if !ok {
+		return
+	}
+
+	p.sketch.increment(key)
+
+	switch elem.Value.(*tinyLFUEntry).list {
+	case p.window:
+		p.window.MoveToFront(elem)
+	case p.protected:
+		p.protected.MoveToFront(elem)
+	case p.probation:
+		p.move(key, p.protected)
+		if p.protected.Len() > p.protectCap {
+			demoted := p.protected.Back().Value.(*tinyLFUEntry).key
+			p.move(demoted, p.probation)
+		}
+	}
+}
+
+func (p *tinyLFUPolicy) Remove(key string) {
+	if elem, ok := p.elems[key]; ok {
+		elem.Value.(*tinyLFUEntry).list.Remove(elem)
+		delete(p.elems, key)
+	}
+}
+
+func (p *tinyLFUPolicy) Evict() (string, bool) {
+	elem := p.mainVictim()
+	if elem == nil {
+		elem = p.window.Back()
+	}
+	if elem == nil {
+		return "", false
+	}
+
+	key := elem.Value.(*tinyLFUEntry).key
+	p.Remove(key)
+	return key, true
+}
+
+// Keys yields the keys of probation, the window and protected, each from
+// the LRU end.
+func (p *tinyLFUPolicy) Keys() iter.Seq[string] {
+	key := func(elem *list.Element) string {
+		return elem.Value.(*tinyLFUEntry).key
+	}
+	return func(yield func(string) bool) {
+		for _, l := range []*list.List{p.probation, p.window, p.protected} {
+			for k := range backToFront(l, key) {
+				if !yield(k) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// mainVictim is the next key to leave the main cache.
+func (p *tinyLFUPolicy) mainVictim() *list.Element {
+	if elem := p.probation.Back(); elem != nil {
+		return elem
+	}
+	return p.protected.Back()
+}
+
+func (p *tinyLFUPolicy) push(key string, l *list.List) {
+	p.elems[key] = l.PushFront(&tinyLFUEntry{key: key, list: l})
+}
+
+func (p *tinyLFUPolicy) move(key string, l *list.List) {
+	p.Remove(key)
+	p.push(key, l)
+}
+
+// sketch is a count-min sketch of 4-bit counters in four rows. After
+// sampleSize increments all counters are halved, so frequencies age.
+type sketch struct {
+	rows       [4][]uint8
+	mask       uint64
+	additions  int
+	sampleSize int
+}
+
+func newSketch(capacity int) *sketch {
+	width := 16
+	for width < capacity {
+		width *= 2
+	}
+
+	s := &sketch{mask: uint64(width - 1), sampleSize: 10 * width}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+func (s *sketch) indexes(key string) [4]uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	sum := h.Sum64()
+	lo, hi := sum, sum>>32|sum<<32
+
+	var idx [4]uint64
+	for i := range idx {
+		idx[i] = (lo + uint64(i)*hi) & s.mask
+	}
+	return idx
+}
+
+func (s *sketch) increment(key string) {
+	for i, idx := range s.indexes(key) {
+		if s.rows[i][idx] < 15 {
+			s.rows[i][idx]++
+		}
+	}
+
+	s.additions++
+	if s.additions >= s.sampleSize {
+		s.reset()
+	}
+}
+
+func (s *sketch) estimate(key string) uint8 {
+	est := uint8(15)
+	for i, idx := range s.indexes(key) {
+		est = min(est, s.rows[i][idx])
+	}
+	return est
+}
+
+func (s *sketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] /= 2
+		}
+	}
+	s.additions /= 2
+}
@@ -0,0 +1,193 @@
+package cache
+
+import (
+	"context"
+	"hash/fnv"
+	"log/slog"
+	"sync"
+	"time"
+	"wb-examples-l0/internal/config"
+	"wb-examples-l0/internal/lib/fieldcrypt"
+	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/storage"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ShardedCache splits the keys over independent LRUCache segments by a
+// hash of the key, so that lookups of different keys rarely wait for the
+// same lock. Every segment holds an equal share of the capacity and evicts
+// on its own, so the cache as a whole is only approximately LRU.
+type ShardedCache struct {
+	shards   []*LRUCache
+	storage  storage.OrderRepository
+	capacity int
+}
+
+func NewShardedCache(cfg config.LruCache, storage storage.OrderRepository, logger *slog.Logger) (*ShardedCache, error) {
+	n := max(cfg.Shards, 1)
+	capacity := cfg.Capacity
+	cfg.Capacity = (cfg.Capacity + n - 1) / n
+	cfg.NegativeCapacity = (cfg.NegativeCapacity + n - 1) / n
+	cfg.MaxBytes = (cfg.MaxBytes + int64(n) - 1) / int64(n)
+
+	cache := &ShardedCache{
+		shards:   make([]*LRUCache, n),
+		storage:  storage,
+		capacity: capacity,
+	}
+	for i := range cache.shards {
+		shard, err := NewLRUCache(cfg, storage, logger)
+		if err != nil {
+			return nil, err
+		}
+		cache.shards[i] = shard
+	}
+
+	return cache, nil
+}
+
+func (s *ShardedCache) shard(key string) *LRUCache {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return s.shards[h.Sum32()%uint32(len(s.shards))]
+}
+
+func (s *ShardedCache) Get(key string) (*models.Order, bool) {
+	return s.shard(key).Get(key)
+}
+
+func (s *ShardedCache) GetOrLoad(ctx context.Context, key string, loader Loader) (*models.Order, error) {
+	return s.shard(key).GetOrLoad(ctx, key, loader)
+}
+
+func (s *ShardedCache) Put(key string, val *models.Order) {
+	s.shard(key).Put(key, val)
+}
+
+func (s *ShardedCache) PutWithTTL(key string, val *models.Order, ttl time.Duration) {
+	s.shard(key).PutWithTTL(key, val, ttl)
+}
+
+func (s *ShardedCache) Remove(key string) {
+	s.shard(key).Remove(key)
+}
+
+func (s *ShardedCache) OrderSaved(order *models.Order) {
+	s.shard(order.OrderUID).OrderSaved(order)
+}
+
+func (s *ShardedCache) Len() int {
+	var n int
+	for _, shard := range s.shards {
+		n += shard.Len()
+	}
+	return n
+}
+
+// Bytes returns the estimated memory of the entries of all segments.
+func (s *ShardedCache) Bytes() int64 {
+	var n int64
+	for _, shard := range s.shards {
+		n += shard.Bytes()
+	}
+	return n
+}
+
+// Purge purges every segment.
+func (s *ShardedCache) Purge() int {
+	var n int
+	for _, shard := range s.shards {
+		n += shard.Purge()
+	}
+	return n
+}
+
+func (s *ShardedCache) Inspect(key string) EntryInfo {
+	return s.shard(key).Inspect(key)
+}
+
+// Warm loads the newest orders from storage into the segments.
+func (s *ShardedCache) Warm(ctx context.Context) (int, error) {
+	return warm(ctx, s, s.storage, s.capacity, nil)
+}
+
+func (s *ShardedCache) startWarm() func(orders []*models.Order) int {
+	finish := make(map[*LRUCache]func([]*models.Order) int, len(s.shards))
+	for _, shard := range s.shards {
+		finish[shard] = shard.startWarm()
+	}
+
+	return func(orders []*models.Order) int {
+		byShard := make(map[*LRUCache][]*models.Order, len(s.shards))
+		for _, order := range orders {
+			shard := s.shard(order.OrderUID)
+			byShard[shard] = append(byShard[shard], order)
+		}
+
+		var n int
+		for shard, f := range finish {
+			n += f(byShard[shard])
+		}
+		return n
+	}
+}
+
+// SaveSnapshot writes the entries of all segments to one snapshot, each
+// segment in its eviction order.
+func (s *ShardedCache) SaveSnapshot(path string, keys *fieldcrypt.Keyring) (int, error) {
+	var entries []snapshotEntry
+	for _, shard := range s.shards {
+		entries = append(entries, shard.snapshotEntries()...)
+	}
+	return len(entries), writeSnapshot(path, time.Now(), entries, keys)
+}
+
+// LoadSnapshot restores the entries of a snapshot into the segments of
+// their keys, which needn't be the segments they were saved from.
+func (s *ShardedCache) LoadSnapshot(path string, maxAge time.Duration, keys *fieldcrypt.Keyring) (int, error) {
+	entries, err := readSnapshot(path, time.Now(), maxAge, keys)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	for _, e := range entries {
+		if s.shard(e.Key).restore(e) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// RunJanitor runs the janitor of every segment until ctx is done.
+func (s *ShardedCache) RunJanitor(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, shard := range s.shards {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			shard.RunJanitor(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// Describe implements prometheus.Collector.
+func (s *ShardedCache) Describe(ch chan<- *prometheus.Desc) {
+	describe(ch)
+}
+
+// Stats returns the totals of all segments.
+func (s *ShardedCache) Stats() Stats {
+	var st Stats
+	for _, shard := range s.shards {
+		st.add(shard.Stats())
+	}
+	return st
+}
+
+// Collect implements prometheus.Collector.
+func (s *ShardedCache) Collect(ch chan<- prometheus.Metric) {
+	s.Stats().collect(ch)
+}
@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"wb-examples-l0/internal/config"
+	"wb-examples-l0/internal/storage"
+	"wb-examples-l0/internal/storage/memory"
+	"wb-examples-l0/internal/storage/storagetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardedCache(t *testing.T) {
+	repo := memory.New()
+	c, err := NewShardedCache(config.LruCache{Capacity: 64, Shards: 4, NegativeTTL: 1, NegativeCapacity: 8},
+		repo, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	require.Len(t, c.shards, 4)
+	for _, shard := range c.shards {
+		assert.Equal(t, 16, shard.capacity)
+		assert.Equal(t, 2, shard.negativeCapacity)
+	}
+
+	for i := range 32 {
+		key := fmt.Sprintf("order-%d", i)
+		c.Put(key, storagetest.NewOrder(key, i))
+	}
+	assert.Equal(t, 32, c.Len())
+
+	for i := range 32 {
+		key := fmt.Sprintf("order-%d", i)
+		got, ok := c.Get(key)
+		require.True(t, ok, key)
+		assert.Equal(t, key, got.OrderUID)
+		assert.Same(t, c.shard(key), c.shard(key), "a key always maps to the same segment")
+	}
+
+	c.Remove("order-1")
+	_, ok := c.Get("order-1")
+	assert.False(t, ok)
+
+	_, err = c.GetOrLoad(context.Background(), "missing", repo.GetOrderByUID)
+	require.ErrorIs(t, err, storage.ErrOrderNotFound)
+
+	c.OrderSaved(storagetest.NewOrder("order-1", 1))
+	_, ok = c.Get("order-1")
+	assert.True(t, ok)
+
+	st := c.Stats()
+	assert.Equal(t, uint64(33), st.Hits)
+	assert.Equal(t, uint64(2), st.Misses)
+	assert.Equal(t, 32, st.Entries)
+	assert.Equal(t, 64, st.Capacity)
+}
+
+func TestNew(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, tc := range []struct {
+		cfg  config.LruCache
+		want Cache
+	}{
+		{config.LruCache{Capacity: 10}, &LRUCache{}},
+		{config.LruCache{Capacity: 10, Shards: 1}, &LRUCache{}},
+		{config.LruCache{Capacity: 10, Shards: 4}, &ShardedCache{}},
+		{config.LruCache{Capacity: 10, Shards: 4, Policy: config.PolicyARC}, &ShardedCache{}},
+	} {
+		c, err := New(tc.cfg, memory.New(), log)
+		require.NoError(t, err)
+		assert.IsType(t, tc.want, c)
+	}
+
+	_, err := New(config.LruCache{Capacity: 10, Policy: "fifo"}, memory.New(), log)
+	assert.ErrorContains(t, err, "unknown cache policy")
+	_, err = New(config.LruCache{Capacity: 10, Shards: 4, Policy: "fifo"}, memory.New(), log)
+	assert.ErrorContains(t, err, "unknown cache policy")
+	_, err = New(config.LruCache{Capacity: 10, Population: "eager"}, memory.New(), log)
+	assert.ErrorContains(t, err, "unknown cache population mode")
+}
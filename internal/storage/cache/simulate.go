@@ -0,0 +1,47 @@
+package cache
+
+import "iter"
+
+// SimResult is the outcome of replaying an access trace.
+type SimResult struct {
+	Policy   string
+	Capacity int
+	Hits     int
+	Misses   int
+}
+
+// HitRatio returns the share of accesses that were hits.
+func (r SimResult) HitRatio() float64 {
+	if r.Hits+r.Misses == 0 {
+		return 0
+	}
+	return float64(r.Hits) / float64(r.Hits+r.Misses)
+}
+
+// Simulate replays the accessed keys through an empty cache of capacity
+// entries evicted by the named policy. Every miss is loaded into the
+// cache, as GetOrLoad does.
+func Simulate(policyName string, capacity int, keys iter.Seq[string]) (SimResult, error) {
+	p, err := newPolicy(policyName, capacity)
+	if err != nil {
+		return SimResult{}, err
+	}
+
+	res := SimResult{Policy: policyName, Capacity: capacity}
+	cached := make(map[string]struct{}, capacity)
+	for key := range keys {
+		if _, ok := cached[key]; ok {
+			res.Hits++
+			p.Hit(key)
+			continue
+		}
+
+		res.Misses++
+		if victim, evicted := p.Add(key); evicted {
+			delete(cached, victim)
+		}
+		cached[key] = struct{}{}
+	}
+
+	return res, nil
+}
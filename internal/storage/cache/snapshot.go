@@ -0,0 +1,247 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+	"wb-examples-l0/internal/lib/fieldcrypt"
+	"wb-examples-l0/internal/models"
+)
+
+// A snapshot file is a header, the SHA-256 of the header and the payload,
+// and the payload: the gzipped gob of the entries, least valuable first.
+// The header is the magic, the format version, the flags and the creation
+// time in Unix nanoseconds, big endian.
+//
+// With a keyring the payload is sealed, see sealedPayload, so that the
+// delivery contacts encrypted in storage are not kept in the clear on disk.
+const (
+	snapshotMagic   = "WBOC"
+	snapshotVersion = 2
+
+	// snapshotSealed is the flag of sealed snapshots.
+	snapshotSealed byte = 1
+
+	snapshotHeaderSize = len(snapshotMagic) + 1 + 1 + 8
+)
+
+var (
+	ErrSnapshotInvalid = errors.New("invalid cache snapshot")
+	ErrSnapshotStale   = errors.New("cache snapshot is too old")
+)
+
+// sealedPayload is the payload of a sealed snapshot: the gzipped gob of
+// the entries encrypted under the envelope, bound to the header.
+type sealedPayload struct {
+	Envelope fieldcrypt.Envelope
+	Data     string
+}
+
+type snapshotEntry struct {
+	Key   string
+	Order *models.Order
+	// ExpiresAt is zero for entries that never expire.
+	ExpiresAt time.Time
+}
+
+// writeSnapshot writes the entries to path, sealed if keys is not nil.
+// The file is written next to path and renamed, so a crash never leaves a
+// partial snapshot behind.
+func writeSnapshot(path string, createdAt time.Time, entries []snapshotEntry, keys *fieldcrypt.Keyring) error {
+	var payload bytes.Buffer
+	zw := gzip.NewWriter(&payload)
+	if err := gob.NewEncoder(zw).Encode(entries); err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+
+	var flags byte
+	if keys != nil {
+		flags |= snapshotSealed
+	}
+
+	header := make([]byte, 0, snapshotHeaderSize)
+	header = append(header, snapshotMagic...)
+	header = append(header, snapshotVersion, flags)
+	header = binary.BigEndian.AppendUint64(header, uint64(createdAt.UnixNano()))
+
+	if keys != nil {
+		env, sealed, err := keys.Seal(string(header), payload.String())
+		if err != nil {
+			return fmt.Errorf("seal snapshot: %w", err)
+		}
+
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(sealedPayload{Envelope: env, Data: sealed[0]}); err != nil {
+			return fmt.Errorf("encode snapshot: %w", err)
+		}
+		payload = buf
+	}
+
+	sum := sha256.New()
+	sum.Write(header)
+	sum.Write(payload.Bytes())
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create snapshot: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	for _, b := range [][]byte{header, sum.Sum(nil), payload.Bytes()} {
+		if _, err := f.Write(b); err != nil {
+			f.Close()
+			return fmt.Errorf("write snapshot: %w", err)
+		}
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// readSnapshot reads the entries of the snapshot at path. It fails with
+// ErrSnapshotInvalid if the file is not a snapshot, its checksum does not
+// match, or it is not sealed with keys while keys is not nil, and with
+// ErrSnapshotStale if it is older than maxAge; a maxAge of 0 accepts
+// snapshots of any age.
+func readSnapshot(path string, now time.Time, maxAge time.Duration, keys *fieldcrypt.Keyring) ([]snapshotEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < snapshotHeaderSize+sha256.Size ||
+		string(data[:len(snapshotMagic)]) != snapshotMagic {
+		return nil, ErrSnapshotInvalid
+	}
+	if v := data[len(snapshotMagic)]; v != snapshotVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrSnapshotInvalid, v)
+	}
+
+	header := data[:snapshotHeaderSize]
+	checksum := data[snapshotHeaderSize : snapshotHeaderSize+sha256.Size]
+	payload := data[snapshotHeaderSize+sha256.Size:]
+
+	sum := sha256.New()
+	sum.Write(header)
+	sum.Write(payload)
+	if !bytes.Equal(sum.Sum(nil), checksum) {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrSnapshotInvalid)
+	}
+
+	createdAt := time.Unix(0, int64(binary.BigEndian.Uint64(header[len(snapshotMagic)+2:])))
+	if age := now.Sub(createdAt); maxAge > 0 && age > maxAge {
+		return nil, fmt.Errorf("%w: created %s ago", ErrSnapshotStale, age.Round(time.Second))
+	}
+
+	sealed := header[len(snapshotMagic)+1]&snapshotSealed != 0
+	switch {
+	case sealed && keys == nil:
+		return nil, fmt.Errorf("%w: sealed, but no keyring is configured", ErrSnapshotInvalid)
+	case !sealed && keys != nil:
+		return nil, fmt.Errorf("%w: not sealed", ErrSnapshotInvalid)
+	case sealed:
+		var p sealedPayload
+		if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&p); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrSnapshotInvalid, err)
+		}
+		values, err := keys.Open(p.Envelope, string(header), p.Data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrSnapshotInvalid, err)
+		}
+		payload = []byte(values[0])
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSnapshotInvalid, err)
+	}
+	var entries []snapshotEntry
+	if err := gob.NewDecoder(zr).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSnapshotInvalid, err)
+	}
+
+	return entries, nil
+}
+
+// SaveSnapshot writes the unexpired entries to path in eviction order and
+// returns their number. The snapshot is sealed with keys unless it is nil.
+func (c *LRUCache) SaveSnapshot(path string, keys *fieldcrypt.Keyring) (int, error) {
+	entries := c.snapshotEntries()
+	return len(entries), writeSnapshot(path, c.now(), entries, keys)
+}
+
+// LoadSnapshot caches the entries of the snapshot at path that have not
+// expired since, keeping their expiry and eviction order, and returns
+// their number; a cache smaller than the saved one keeps the entries its
+// policy values most. Snapshots older than maxAge are rejected, as are
+// snapshots not sealed with keys, if it is not nil.
+func (c *LRUCache) LoadSnapshot(path string, maxAge time.Duration, keys *fieldcrypt.Keyring) (int, error) {
+	entries, err := readSnapshot(path, c.now(), maxAge, keys)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	for _, e := range entries {
+		if c.restore(e) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// snapshotEntries returns the unexpired entries from the one the policy
+// values least to the one it values most.
+func (c *LRUCache) snapshotEntries() []snapshotEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	entries := make([]snapshotEntry, 0, len(c.items))
+	for key := range c.policy.Keys() {
+		item, ok := c.items[key]
+		if !ok || item.expired(now) {
+			continue
+		}
+		entries = append(entries, snapshotEntry{Key: key, Order: item.value, ExpiresAt: item.expiresAt})
+	}
+	return entries
+}
+
+// restore caches a snapshot entry for the rest of its lifetime, but not
+// longer than the TTL. Entries without expiry get the default TTL.
+func (c *LRUCache) restore(e snapshotEntry) bool {
+	if e.Order == nil {
+		return false
+	}
+
+	ttl := c.ttl
+	if !e.ExpiresAt.IsZero() {
+		left := e.ExpiresAt.Sub(c.now())
+		if left <= 0 {
+			return false
+		}
+		if ttl == 0 || left < ttl {
+			ttl = left
+		}
+	}
+
+	c.PutWithTTL(e.Key, e.Order, ttl)
+	return true
+}
@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"wb-examples-l0/internal/config"
+	"wb-examples-l0/internal/lib/fieldcrypt"
+	"wb-examples-l0/internal/storage/memory"
+	"wb-examples-l0/internal/storage/storagetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRUCache_Snapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+
+	c, clk := newTestCache(memory.New(), config.LruCache{Capacity: 3, TTL: time.Minute})
+	c.Put("a", storagetest.NewOrder("a", 1))
+	c.Put("b", storagetest.NewOrder("b", 1))
+	c.PutWithTTL("c", storagetest.NewOrder("c", 1), time.Second)
+	c.Get("a")
+	clk.Advance(2 * time.Second)
+
+	n, err := c.SaveSnapshot(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n, "expired entries are not saved")
+
+	// The smaller cache keeps the most recently used entry.
+	restored, clk2 := newTestCache(memory.New(), config.LruCache{Capacity: 1, TTL: time.Hour})
+	clk2.now = clk.now.Add(30 * time.Second)
+	n, err = restored.LoadSnapshot(path, time.Hour, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	got, ok := restored.Get("a")
+	require.True(t, ok)
+	assert.Equal(t, "a", got.OrderUID)
+	_, ok = restored.Get("b")
+	assert.False(t, ok)
+
+	// The entry keeps its expiry rather than getting a new TTL.
+	clk2.Advance(30 * time.Second)
+	_, ok = restored.Get("a")
+	assert.False(t, ok)
+}
+
+func TestShardedCache_Snapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	c, err := NewShardedCache(config.LruCache{Capacity: 64, Shards: 4}, memory.New(), logger)
+	require.NoError(t, err)
+	for _, key := range keys("order", 20) {
+		c.Put(key, storagetest.NewOrder(key, 1))
+	}
+	n, err := c.SaveSnapshot(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 20, n)
+
+	restored, err := NewShardedCache(config.LruCache{Capacity: 64, Shards: 8}, memory.New(), logger)
+	require.NoError(t, err)
+	n, err = restored.LoadSnapshot(path, 0, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 20, n)
+	for _, key := range keys("order", 20) {
+		_, ok := restored.Get(key)
+		assert.True(t, ok, key)
+	}
+}
+
+func TestLRUCache_SnapshotRejected(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cache.snapshot")
+
+	c, clk := newTestCache(memory.New(), config.LruCache{Capacity: 3})
+	c.Put("a", storagetest.NewOrder("a", 1))
+	_, err := c.SaveSnapshot(path, nil)
+	require.NoError(t, err)
+
+	_, err = c.LoadSnapshot(filepath.Join(dir, "missing"), time.Minute, nil)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	clk.Advance(2 * time.Minute)
+	_, err = c.LoadSnapshot(path, time.Minute, nil)
+	require.ErrorIs(t, err, ErrSnapshotStale)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	data[len(data)-1] ^= 0xff
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	_, err = c.LoadSnapshot(path, 0, nil)
+	require.ErrorIs(t, err, ErrSnapshotInvalid)
+
+	require.NoError(t, os.WriteFile(path, []byte("not a snapshot"), 0o600))
+	_, err = c.LoadSnapshot(path, 0, nil)
+	require.ErrorIs(t, err, ErrSnapshotInvalid)
+}
+
+func TestLRUCache_SnapshotSealed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	key := func(b byte) string {
+		return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+	}
+	keys, err := fieldcrypt.New("k1", map[string]string{"k1": key(1)}, key(9))
+	require.NoError(t, err)
+
+	c, _ := newTestCache(memory.New(), config.LruCache{Capacity: 3})
+	order := storagetest.NewOrder("a", 1)
+	c.Put("a", order)
+	_, err = c.SaveSnapshot(path, keys)
+	require.NoError(t, err)
+
+	other, err := fieldcrypt.New("k2", map[string]string{"k2": key(2)}, key(9))
+	require.NoError(t, err)
+	_, err = c.LoadSnapshot(path, 0, other)
+	require.ErrorIs(t, err, ErrSnapshotInvalid, "a snapshot opens only with its keyring")
+	_, err = c.LoadSnapshot(path, 0, nil)
+	require.ErrorIs(t, err, ErrSnapshotInvalid, "a sealed snapshot needs the keyring")
+
+	restored, _ := newTestCache(memory.New(), config.LruCache{Capacity: 3})
+	n, err := restored.LoadSnapshot(path, 0, keys)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	got, ok := restored.Get("a")
+	require.True(t, ok)
+	assert.Equal(t, order.Delivery, got.Delivery)
+
+	// A plain snapshot is not accepted once a keyring is configured.
+	_, err = c.SaveSnapshot(path, nil)
+	require.NoError(t, err)
+	_, err = restored.LoadSnapshot(path, 0, keys)
+	require.ErrorIs(t, err, ErrSnapshotInvalid)
+}
@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+	"wb-examples-l0/internal/config"
+	"wb-examples-l0/internal/lib/fieldcrypt"
+	"wb-examples-l0/internal/storage"
+)
+
+const (
+	SourceSnapshot = "snapshot"
+	SourceStorage  = "storage"
+)
+
+// Warmup tracks the preload of a cache started by Preload.
+type Warmup struct {
+	target      int
+	failOnError bool
+	startedAt   time.Time
+	done        chan struct{}
+
+	mu       sync.Mutex
+	source   string
+	loaded   int
+	err      error
+	timedOut bool
+	duration time.Duration
+}
+
+// WarmupStatus is the progress of a warm-up.
+type WarmupStatus struct {
+	Ready bool `json:"ready"`
+	Done  bool `json:"done"`
+	// TimedOut is set if the warm-up took longer than its timeout; the
+	// service is ready then, while the preload goes on.
+	TimedOut bool `json:"timed_out"`
+	// Source is "snapshot" or "storage", unset until the first orders
+	// are loaded.
+	Source string `json:"source,omitempty"`
+	// Loaded is the number of orders loaded so far, Target the capacity.
+	Loaded  int    `json:"loaded"`
+	Target  int    `json:"target"`
+	Elapsed string `json:"elapsed"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Preload fills c in the background, from the snapshot if one is
+// configured and fresh, from storage otherwise, and returns the tracker of
+// its progress. keys is the keyring the snapshot was sealed with, nil if it
+// is not sealed. It stops early when ctx is done.
+func Preload(ctx context.Context, c Cache, repo storage.OrderRepository, cfg config.LruCache, keys *fieldcrypt.Keyring, logger *slog.Logger) *Warmup {
+	w := &Warmup{
+		target:      cfg.Capacity,
+		failOnError: cfg.Warmup.OnError == config.WarmupFail,
+		startedAt:   time.Now(),
+		done:        make(chan struct{}),
+	}
+
+	go func() {
+		if timeout := cfg.Warmup.Timeout; timeout > 0 {
+			timer := time.AfterFunc(timeout, func() {
+				if w.timeout() {
+					logger.Warn("Cache warm-up timed out, reporting ready", "timeout", timeout, "items_loaded", w.Status().Loaded)
+				}
+			})
+			defer timer.Stop()
+		}
+
+		w.finish(preload(ctx, c, repo, cfg, keys, logger, w))
+	}()
+
+	return w
+}
+
+// Done is closed when the preload has finished.
+func (w *Warmup) Done() <-chan struct{} {
+	return w.done
+}
+
+// Err returns the error of a finished preload.
+func (w *Warmup) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.err
+}
+
+// Ready reports whether the service may receive traffic: the preload
+// succeeded, failed with the "continue" policy, or timed out.
+func (w *Warmup) Ready() bool {
+	return w.Status().Ready
+}
+
+func (w *Warmup) Status() WarmupStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	st := WarmupStatus{
+		TimedOut: w.timedOut,
+		Source:   w.source,
+		Loaded:   w.loaded,
+		Target:   w.target,
+	}
+
+	select {
+	case <-w.done:
+		st.Done = true
+		st.Elapsed = w.duration.Round(time.Millisecond).String()
+	default:
+		st.Elapsed = time.Since(w.startedAt).Round(time.Millisecond).String()
+	}
+
+	if w.err != nil {
+		st.Error = w.err.Error()
+	}
+	st.Ready = w.timedOut || st.Done && (w.err == nil || !w.failOnError)
+
+	return st
+}
+
+func (w *Warmup) progress(source string, loaded int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.source = source
+	w.loaded = loaded
+}
+
+// timeout marks a running warm-up as timed out and reports whether it was
+// running.
+func (w *Warmup) timeout() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return false
+	default:
+		w.timedOut = true
+		return true
+	}
+}
+
+func (w *Warmup) finish(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err != nil {
+		w.err = fmt.Errorf("preload cache: %w", err)
+	}
+	w.duration = time.Since(w.startedAt)
+	close(w.done)
+}
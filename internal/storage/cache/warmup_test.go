@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"wb-examples-l0/internal/config"
+	"wb-examples-l0/internal/storage"
+	"wb-examples-l0/internal/storage/memory"
+	"wb-examples-l0/internal/storage/storagetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listRepo fails or blocks listings until release is closed.
+type listRepo struct {
+	storage.OrderRepository
+	release chan struct{}
+	err     error
+}
+
+func (r *listRepo) ListOrders(ctx context.Context, filter storage.OrderFilter) (*storage.OrderPage, error) {
+	if r.release != nil {
+		<-r.release
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.OrderRepository.ListOrders(ctx, filter)
+}
+
+func preloadTest(t *testing.T, repo storage.OrderRepository, cfg config.LruCache) (*Warmup, *LRUCache) {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	c, err := NewLRUCache(cfg, repo, logger)
+	require.NoError(t, err)
+	return Preload(context.Background(), c, repo, cfg, nil, logger), c
+}
+
+func waitWarmup(t *testing.T, w *Warmup) {
+	t.Helper()
+
+	select {
+	case <-w.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("warm-up did not finish")
+	}
+}
+
+func TestPreload_Storage(t *testing.T) {
+	repo := memory.New()
+	for _, uid := range []string{"a", "b", "c"} {
+		require.NoError(t, repo.SaveOrder(context.Background(), storagetest.NewOrder(uid, 1)))
+	}
+
+	w, c := preloadTest(t, repo, config.LruCache{Capacity: 10})
+	waitWarmup(t, w)
+
+	require.NoError(t, w.Err())
+	st := w.Status()
+	assert.True(t, st.Ready)
+	assert.True(t, st.Done)
+	assert.Equal(t, SourceStorage, st.Source)
+	assert.Equal(t, 3, st.Loaded)
+	assert.Equal(t, 10, st.Target)
+	assert.Equal(t, 3, c.Len())
+}
+
+func TestPreload_Snapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	saved, _ := newTestCache(memory.New(), config.LruCache{Capacity: 10})
+	saved.Put("a", storagetest.NewOrder("a", 1))
+	_, err := saved.SaveSnapshot(path, nil)
+	require.NoError(t, err)
+
+	// Storage fails, so the orders can only come from the snapshot.
+	repo := &listRepo{OrderRepository: memory.New(), err: errors.New("unavailable")}
+	w, c := preloadTest(t, repo, config.LruCache{Capacity: 10, SnapshotPath: path})
+	waitWarmup(t, w)
+
+	require.NoError(t, w.Err())
+	assert.Equal(t, SourceSnapshot, w.Status().Source)
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+	_, err = os.Stat(path)
+	assert.ErrorIs(t, err, os.ErrNotExist, "a loaded snapshot is removed")
+}
+
+func TestPreload_Error(t *testing.T) {
+	for _, tt := range []struct {
+		onError string
+		ready   bool
+	}{
+		{config.WarmupFail, false},
+		{config.WarmupContinue, true},
+	} {
+		t.Run(tt.onError, func(t *testing.T) {
+			repo := &listRepo{OrderRepository: memory.New(), err: errors.New("unavailable")}
+			cfg := config.LruCache{Capacity: 10}
+			cfg.Warmup.OnError = tt.onError
+
+			w, _ := preloadTest(t, repo, cfg)
+			waitWarmup(t, w)
+
+			require.ErrorIs(t, w.Err(), repo.err)
+			st := w.Status()
+			assert.Equal(t, tt.ready, st.Ready)
+			assert.NotEmpty(t, st.Error)
+		})
+	}
+}
+
+func TestPreload_Timeout(t *testing.T) {
+	repo := &listRepo{OrderRepository: memory.New(), release: make(chan struct{})}
+	cfg := config.LruCache{Capacity: 10}
+	cfg.Warmup.Timeout = 10 * time.Millisecond
+
+	w, _ := preloadTest(t, repo, cfg)
+	assert.False(t, w.Ready())
+
+	assert.Eventually(t, w.Ready, 5*time.Second, time.Millisecond)
+	st := w.Status()
+	assert.True(t, st.TimedOut)
+	assert.False(t, st.Done, "the preload goes on after the timeout")
+
+	close(repo.release)
+	waitWarmup(t, w)
+	require.NoError(t, w.Err())
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+	"wb-examples-l0/internal/models"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 500
+
+	// CustomerTopBrands is how many brands a customer summary reports.
+	CustomerTopBrands = 5
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// OrderFilter describes a page request for the order listing.
+// Empty fields are not applied. Orders are always sorted newest-first
+// by date_created, with order_uid as a tie-breaker.
+type OrderFilter struct {
+	CustomerID      string
+	TrackNumber     string
+	DeliveryService string
+	Locale          string
+	CreatedFrom     time.Time
+	CreatedTo       time.Time
+	Currency        string
+	Provider        string
+	Bank            string
+	Brand           string
+
+	Cursor string
+	Limit  int
+}
+
+type OrderPage struct {
+	Orders     []*models.Order `json:"orders"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// PageLimit returns Limit clamped to [1, MaxPageLimit].
+func (f OrderFilter) PageLimit() int {
+	switch {
+	case f.Limit <= 0:
+		return DefaultPageLimit
+	case f.Limit > MaxPageLimit:
+		return MaxPageLimit
+	default:
+		return f.Limit
+	}
+}
+
+// EncodeCursor builds an opaque keyset cursor pointing right after
+// the order with the given date_created and order_uid.
+func EncodeCursor(dateCreated time.Time, orderUID string) string {
+	raw := dateCreated.UTC().Format(time.RFC3339Nano) + "|" + orderUID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+
+	ts, uid, ok := strings.Cut(string(raw), "|")
+	if !ok || uid == "" {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+
+	dateCreated, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+
+	return dateCreated, uid, nil
+}
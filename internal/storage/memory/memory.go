@@ -0,0 +1,450 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/storage"
+)
+
+// Storage keeps orders in process memory. It is meant for tests and demo
+// mode and has the same semantics as postgres.Storage. Orders are copied
+// on the way in and out, so callers never share state with the store.
+type Storage struct {
+	mu        sync.RWMutex
+	orders    map[string]*models.Order
+	timelines map[string]*models.OrderTimeline
+}
+
+func New() *Storage {
+	return &Storage{
+		orders:    make(map[string]*models.Order),
+		timelines: make(map[string]*models.OrderTimeline),
+	}
+}
+
+func (s *Storage) SaveOrder(_ context.Context, order *models.Order) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.orders[order.OrderUID]; exists {
+		return fmt.Errorf("insert order: %w", storage.ErrOrderExists)
+	}
+
+	s.orders[order.OrderUID] = cloneOrder(order)
+	s.timelines[order.OrderUID] = &models.OrderTimeline{
+		OrderUID: order.OrderUID,
+		Status:   models.StatusCreated,
+		History: []models.StatusChange{{
+			Status:    models.StatusCreated,
+			Source:    models.StatusSourceOrder,
+			ChangedAt: order.DateCreated,
+		}},
+	}
+
+	return nil
+}
+
+func (s *Storage) GetOrderByUID(_ context.Context, orderUID string) (*models.Order, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	order, exists := s.orders[orderUID]
+	if !exists {
+		return nil, fmt.Errorf("get order: %w", storage.ErrOrderNotFound)
+	}
+
+	return cloneOrder(order), nil
+}
+
+func (s *Storage) OrderExists(_ context.Context, orderUID string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, exists := s.orders[orderUID]
+	return exists, nil
+}
+
+func (s *Storage) DeleteOrder(_ context.Context, orderUID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.orders[orderUID]; !exists {
+		return fmt.Errorf("delete order: %w", storage.ErrOrderNotFound)
+	}
+
+	delete(s.orders, orderUID)
+	delete(s.timelines, orderUID)
+
+	return nil
+}
+
+func (s *Storage) UpdateOrderStatus(_ context.Context, update models.StatusUpdate) error {
+	const op = "storage.memory.UpdateOrderStatus"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	timeline, exists := s.timelines[update.OrderUID]
+	if !exists {
+		return fmt.Errorf("%s: %w", op, storage.ErrOrderNotFound)
+	}
+
+	if update.ChrtID != 0 {
+		items := s.orders[update.OrderUID].Items
+
+		var codes []int
+		for _, item := range items {
+			if item.ChrtID == update.ChrtID {
+				codes = append(codes, item.Status)
+			}
+		}
+		changed, err := storage.CheckItemTransition(codes, update.Status)
+		if err != nil {
+			return fmt.Errorf("%s: chrt_id %d: %w", op, update.ChrtID, err)
+		}
+		if !changed {
+			return nil
+		}
+
+		for i := range items {
+			if items[i].ChrtID == update.ChrtID {
+				items[i].Status = update.Status.ItemCode()
+			}
+		}
+	} else {
+		if timeline.Status == update.Status {
+			return nil
+		}
+		if !timeline.Status.CanTransitionTo(update.Status) {
+			return fmt.Errorf("%s: %s -> %s: %w", op, timeline.Status, update.Status, storage.ErrIllegalTransition)
+		}
+
+		timeline.Status = update.Status
+	}
+
+	timeline.History = append(timeline.History, models.StatusChange{
+		Status:    update.Status,
+		Source:    update.Source,
+		ChangedAt: update.ChangedAt,
+		ChrtID:    update.ChrtID,
+	})
+
+	return nil
+}
+
+func (s *Storage) GetOrderTimeline(_ context.Context, orderUID string) (*models.OrderTimeline, error) {
+	const op = "storage.memory.GetOrderTimeline"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	timeline, exists := s.timelines[orderUID]
+	if !exists {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrOrderNotFound)
+	}
+
+	c := *timeline
+	c.History = make([]models.StatusChange, len(timeline.History))
+	copy(c.History, timeline.History)
+
+	return &c, nil
+}
+
+func (s *Storage) ListOrders(_ context.Context, filter storage.OrderFilter) (*storage.OrderPage, error) {
+	const op = "storage.memory.ListOrders"
+
+	var (
+		afterDate time.Time
+		afterUID  string
+	)
+	if filter.Cursor != "" {
+		var err error
+		afterDate, afterUID, err = storage.DecodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	limit := filter.PageLimit()
+
+	matched := s.find(func(o *models.Order) bool {
+		if filter.Cursor != "" && !before(o, afterDate, afterUID) {
+			return false
+		}
+		return matchFilter(o, filter)
+	})
+
+	page := &storage.OrderPage{Orders: matched}
+	if len(matched) > limit {
+		page.Orders = matched[:limit]
+		last := page.Orders[limit-1]
+		page.NextCursor = storage.EncodeCursor(last.DateCreated, last.OrderUID)
+	}
+
+	return page, nil
+}
+
+func (s *Storage) GetOrdersByTrackNumber(_ context.Context, trackNumber string) ([]*models.Order, error) {
+	return s.findLimited(func(o *models.Order) bool {
+		if o.TrackNumber == trackNumber {
+			return true
+		}
+		for _, item := range o.Items {
+			if item.TrackNumber == trackNumber {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+func (s *Storage) GetOrdersByPayment(_ context.Context, requestID, transaction string) ([]*models.Order, error) {
+	const op = "storage.memory.GetOrdersByPayment"
+
+	if requestID == "" && transaction == "" {
+		return nil, fmt.Errorf("%s: request_id or transaction must be provided", op)
+	}
+
+	return s.findLimited(func(o *models.Order) bool {
+		return (requestID == "" || o.Payment.RequestID == requestID) &&
+			(transaction == "" || o.OrderUID == transaction)
+	}), nil
+}
+
+func (s *Storage) GetOrdersByItem(_ context.Context, rid string, chrtID int) ([]*models.Order, error) {
+	const op = "storage.memory.GetOrdersByItem"
+
+	if rid == "" && chrtID == 0 {
+		return nil, fmt.Errorf("%s: rid or chrt_id must be provided", op)
+	}
+
+	return s.findLimited(func(o *models.Order) bool {
+		for _, item := range o.Items {
+			if (rid == "" || item.Rid == rid) && (chrtID == 0 || item.ChrtID == chrtID) {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+func (s *Storage) GetCustomerSummary(_ context.Context, customerID string) (*models.CustomerSummary, error) {
+	summary := &models.CustomerSummary{
+		CustomerID: customerID,
+		TotalSpent: make(map[string]int),
+		TopBrands:  make([]models.BrandCount, 0),
+	}
+
+	orders := s.find(func(o *models.Order) bool {
+		return o.CustomerID == customerID
+	})
+	if len(orders) == 0 {
+		return summary, nil
+	}
+
+	brands := make(map[string]int)
+	itemCount := 0
+	for _, o := range orders {
+		summary.TotalSpent[o.Payment.Currency] += o.Payment.Amount
+		itemCount += len(o.Items)
+		for _, item := range o.Items {
+			if item.Brand != "" {
+				brands[item.Brand]++
+			}
+		}
+	}
+
+	for brand, count := range brands {
+		summary.TopBrands = append(summary.TopBrands, models.BrandCount{Brand: brand, Count: count})
+	}
+	sort.Slice(summary.TopBrands, func(i, j int) bool {
+		a, b := summary.TopBrands[i], summary.TopBrands[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Brand < b.Brand
+	})
+	if len(summary.TopBrands) > storage.CustomerTopBrands {
+		summary.TopBrands = summary.TopBrands[:storage.CustomerTopBrands]
+	}
+
+	summary.OrderCount = len(orders)
+	summary.AvgBasketSize = float64(itemCount) / float64(len(orders))
+	summary.LastOrderAt = orders[0].DateCreated
+	summary.FirstOrderAt = orders[len(orders)-1].DateCreated
+
+	return summary, nil
+}
+
+// SearchOrders mimics the Postgres full-text search: every query word must
+// be a prefix of a word in the same item (name, brand) or in the delivery
+// (name, city, address). The rank is the number of matched rows.
+func (s *Storage) SearchOrders(_ context.Context, query string, limit int) ([]models.SearchResult, error) {
+	results := make([]models.SearchResult, 0)
+
+	terms := words(query)
+	if len(terms) == 0 {
+		return results, nil
+	}
+
+	for _, o := range s.find(func(*models.Order) bool { return true }) {
+		var highlights []string
+
+		for _, item := range o.Items {
+			if text := item.Name + " " + item.Brand; matchAll(text, terms) {
+				highlights = append(highlights, highlight(text, terms))
+			}
+		}
+		if text := o.Delivery.Name + ", " + o.Delivery.City + ", " + o.Delivery.Address; matchAll(text, terms) {
+			highlights = append(highlights, highlight(text, terms))
+		}
+
+		if len(highlights) > 0 {
+			results = append(results, models.SearchResult{
+				OrderUID:    o.OrderUID,
+				TrackNumber: o.TrackNumber,
+				CustomerID:  o.CustomerID,
+				DateCreated: o.DateCreated,
+				Rank:        float64(len(highlights)),
+				Highlights:  highlights,
+			})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Rank > results[j].Rank
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+// find returns copies of the orders matching fn, newest first.
+func (s *Storage) find(fn func(*models.Order) bool) []*models.Order {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	orders := make([]*models.Order, 0)
+	for _, o := range s.orders {
+		if fn(o) {
+			orders = append(orders, cloneOrder(o))
+		}
+	}
+
+	sort.Slice(orders, func(i, j int) bool {
+		return before(orders[j], orders[i].DateCreated, orders[i].OrderUID)
+	})
+
+	return orders
+}
+
+func (s *Storage) findLimited(fn func(*models.Order) bool) []*models.Order {
+	orders := s.find(fn)
+	if len(orders) > storage.MaxPageLimit {
+		orders = orders[:storage.MaxPageLimit]
+	}
+	return orders
+}
+
+// before reports whether o sorts after (date, uid) in newest-first order,
+// i.e. (o.DateCreated, o.OrderUID) < (date, uid).
+func before(o *models.Order, date time.Time, uid string) bool {
+	if o.DateCreated.Equal(date) {
+		return o.OrderUID < uid
+	}
+	return o.DateCreated.Before(date)
+}
+
+func matchFilter(o *models.Order, f storage.OrderFilter) bool {
+	eq := func(value, want string) bool {
+		return want == "" || value == want
+	}
+
+	if !eq(o.CustomerID, f.CustomerID) ||
+		!eq(o.TrackNumber, f.TrackNumber) ||
+		!eq(o.DeliveryService, f.DeliveryService) ||
+		!eq(o.Locale, f.Locale) ||
+		!eq(o.Payment.Currency, f.Currency) ||
+		!eq(o.Payment.Provider, f.Provider) ||
+		!eq(o.Payment.Bank, f.Bank) {
+		return false
+	}
+
+	if !f.CreatedFrom.IsZero() && o.DateCreated.Before(f.CreatedFrom) {
+		return false
+	}
+	if !f.CreatedTo.IsZero() && !o.DateCreated.Before(f.CreatedTo) {
+		return false
+	}
+
+	if f.Brand != "" {
+		for _, item := range o.Items {
+			if item.Brand == f.Brand {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func matchAll(text string, terms []string) bool {
+	textWords := words(text)
+	for _, term := range terms {
+		found := false
+		for _, w := range textWords {
+			if strings.HasPrefix(w, term) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// highlight wraps every word of text starting with one of terms in <b></b>,
+// like ts_headline does.
+func highlight(text string, terms []string) string {
+	fields := strings.Fields(text)
+	for i, field := range fields {
+		for _, w := range words(field) {
+			matched := false
+			for _, term := range terms {
+				if strings.HasPrefix(w, term) {
+					matched = true
+					break
+				}
+			}
+			if matched {
+				fields[i] = "<b>" + field + "</b>"
+				break
+			}
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+func cloneOrder(o *models.Order) *models.Order {
+	c := *o
+	c.Items = make([]models.Item, len(o.Items))
+	copy(c.Items, o.Items)
+	return &c
+}
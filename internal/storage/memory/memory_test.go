@@ -0,0 +1,19 @@
+package memory
+
+import (
+	"testing"
+	"wb-examples-l0/internal/storage"
+	"wb-examples-l0/internal/storage/storagetest"
+)
+
+func TestStorage_Contract(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.OrderRepository {
+		return New()
+	})
+}
+
+func TestStorage_StatusContract(t *testing.T) {
+	storagetest.RunStatus(t, func(t *testing.T) storage.StatusRepository {
+		return New()
+	})
+}
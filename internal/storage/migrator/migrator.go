@@ -0,0 +1,201 @@
+package migrator
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"strings"
+	"time"
+	"wb-examples-l0/migrations"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	_ "github.com/lib/pq"
+)
+
+// lockTimeout bounds how long Up/Down wait for the advisory lock held by
+// another replica that is migrating the same database.
+const lockTimeout = 5 * time.Minute
+
+var (
+	ErrSchemaOutdated = errors.New("database schema is outdated")
+	ErrSchemaDirty    = errors.New("database schema is dirty")
+)
+
+// Migrator applies the migrations embedded in the binary. It uses the same
+// schema_migrations table as the golang-migrate CLI, so databases migrated
+// by either are interchangeable. Every Up/Down runs under a Postgres
+// advisory lock, so replicas starting at once apply migrations only once.
+type Migrator struct {
+	m      *migrate.Migrate
+	source source.Driver
+}
+
+type MigrationStatus struct {
+	Version uint
+	Name    string
+	Applied bool
+}
+
+func New(dsn string, log *slog.Logger) (*Migrator, error) {
+	const op = "storage.migrator.New"
+
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	driver, err := postgres.WithInstance(db, &postgres.Config{})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, "postgres", driver)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	m.LockTimeout = lockTimeout
+	m.Log = &logger{log: log}
+
+	return &Migrator{
+		m:      m,
+		source: src,
+	}, nil
+}
+
+// Up applies all pending migrations.
+func (m *Migrator) Up() error {
+	const op = "storage.migrator.Up"
+
+	if err := m.m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Down rolls back the most recently applied migration.
+func (m *Migrator) Down() error {
+	const op = "storage.migrator.Down"
+
+	if err := m.m.Steps(-1); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Version returns the current schema version. A database without any
+// migrations applied has version 0.
+func (m *Migrator) Version() (uint, bool, error) {
+	const op = "storage.migrator.Version"
+
+	version, dirty, err := m.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return version, dirty, nil
+}
+
+// Latest returns the version of the newest embedded migration.
+func (m *Migrator) Latest() (uint, error) {
+	statuses, err := m.Status()
+	if err != nil {
+		return 0, err
+	}
+	if len(statuses) == 0 {
+		return 0, nil
+	}
+
+	return statuses[len(statuses)-1].Version, nil
+}
+
+// Status lists all embedded migrations, oldest first, marking those
+// already applied to the database.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	const op = "storage.migrator.Status"
+
+	current, _, err := m.Version()
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+
+	version, err := m.source.First()
+	for err == nil {
+		r, name, rerr := m.source.ReadUp(version)
+		if rerr != nil {
+			return nil, fmt.Errorf("%s: read migration %d: %w", op, version, rerr)
+		}
+		r.Close()
+
+		statuses = append(statuses, MigrationStatus{
+			Version: version,
+			Name:    name,
+			Applied: version <= current,
+		})
+
+		version, err = m.source.Next(version)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return statuses, nil
+}
+
+// CheckVersion returns ErrSchemaDirty if a previous migration failed
+// half-way and ErrSchemaOutdated if embedded migrations are pending.
+func (m *Migrator) CheckVersion() error {
+	current, dirty, err := m.Version()
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("%w: version %d", ErrSchemaDirty, current)
+	}
+
+	latest, err := m.Latest()
+	if err != nil {
+		return err
+	}
+	if current < latest {
+		return fmt.Errorf("%w: version %d, expected %d", ErrSchemaOutdated, current, latest)
+	}
+
+	return nil
+}
+
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.m.Close()
+	return errors.Join(srcErr, dbErr)
+}
+
+// logger adapts slog to migrate.Logger.
+type logger struct {
+	log *slog.Logger
+}
+
+func (l *logger) Printf(format string, v ...interface{}) {
+	l.log.Info(strings.TrimSpace(fmt.Sprintf(format, v...)), slog.String("component", "migrator"))
+}
+
+func (l *logger) Verbose() bool {
+	return false
+}
@@ -0,0 +1,228 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/storage/storagetest"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+// The benchmarks compare Storage with sqlStorage, the database/sql + lib/pq
+// implementation it replaced. Run them with
+//
+//	TEST_POSTGRES_DSN=... go test -run '^$' -bench . ./internal/storage/postgres
+
+// sqlStorage is the former database/sql implementation of SaveOrder and
+// GetOrderByUID, kept here as a baseline.
+type sqlStorage struct {
+	db *sql.DB
+}
+
+func newSQLStorage(b *testing.B) *sqlStorage {
+	b.Helper()
+
+	s := newTestStorage(b)
+
+	db, err := sql.Open("postgres", s.pool.Config().ConnConfig.ConnString())
+	require.NoError(b, err)
+	b.Cleanup(func() { db.Close() })
+
+	return &sqlStorage{db}
+}
+
+func (s *sqlStorage) SaveOrder(ctx context.Context, order *models.Order) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO order_uids (order_uid, date_created) VALUES ($1, $2)`,
+		order.OrderUID, order.DateCreated)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        INSERT INTO orders (order_uid, track_number, entry, locale, internal_signature,
+                          customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+    `, order.OrderUID, order.TrackNumber, order.Entry, order.Locale, order.InternalSignature,
+		order.CustomerID, order.DeliveryService, order.Shardkey, order.SmID, order.DateCreated, order.OofShard)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        INSERT INTO deliveries (order_uid, name, phone, zip, city, address, region, email)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    `, order.OrderUID, order.Delivery.Name, order.Delivery.Phone, order.Delivery.Zip,
+		order.Delivery.City, order.Delivery.Address, order.Delivery.Region, order.Delivery.Email)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        INSERT INTO payments (order_uid, request_id, currency, provider, amount,
+                             payment_dt, bank, delivery_cost, goods_total, custom_fee)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+    `, order.OrderUID, order.Payment.RequestID, order.Payment.Currency, order.Payment.Provider,
+		order.Payment.Amount, order.Payment.PaymentDt, order.Payment.Bank, order.Payment.DeliveryCost,
+		order.Payment.GoodsTotal, order.Payment.CustomFee)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range order.Items {
+		_, err = tx.ExecContext(ctx, `
+            INSERT INTO items (order_uid, chrt_id, track_number, price, rid, name,
+                              sale, size, total_price, nm_id, brand, status, date_created)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+        `, order.OrderUID, item.ChrtID, item.TrackNumber, item.Price, item.Rid, item.Name,
+			item.Sale, item.Size, item.TotalPrice, item.NmID, item.Brand, item.Status, order.DateCreated)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *sqlStorage) GetOrderByUID(ctx context.Context, orderUID string) (*models.Order, error) {
+	var order models.Order
+
+	err := s.db.QueryRowContext(ctx, `
+        SELECT order_uid, track_number, entry, locale, internal_signature,
+               customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard
+        FROM orders WHERE order_uid = $1
+    `, orderUID).Scan(
+		&order.OrderUID, &order.TrackNumber, &order.Entry, &order.Locale, &order.InternalSignature,
+		&order.CustomerID, &order.DeliveryService, &order.Shardkey, &order.SmID, &order.DateCreated, &order.OofShard,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.QueryRowContext(ctx, `
+        SELECT name, phone, zip, city, address, region, email
+        FROM deliveries WHERE order_uid = $1
+    `, orderUID).Scan(
+		&order.Delivery.Name, &order.Delivery.Phone, &order.Delivery.Zip, &order.Delivery.City,
+		&order.Delivery.Address, &order.Delivery.Region, &order.Delivery.Email,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.QueryRowContext(ctx, `
+        SELECT request_id, currency, provider, amount, payment_dt,
+               bank, delivery_cost, goods_total, custom_fee
+        FROM payments WHERE order_uid = $1
+    `, orderUID).Scan(
+		&order.Payment.RequestID, &order.Payment.Currency, &order.Payment.Provider, &order.Payment.Amount,
+		&order.Payment.PaymentDt, &order.Payment.Bank, &order.Payment.DeliveryCost, &order.Payment.GoodsTotal,
+		&order.Payment.CustomFee,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT chrt_id, track_number, price, rid, name, sale, size,
+               total_price, nm_id, brand, status
+        FROM items WHERE order_uid = $1
+    `, orderUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.Item
+		err := rows.Scan(
+			&item.ChrtID, &item.TrackNumber, &item.Price, &item.Rid, &item.Name, &item.Sale, &item.Size,
+			&item.TotalPrice, &item.NmID, &item.Brand, &item.Status,
+		)
+		if err != nil {
+			return nil, err
+		}
+		order.Items = append(order.Items, item)
+	}
+
+	return &order, rows.Err()
+}
+
+type orderSaver interface {
+	SaveOrder(ctx context.Context, order *models.Order) error
+}
+
+type orderGetter interface {
+	GetOrderByUID(ctx context.Context, orderUID string) (*models.Order, error)
+}
+
+// benchOrder returns an order with the given number of items.
+func benchOrder(uid string, items int) *models.Order {
+	order := storagetest.NewOrder(uid, 0)
+	item := order.Items[0]
+	order.Items = order.Items[:0]
+	for i := 0; i < items; i++ {
+		item.Rid = fmt.Sprintf("%s-%d", uid, i)
+		order.Items = append(order.Items, item)
+	}
+	return order
+}
+
+func BenchmarkSaveOrder(b *testing.B) {
+	for _, items := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("pgx/items=%d", items), func(b *testing.B) {
+			benchmarkSaveOrder(b, newTestStorage(b), items)
+		})
+		b.Run(fmt.Sprintf("database-sql/items=%d", items), func(b *testing.B) {
+			benchmarkSaveOrder(b, newSQLStorage(b), items)
+		})
+	}
+}
+
+func benchmarkSaveOrder(b *testing.B, s orderSaver, items int) {
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := s.SaveOrder(ctx, benchOrder(fmt.Sprintf("bench-save-%d", i), items)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetOrderByUID(b *testing.B) {
+	b.Run("pgx", func(b *testing.B) {
+		benchmarkGetOrderByUID(b, newTestStorage(b))
+	})
+	b.Run("database-sql", func(b *testing.B) {
+		benchmarkGetOrderByUID(b, newSQLStorage(b))
+	})
+}
+
+func benchmarkGetOrderByUID(b *testing.B, s interface {
+	orderSaver
+	orderGetter
+}) {
+	ctx := context.Background()
+
+	order := benchOrder("bench-get", 10)
+	require.NoError(b, s.SaveOrder(ctx, order))
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := s.GetOrderByUID(ctx, order.OrderUID); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
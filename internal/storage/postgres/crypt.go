@@ -0,0 +1,277 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"wb-examples-l0/internal/lib/fieldcrypt"
+	"wb-examples-l0/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrNoKeyring is returned when encrypted rows are read or re-encrypted
+// without a keyring configured.
+var ErrNoKeyring = errors.New("encryption keyring is not configured")
+
+// sealedDelivery holds the contact columns of a delivery as stored.
+// keyID, dek and emailHash are nil for plain text rows.
+type sealedDelivery struct {
+	phone, email, address string
+	keyID                 *string
+	dek                   []byte
+	emailHash             *string
+}
+
+// sealDelivery encrypts the phone, email and address of an order's
+// delivery. The ciphertexts are bound to the order UID. Without a keyring
+// the values are returned as is.
+func (s *Storage) sealDelivery(orderUID string, d *models.Delivery) (sealedDelivery, error) {
+	if s.keys == nil {
+		return sealedDelivery{phone: d.Phone, email: d.Email, address: d.Address}, nil
+	}
+
+	env, sealed, err := s.keys.Seal(orderUID, d.Phone, d.Email, d.Address)
+	if err != nil {
+		return sealedDelivery{}, err
+	}
+
+	emailHash := s.keys.BlindIndex(d.Email)
+
+	return sealedDelivery{
+		phone:     sealed[0],
+		email:     sealed[1],
+		address:   sealed[2],
+		keyID:     &env.KeyID,
+		dek:       env.WrappedKey,
+		emailHash: &emailHash,
+	}, nil
+}
+
+// openDelivery decrypts the contact data of d in place if keyID is set.
+func (s *Storage) openDelivery(orderUID string, d *models.Delivery, keyID *string, dek []byte) error {
+	if keyID == nil {
+		return nil
+	}
+	if s.keys == nil {
+		return ErrNoKeyring
+	}
+
+	values, err := s.keys.Open(fieldcrypt.Envelope{KeyID: *keyID, WrappedKey: dek}, orderUID,
+		d.Phone, d.Email, d.Address)
+	if err != nil {
+		return err
+	}
+
+	d.Phone, d.Email, d.Address = values[0], values[1], values[2]
+	return nil
+}
+
+// emailBlindIndex returns the blind index of email, or nil without a keyring.
+func (s *Storage) emailBlindIndex(email string) *string {
+	if s.keys == nil {
+		return nil
+	}
+
+	hash := s.keys.BlindIndex(email)
+	return &hash
+}
+
+// ReencryptDeliveries brings up to limit live and up to limit archived
+// deliveries to the primary key and returns how many were changed. Plain
+// text rows are encrypted; rows sealed with an older key get their data key
+// re-wrapped, the ciphertexts stay as they are. Every call is a separate
+// transaction, so callers repeat it until it returns 0.
+func (s *Storage) ReencryptDeliveries(ctx context.Context, limit int) (int, error) {
+	const op = "storage.postgres.ReencryptDeliveries"
+
+	if s.keys == nil {
+		return 0, fmt.Errorf("%s: %w", op, ErrNoKeyring)
+	}
+
+	var total int
+	for _, table := range []string{"deliveries", "deliveries_archive"} {
+		n, err := s.reencryptTable(ctx, table, limit)
+		if err != nil {
+			return total, fmt.Errorf("%s: %s: %w", op, table, err)
+		}
+		total += n
+	}
+
+	return total, nil
+}
+
+func (s *Storage) reencryptTable(ctx context.Context, table string, limit int) (int, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+        SELECT order_uid, phone, coalesce(email, ''), address, enc_key_id, enc_dek FROM `+table+`
+        WHERE enc_key_id IS DISTINCT FROM $1
+        ORDER BY order_uid
+        LIMIT $2
+        FOR UPDATE SKIP LOCKED
+    `, s.keys.Primary(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("select deliveries: %w", err)
+	}
+
+	type row struct {
+		orderUID string
+		delivery models.Delivery
+		keyID    *string
+		dek      []byte
+	}
+
+	pending, err := pgx.CollectRows(rows, func(r pgx.CollectableRow) (row, error) {
+		var v row
+		err := r.Scan(&v.orderUID, &v.delivery.Phone, &v.delivery.Email, &v.delivery.Address, &v.keyID, &v.dek)
+		return v, err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("select deliveries: %w", err)
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, r := range pending {
+		if r.keyID != nil {
+			env, err := s.keys.Rewrap(fieldcrypt.Envelope{KeyID: *r.keyID, WrappedKey: r.dek}, r.orderUID)
+			if err != nil {
+				return 0, fmt.Errorf("rewrap %s: %w", r.orderUID, err)
+			}
+			batch.Queue(`UPDATE `+table+` SET enc_key_id = $2, enc_dek = $3 WHERE order_uid = $1`,
+				r.orderUID, env.KeyID, env.WrappedKey)
+			continue
+		}
+
+		sealed, err := s.sealDelivery(r.orderUID, &r.delivery)
+		if err != nil {
+			return 0, fmt.Errorf("encrypt %s: %w", r.orderUID, err)
+		}
+		batch.Queue(`
+            UPDATE `+table+` SET phone = $2, email = $3, address = $4,
+                                 enc_key_id = $5, enc_dek = $6, email_hash = $7`+archiveVector(table, false)+`
+            WHERE order_uid = $1
+        `, r.orderUID, sealed.phone, sealed.email, sealed.address, sealed.keyID, sealed.dek, sealed.emailHash)
+	}
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return 0, fmt.Errorf("update deliveries: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return len(pending), nil
+}
+
+// DecryptDeliveries writes up to limit live and up to limit archived
+// encrypted deliveries back in plain text and returns how many were
+// changed. It undoes ReencryptDeliveries before encryption is turned off
+// or migration 000013 is rolled back. Every call is a separate
+// transaction, so callers repeat it until it returns 0.
+func (s *Storage) DecryptDeliveries(ctx context.Context, limit int) (int, error) {
+	const op = "storage.postgres.DecryptDeliveries"
+
+	if s.keys == nil {
+		return 0, fmt.Errorf("%s: %w", op, ErrNoKeyring)
+	}
+
+	var total int
+	for _, table := range []string{"deliveries", "deliveries_archive"} {
+		n, err := s.decryptTable(ctx, table, limit)
+		if err != nil {
+			return total, fmt.Errorf("%s: %s: %w", op, table, err)
+		}
+		total += n
+	}
+
+	return total, nil
+}
+
+func (s *Storage) decryptTable(ctx context.Context, table string, limit int) (int, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+        SELECT order_uid, phone, coalesce(email, ''), address, enc_key_id, enc_dek FROM `+table+`
+        WHERE enc_key_id IS NOT NULL
+        ORDER BY order_uid
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED
+    `, limit)
+	if err != nil {
+		return 0, fmt.Errorf("select deliveries: %w", err)
+	}
+
+	type row struct {
+		orderUID string
+		delivery models.Delivery
+		keyID    *string
+		dek      []byte
+	}
+
+	pending, err := pgx.CollectRows(rows, func(r pgx.CollectableRow) (row, error) {
+		var v row
+		err := r.Scan(&v.orderUID, &v.delivery.Phone, &v.delivery.Email, &v.delivery.Address, &v.keyID, &v.dek)
+		return v, err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("select deliveries: %w", err)
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, r := range pending {
+		if err := s.openDelivery(r.orderUID, &r.delivery, r.keyID, r.dek); err != nil {
+			return 0, fmt.Errorf("decrypt %s: %w", r.orderUID, err)
+		}
+		batch.Queue(`
+            UPDATE `+table+` SET phone = $2, email = $3, address = $4,
+                                 enc_key_id = NULL, enc_dek = NULL, email_hash = NULL`+archiveVector(table, true)+`
+            WHERE order_uid = $1
+        `, r.orderUID, r.delivery.Phone, r.delivery.Email, r.delivery.Address)
+	}
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return 0, fmt.Errorf("update deliveries: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return len(pending), nil
+}
+
+// archiveVector returns the assignment that recomputes the search_vector
+// of an archived delivery the way the generated column of deliveries does:
+// without the address when the row is encrypted, with it when it is
+// decrypted. The archive keeps a plain copy of the vector, which would
+// otherwise keep the address in the clear or stop finding it.
+func archiveVector(table string, address bool) string {
+	if table != "deliveries_archive" {
+		return ""
+	}
+	if address {
+		return `,
+                                 search_vector = setweight(to_tsvector('simple', coalesce(name, '')), 'B') ||
+                                                 setweight(to_tsvector('simple', coalesce(city, '')), 'B') ||
+                                                 setweight(to_tsvector('simple', coalesce(address, '')), 'C')`
+	}
+	return `,
+                                 search_vector = setweight(to_tsvector('simple', coalesce(name, '')), 'B') ||
+                                                 setweight(to_tsvector('simple', coalesce(city, '')), 'B')`
+}
@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/storage"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GetCustomerSummary aggregates all orders of a customer. A customer
+// without orders gets a summary with a zero OrderCount. The three
+// aggregate queries are sent as one batch.
+func (s *Storage) GetCustomerSummary(ctx context.Context, customerID string) (*models.CustomerSummary, error) {
+	const op = "storage.postgres.GetCustomerSummary"
+
+	summary := &models.CustomerSummary{
+		CustomerID: customerID,
+		TotalSpent: make(map[string]int),
+		TopBrands:  make([]models.BrandCount, 0),
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(`
+        SELECT count(*), min(o.date_created), max(o.date_created),
+               coalesce(sum((SELECT count(*) FROM items i WHERE i.order_uid = o.order_uid)), 0)::bigint
+        FROM orders o WHERE o.customer_id = $1 AND o.deleted_at IS NULL
+    `, customerID)
+	batch.Queue(`
+        SELECT p.currency, sum(p.amount)
+        FROM orders o JOIN payments p ON p.order_uid = o.order_uid
+        WHERE o.customer_id = $1 AND o.deleted_at IS NULL
+        GROUP BY p.currency
+    `, customerID)
+	batch.Queue(`
+        SELECT i.brand, count(*) AS cnt
+        FROM orders o JOIN items i ON i.order_uid = o.order_uid
+        WHERE o.customer_id = $1 AND o.deleted_at IS NULL AND i.brand <> ''
+        GROUP BY i.brand
+        ORDER BY cnt DESC, i.brand
+        LIMIT $2
+    `, customerID, storage.CustomerTopBrands)
+
+	results := s.reads.reader().SendBatch(ctx, batch)
+	defer results.Close()
+
+	var firstOrderAt, lastOrderAt *time.Time
+	var itemCount int
+	err := results.QueryRow().Scan(&summary.OrderCount, &firstOrderAt, &lastOrderAt, &itemCount)
+	if err != nil {
+		return nil, fmt.Errorf("%s: get order stats: %w", op, err)
+	}
+
+	if summary.OrderCount == 0 {
+		return summary, nil
+	}
+
+	summary.FirstOrderAt = *firstOrderAt
+	summary.LastOrderAt = *lastOrderAt
+	summary.AvgBasketSize = float64(itemCount) / float64(summary.OrderCount)
+
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("%s: get total spent: %w", op, err)
+	}
+
+	for rows.Next() {
+		var currency string
+		var total int
+		if err := rows.Scan(&currency, &total); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("%s: scan total spent: %w", op, err)
+		}
+		summary.TotalSpent[currency] = total
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	brandRows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("%s: get top brands: %w", op, err)
+	}
+	defer brandRows.Close()
+
+	for brandRows.Next() {
+		var bc models.BrandCount
+		if err := brandRows.Scan(&bc.Brand, &bc.Count); err != nil {
+			return nil, fmt.Errorf("%s: scan brand: %w", op, err)
+		}
+		summary.TopBrands = append(summary.TopBrands, bc)
+	}
+	if err := brandRows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"wb-examples-l0/internal/storage"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ClaimOrderShard records that an order is stored on shard, for the
+// sharded storage. Repeated claims for the same shard succeed; a claim by
+// another shard returns storage.ErrOrderExists. The directory is always
+// read and written on the primary.
+func (s *Storage) ClaimOrderShard(ctx context.Context, orderUID, shard string) error {
+	const op = "storage.postgres.ClaimOrderShard"
+
+	// The no-op update makes RETURNING report the existing row.
+	var owner string
+	err := s.pool.QueryRow(ctx, `
+        INSERT INTO shard_directory (order_uid, shard) VALUES ($1, $2)
+        ON CONFLICT (order_uid) DO UPDATE SET shard = shard_directory.shard
+        RETURNING shard
+    `, orderUID, shard).Scan(&owner)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if owner != shard {
+		return fmt.Errorf("%s: claimed by shard %s: %w", op, owner, storage.ErrOrderExists)
+	}
+
+	return nil
+}
+
+// LookupOrderShard returns the shard of an order.
+func (s *Storage) LookupOrderShard(ctx context.Context, orderUID string) (string, error) {
+	const op = "storage.postgres.LookupOrderShard"
+
+	var shard string
+	err := s.pool.QueryRow(ctx, `SELECT shard FROM shard_directory WHERE order_uid = $1`, orderUID).Scan(&shard)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", fmt.Errorf("%s: %w", op, storage.ErrOrderNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return shard, nil
+}
+
+// ReleaseOrderShard removes an order from the directory.
+func (s *Storage) ReleaseOrderShard(ctx context.Context, orderUID string) error {
+	const op = "storage.postgres.ReleaseOrderShard"
+
+	if _, err := s.pool.Exec(ctx, `DELETE FROM shard_directory WHERE order_uid = $1`, orderUID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,215 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/storage"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ListOrders returns one page of orders matching the filter, newest first.
+// Pagination is keyset-based on (date_created, order_uid), which is served
+// by idx_orders_date_created_uid / idx_orders_customer_id_date_created.
+func (s *Storage) ListOrders(ctx context.Context, filter storage.OrderFilter) (*storage.OrderPage, error) {
+	const op = "storage.postgres.ListOrders"
+
+	limit := filter.PageLimit()
+
+	query, args, err := buildListQuery(filter, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	uids, err := s.queryUIDs(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	hasMore := len(uids) > limit
+	if hasMore {
+		uids = uids[:limit]
+	}
+
+	orders, err := s.getOrdersByUIDs(ctx, uids)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	page := &storage.OrderPage{Orders: orders}
+	if hasMore && len(orders) > 0 {
+		last := orders[len(orders)-1]
+		page.NextCursor = storage.EncodeCursor(last.DateCreated, last.OrderUID)
+	}
+
+	return page, nil
+}
+
+func buildListQuery(filter storage.OrderFilter, limit int) (string, []interface{}, error) {
+	var (
+		where = []string{"o.deleted_at IS NULL"}
+		args  []interface{}
+	)
+
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	eq := func(column, value string) {
+		if value != "" {
+			where = append(where, column+" = "+arg(value))
+		}
+	}
+
+	eq("o.customer_id", filter.CustomerID)
+	eq("o.track_number", filter.TrackNumber)
+	eq("o.delivery_service", filter.DeliveryService)
+	eq("o.locale", filter.Locale)
+
+	if !filter.CreatedFrom.IsZero() {
+		where = append(where, "o.date_created >= "+arg(filter.CreatedFrom))
+	}
+	if !filter.CreatedTo.IsZero() {
+		where = append(where, "o.date_created < "+arg(filter.CreatedTo))
+	}
+
+	joinPayments := filter.Currency != "" || filter.Provider != "" || filter.Bank != ""
+	eq("p.currency", filter.Currency)
+	eq("p.provider", filter.Provider)
+	eq("p.bank", filter.Bank)
+
+	if filter.Brand != "" {
+		where = append(where,
+			"EXISTS (SELECT 1 FROM items i WHERE i.order_uid = o.order_uid AND i.brand = "+arg(filter.Brand)+")")
+	}
+
+	if filter.Cursor != "" {
+		dateCreated, uid, err := storage.DecodeCursor(filter.Cursor)
+		if err != nil {
+			return "", nil, err
+		}
+		where = append(where, fmt.Sprintf("(o.date_created, o.order_uid) < (%s, %s)", arg(dateCreated), arg(uid)))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("SELECT o.order_uid FROM orders o")
+	if joinPayments {
+		sb.WriteString(" JOIN payments p ON p.order_uid = o.order_uid")
+	}
+	sb.WriteString(" WHERE ")
+	sb.WriteString(strings.Join(where, " AND "))
+	sb.WriteString(" ORDER BY o.date_created DESC, o.order_uid DESC LIMIT ")
+	sb.WriteString(arg(limit))
+
+	return sb.String(), args, nil
+}
+
+// getOrdersByUIDs loads full orders for the given UIDs and returns them in
+// the order of uids. Unknown and soft-deleted UIDs are skipped. Orders and items are read
+// with two queries sent as one batch, i.e. in a single round trip and an
+// implicit transaction. Both go through order_uids, so only the partitions
+// holding the requested orders are read.
+func (s *Storage) getOrdersByUIDs(ctx context.Context, uids []string) ([]*models.Order, error) {
+	orders := make([]*models.Order, 0, len(uids))
+	if len(uids) == 0 {
+		return orders, nil
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(`
+        SELECT o.order_uid, o.track_number, o.entry, o.locale, o.internal_signature,
+               o.customer_id, o.delivery_service, o.shardkey, o.sm_id, o.date_created, o.oof_shard,
+               d.name, d.phone, d.zip, d.city, d.address, d.region, d.email,
+               d.enc_key_id, d.enc_dek,
+               p.request_id, p.currency, p.provider, p.amount, p.payment_dt,
+               p.bank, p.delivery_cost, p.goods_total, p.custom_fee
+        FROM order_uids u
+        `+joinOrders+`
+        JOIN deliveries d ON d.order_uid = o.order_uid
+        JOIN payments p ON p.order_uid = o.order_uid
+        WHERE u.order_uid = ANY($1) AND o.deleted_at IS NULL
+    `, uids)
+	batch.Queue(`
+        SELECT i.order_uid, i.chrt_id, i.track_number, i.price, i.rid, i.name, i.sale, i.size,
+               i.total_price, i.nm_id, i.brand, i.status
+        FROM order_uids u
+        JOIN items i ON i.order_uid = u.order_uid AND i.date_created = u.date_created
+        WHERE u.order_uid = ANY($1)
+        ORDER BY i.id
+    `, uids)
+
+	results := s.reads.readerFor(uids...).SendBatch(ctx, batch)
+	defer results.Close()
+
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("get orders: %w", err)
+	}
+
+	byUID := make(map[string]*models.Order, len(uids))
+	for rows.Next() {
+		var (
+			order models.Order
+			keyID *string
+			dek   []byte
+		)
+		err := rows.Scan(
+			&order.OrderUID, &order.TrackNumber, &order.Entry, &order.Locale, &order.InternalSignature,
+			&order.CustomerID, &order.DeliveryService, &order.Shardkey, &order.SmID, &order.DateCreated, &order.OofShard,
+			&order.Delivery.Name, &order.Delivery.Phone, &order.Delivery.Zip, &order.Delivery.City,
+			&order.Delivery.Address, &order.Delivery.Region, &order.Delivery.Email,
+			&keyID, &dek,
+			&order.Payment.RequestID, &order.Payment.Currency, &order.Payment.Provider, &order.Payment.Amount,
+			&order.Payment.PaymentDt, &order.Payment.Bank, &order.Payment.DeliveryCost, &order.Payment.GoodsTotal,
+			&order.Payment.CustomFee,
+		)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan order: %w", err)
+		}
+		if err := s.openDelivery(order.OrderUID, &order.Delivery, keyID, dek); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("decrypt delivery %s: %w", order.OrderUID, err)
+		}
+		order.Payment.Transaction = order.OrderUID
+		order.Items = make([]models.Item, 0)
+		byUID[order.OrderUID] = &order
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	itemRows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("get items: %w", err)
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var item models.Item
+		err := itemRows.Scan(
+			&item.OrderUID, &item.ChrtID, &item.TrackNumber, &item.Price, &item.Rid, &item.Name, &item.Sale, &item.Size,
+			&item.TotalPrice, &item.NmID, &item.Brand, &item.Status,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan item: %w", err)
+		}
+		if order, ok := byUID[item.OrderUID]; ok {
+			order.Items = append(order.Items, item)
+		}
+	}
+	if err := itemRows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	for _, uid := range uids {
+		if order, ok := byUID[uid]; ok {
+			orders = append(orders, order)
+		}
+	}
+
+	return orders, nil
+}
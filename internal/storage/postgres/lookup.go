@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/storage"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GetOrdersByTrackNumber returns orders whose own track number or one of
+// whose items' track numbers matches, newest first.
+func (s *Storage) GetOrdersByTrackNumber(ctx context.Context, trackNumber string) ([]*models.Order, error) {
+	const op = "storage.postgres.GetOrdersByTrackNumber"
+
+	orders, err := s.findOrders(ctx, `
+        SELECT o.order_uid FROM orders o
+        WHERE o.deleted_at IS NULL
+          AND (o.track_number = $1
+           OR EXISTS (SELECT 1 FROM items i WHERE i.order_uid = o.order_uid AND i.track_number = $1))
+        ORDER BY o.date_created DESC, o.order_uid DESC
+        LIMIT $2
+    `, trackNumber, storage.MaxPageLimit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return orders, nil
+}
+
+// GetOrdersByPayment returns orders matching the payment request_id and/or
+// transaction. Empty arguments are not applied, but at least one is required.
+// The transaction always equals the order_uid, so it is matched against it.
+func (s *Storage) GetOrdersByPayment(ctx context.Context, requestID, transaction string) ([]*models.Order, error) {
+	const op = "storage.postgres.GetOrdersByPayment"
+
+	if requestID == "" && transaction == "" {
+		return nil, fmt.Errorf("%s: request_id or transaction must be provided", op)
+	}
+
+	orders, err := s.findOrders(ctx, `
+        SELECT o.order_uid FROM orders o
+        JOIN payments p ON p.order_uid = o.order_uid
+        WHERE o.deleted_at IS NULL
+          AND ($1 = '' OR p.request_id = $1)
+          AND ($2 = '' OR p.order_uid = $2)
+        ORDER BY o.date_created DESC, o.order_uid DESC
+        LIMIT $3
+    `, requestID, transaction, storage.MaxPageLimit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return orders, nil
+}
+
+// GetOrdersByItem returns orders containing an item with the given rid
+// and/or chrt_id. Empty rid and zero chrtID are not applied, but at least
+// one is required.
+func (s *Storage) GetOrdersByItem(ctx context.Context, rid string, chrtID int) ([]*models.Order, error) {
+	const op = "storage.postgres.GetOrdersByItem"
+
+	if rid == "" && chrtID == 0 {
+		return nil, fmt.Errorf("%s: rid or chrt_id must be provided", op)
+	}
+
+	orders, err := s.findOrders(ctx, `
+        SELECT o.order_uid FROM orders o
+        WHERE o.deleted_at IS NULL
+          AND EXISTS (
+            SELECT 1 FROM items i
+            WHERE i.order_uid = o.order_uid
+              AND ($1 = '' OR i.rid = $1)
+              AND ($2 = 0 OR i.chrt_id = $2)
+        )
+        ORDER BY o.date_created DESC, o.order_uid DESC
+        LIMIT $3
+    `, rid, chrtID, storage.MaxPageLimit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return orders, nil
+}
+
+// findOrders runs a query selecting order_uid values and loads the orders.
+func (s *Storage) findOrders(ctx context.Context, query string, args ...interface{}) ([]*models.Order, error) {
+	uids, err := s.queryUIDs(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getOrdersByUIDs(ctx, uids)
+}
+
+// queryUIDs runs a query selecting order_uid values on a read pool.
+func (s *Storage) queryUIDs(ctx context.Context, query string, args ...interface{}) ([]string, error) {
+	rows, err := s.reads.reader().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query order UIDs: %w", err)
+	}
+
+	uids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("scan order UID: %w", err)
+	}
+
+	return uids, nil
+}
@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	poolAcquiredConns = prometheus.NewDesc(
+		"orders_db_pool_acquired_conns",
+		"Number of connections currently acquired from the pool.",
+		nil, nil,
+	)
+	poolIdleConns = prometheus.NewDesc(
+		"orders_db_pool_idle_conns",
+		"Number of idle connections in the pool.",
+		nil, nil,
+	)
+	poolTotalConns = prometheus.NewDesc(
+		"orders_db_pool_total_conns",
+		"Total number of connections in the pool.",
+		nil, nil,
+	)
+	poolMaxConns = prometheus.NewDesc(
+		"orders_db_pool_max_conns",
+		"Maximum size of the pool.",
+		nil, nil,
+	)
+	poolAcquireTotal = prometheus.NewDesc(
+		"orders_db_pool_acquire_total",
+		"Number of successful connection acquires.",
+		nil, nil,
+	)
+	poolEmptyAcquireTotal = prometheus.NewDesc(
+		"orders_db_pool_empty_acquire_total",
+		"Number of acquires that had to wait for a connection.",
+		nil, nil,
+	)
+	poolCanceledAcquireTotal = prometheus.NewDesc(
+		"orders_db_pool_canceled_acquire_total",
+		"Number of acquires canceled by their context.",
+		nil, nil,
+	)
+	poolAcquireSeconds = prometheus.NewDesc(
+		"orders_db_pool_acquire_seconds_total",
+		"Total time spent acquiring connections.",
+		nil, nil,
+	)
+	poolWaitSeconds = prometheus.NewDesc(
+		"orders_db_pool_wait_seconds_total",
+		"Total time spent waiting for a connection when the pool was empty.",
+		nil, nil,
+	)
+	replicaHealthy = prometheus.NewDesc(
+		"orders_db_replica_healthy",
+		"Whether the read replica passed its last health and lag check.",
+		[]string{"replica"}, nil,
+	)
+	replicaLagSeconds = prometheus.NewDesc(
+		"orders_db_replica_lag_seconds",
+		"Replication lag of the read replica at its last check.",
+		[]string{"replica"}, nil,
+	)
+	replicaFallbackTotal = prometheus.NewDesc(
+		"orders_db_replica_fallback_total",
+		"Number of reads sent to the primary because no replica was healthy.",
+		nil, nil,
+	)
+)
+
+// Describe implements prometheus.Collector.
+func (s *Storage) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolAcquiredConns
+	ch <- poolIdleConns
+	ch <- poolTotalConns
+	ch <- poolMaxConns
+	ch <- poolAcquireTotal
+	ch <- poolEmptyAcquireTotal
+	ch <- poolCanceledAcquireTotal
+	ch <- poolAcquireSeconds
+	ch <- poolWaitSeconds
+	ch <- replicaHealthy
+	ch <- replicaLagSeconds
+	ch <- replicaFallbackTotal
+}
+
+// Collect implements prometheus.Collector by reading the pool stats and
+// the replica state on every scrape.
+func (s *Storage) Collect(ch chan<- prometheus.Metric) {
+	stat := s.pool.Stat()
+
+	ch <- prometheus.MustNewConstMetric(poolAcquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(poolIdleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(poolTotalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(poolMaxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(poolAcquireTotal, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolEmptyAcquireTotal, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolCanceledAcquireTotal, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolAcquireSeconds, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(poolWaitSeconds, prometheus.CounterValue, stat.EmptyAcquireWaitTime().Seconds())
+
+	for _, rep := range s.reads.replicas {
+		var healthy float64
+		if rep.healthy.Load() {
+			healthy = 1
+		}
+		ch <- prometheus.MustNewConstMetric(replicaHealthy, prometheus.GaugeValue, healthy, rep.name)
+		ch <- prometheus.MustNewConstMetric(replicaLagSeconds, prometheus.GaugeValue,
+			time.Duration(rep.lag.Load()).Seconds(), rep.name)
+	}
+	ch <- prometheus.MustNewConstMetric(replicaFallbackTotal, prometheus.CounterValue, float64(s.reads.fallbacks.Load()))
+}
@@ -0,0 +1,23 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// CreatePartitions makes sure the monthly partitions of orders and items
+// exist for months months starting with the month of since, and returns
+// how many tables were created. It is safe to call from several instances
+// at once.
+func (s *Storage) CreatePartitions(ctx context.Context, since time.Time, months int) (int, error) {
+	const op = "storage.postgres.CreatePartitions"
+
+	var created int
+	err := s.pool.QueryRow(ctx, `SELECT create_order_partitions($1, $2)`, since, months).Scan(&created)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return created, nil
+}
@@ -2,216 +2,259 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 	"wb-examples-l0/internal/config"
+	"wb-examples-l0/internal/lib/fieldcrypt"
 	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/storage"
 
-	_ "github.com/lib/pq"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// Storage keeps orders in Postgres. Queries go through a pgx pool in the
+// default QueryExecModeCacheStatement mode: every statement is prepared
+// once per connection and reused afterwards.
+//
+// With a keyring configured, delivery phone, email and address are
+// encrypted on write and decrypted on read, see crypt.go. Writes use pool,
+// reads the pool picked by reads, see replicas.go.
 type Storage struct {
-	db *sql.DB
+	pool  *pgxpool.Pool
+	reads *replicaRouter
+	keys  *fieldcrypt.Keyring
 }
 
 func New(cfg *config.Config) (*Storage, error) {
 	const op = "storage.postgres.New"
 
-	db, err := sql.Open("postgres", cfg.Storage.Postgres.Dsn)
+	poolCfg, err := pgxpool.ParseConfig(cfg.Storage.Postgres.Dsn)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	db.SetMaxOpenConns(cfg.Storage.Postgres.MaxOpenConns)
+	enc := cfg.Storage.Postgres.Encryption
+	keys, err := fieldcrypt.Load(enc.Keys, enc.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
 
-	db.SetMaxIdleConns(cfg.Storage.Postgres.MaxIdleConns)
+	if cfg.Storage.Postgres.MaxOpenConns > 0 {
+		poolCfg.MaxConns = int32(cfg.Storage.Postgres.MaxOpenConns)
+	}
+	poolCfg.MinConns = int32(cfg.Storage.Postgres.MinConns)
 
 	duration, err := time.ParseDuration(cfg.Storage.Postgres.MaxIdleTime)
 	if err != nil {
 		return nil, err
 	}
 
-	db.SetConnMaxIdleTime(duration)
+	poolCfg.MaxConnIdleTime = duration
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	err = db.PingContext(ctx)
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	err = pool.Ping(ctx)
+	if err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	replicas := make([]*replica, 0, len(cfg.Storage.Postgres.Replicas.Dsns))
+	for _, dsn := range cfg.Storage.Postgres.Replicas.Dsns {
+		rep, err := newReplica(dsn, poolCfg)
+		if err != nil {
+			for _, r := range replicas {
+				r.pool.Close()
+			}
+			pool.Close()
+			return nil, fmt.Errorf("%s: replica: %w", op, err)
+		}
+		replicas = append(replicas, rep)
+	}
+
+	rcfg := cfg.Storage.Postgres.Replicas
+	reads := newReplicaRouter(pool, replicas, rcfg.MaxLag, rcfg.CheckInterval, rcfg.ReadYourWrites)
+	reads.start()
+
 	return &Storage{
-		db,
+		pool:  pool,
+		reads: reads,
+		keys:  keys,
 	}, nil
 }
 
-//	TODO - добавить контекст в запросах
+func (s *Storage) Close() {
+	s.reads.close()
+	s.pool.Close()
+}
+
+// uniqueViolation is the Postgres error code for a duplicate key.
+const uniqueViolation = "23505"
 
-func (s *Storage) SaveOrder(order *models.Order) error {
-	tx, err := s.db.Begin()
+// joinOrders joins orders to order_uids u on the full primary key, so a
+// lookup by order_uid reads a single partition of orders.
+const joinOrders = `JOIN orders o ON o.order_uid = u.order_uid AND o.date_created = u.date_created`
+
+// itemColumns are the items columns written by SaveOrder with COPY;
+// search_vector is generated by Postgres. date_created is the partition
+// key, copied from the order.
+var itemColumns = []string{
+	"order_uid", "chrt_id", "track_number", "price", "rid", "name",
+	"sale", "size", "total_price", "nm_id", "brand", "status", "date_created",
+}
+
+// SaveOrder writes the order, delivery, payment and initial status in one
+// batch and the items with COPY, all in a single transaction. The UID is
+// claimed in order_uids first, which keeps it unique across partitions.
+// Archived UIDs are treated as taken.
+func (s *Storage) SaveOrder(ctx context.Context, order *models.Order) error {
+	delivery, err := s.sealDelivery(order.OrderUID, &order.Delivery)
+	if err != nil {
+		return fmt.Errorf("encrypt delivery: %w", err)
+	}
+
+	tx, err := s.pool.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("begin transaction: %w", err)
 	}
-	defer tx.Rollback()
+	defer tx.Rollback(ctx)
 
-	_, err = tx.Exec(`
+	batch := &pgx.Batch{}
+	batch.Queue(`SELECT EXISTS(SELECT 1 FROM orders_archive WHERE order_uid = $1)`, order.OrderUID)
+	batch.Queue(`INSERT INTO order_uids (order_uid, date_created) VALUES ($1, $2)`,
+		order.OrderUID, order.DateCreated)
+	batch.Queue(`
         INSERT INTO orders (order_uid, track_number, entry, locale, internal_signature, 
                           customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard)
         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
     `, order.OrderUID, order.TrackNumber, order.Entry, order.Locale, order.InternalSignature,
 		order.CustomerID, order.DeliveryService, order.Shardkey, order.SmID, order.DateCreated, order.OofShard)
-	if err != nil {
-		return fmt.Errorf("insert order: %w", err)
-	}
-
-	_, err = tx.Exec(`
-        INSERT INTO deliveries (order_uid, name, phone, zip, city, address, region, email)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-    `, order.OrderUID, order.Delivery.Name, order.Delivery.Phone, order.Delivery.Zip,
-		order.Delivery.City, order.Delivery.Address, order.Delivery.Region, order.Delivery.Email)
-	if err != nil {
-		return fmt.Errorf("insert delivery: %w", err)
-	}
-
-	_, err = tx.Exec(`
+	batch.Queue(`
+        INSERT INTO deliveries (order_uid, name, phone, zip, city, address, region, email,
+                                enc_key_id, enc_dek, email_hash)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+    `, order.OrderUID, order.Delivery.Name, delivery.phone, order.Delivery.Zip,
+		order.Delivery.City, delivery.address, order.Delivery.Region, delivery.email,
+		delivery.keyID, delivery.dek, delivery.emailHash)
+	batch.Queue(`
         INSERT INTO payments (order_uid, request_id, currency, provider, amount, 
                              payment_dt, bank, delivery_cost, goods_total, custom_fee)
         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
     `, order.OrderUID, order.Payment.RequestID, order.Payment.Currency, order.Payment.Provider,
 		order.Payment.Amount, order.Payment.PaymentDt, order.Payment.Bank, order.Payment.DeliveryCost,
 		order.Payment.GoodsTotal, order.Payment.CustomFee)
-	if err != nil {
+	batch.Queue(`
+        INSERT INTO status_history (order_uid, status, source, changed_at)
+        VALUES ($1, $2, $3, $4)
+    `, order.OrderUID, models.StatusCreated, models.StatusSourceOrder, order.DateCreated)
+
+	results := tx.SendBatch(ctx, batch)
+
+	// An archived order must not come back when Kafka redelivers it.
+	var archived bool
+	if err := results.QueryRow().Scan(&archived); err != nil {
+		results.Close()
+		return fmt.Errorf("check archive: %w", err)
+	}
+	if archived {
+		results.Close()
+		return fmt.Errorf("insert order: %w", storage.ErrOrderExists)
+	}
+	if _, err := results.Exec(); err != nil {
+		results.Close()
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return fmt.Errorf("insert order: %w", storage.ErrOrderExists)
+		}
+		return fmt.Errorf("insert order: %w", err)
+	}
+	if _, err := results.Exec(); err != nil {
+		results.Close()
+		return fmt.Errorf("insert order: %w", err)
+	}
+	if _, err := results.Exec(); err != nil {
+		results.Close()
+		return fmt.Errorf("insert delivery: %w", err)
+	}
+	if _, err := results.Exec(); err != nil {
+		results.Close()
 		return fmt.Errorf("insert payment: %w", err)
 	}
+	if _, err := results.Exec(); err != nil {
+		results.Close()
+		return fmt.Errorf("insert status: %w", err)
+	}
+	if err := results.Close(); err != nil {
+		return fmt.Errorf("insert order: %w", err)
+	}
 
-	for _, item := range order.Items {
-		_, err = tx.Exec(`
-            INSERT INTO items (order_uid, chrt_id, track_number, price, rid, name, 
-                              sale, size, total_price, nm_id, brand, status)
-            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
-        `, order.OrderUID, item.ChrtID, item.TrackNumber, item.Price, item.Rid, item.Name,
-			item.Sale, item.Size, item.TotalPrice, item.NmID, item.Brand, item.Status)
-		if err != nil {
-			return fmt.Errorf("insert item: %w", err)
-		}
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"items"}, itemColumns,
+		pgx.CopyFromSlice(len(order.Items), func(i int) ([]any, error) {
+			item := order.Items[i]
+			return []any{
+				order.OrderUID, item.ChrtID, item.TrackNumber, item.Price, item.Rid, item.Name,
+				item.Sale, item.Size, item.TotalPrice, item.NmID, item.Brand, item.Status,
+				order.DateCreated,
+			}, nil
+		}))
+	if err != nil {
+		return fmt.Errorf("insert items: %w", err)
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := tx.Commit(ctx); err != nil {
 		return fmt.Errorf("commit transaction: %w", err)
 	}
+	s.reads.wrote(order.OrderUID)
 
 	return nil
 }
 
-func (s *Storage) GetOrderByUID(orderUID string) (*models.Order, error) {
-	var order models.Order
-
-	err := s.db.QueryRow(`
-        SELECT order_uid, track_number, entry, locale, internal_signature, 
-               customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard
-        FROM orders WHERE order_uid = $1
-    `, orderUID).Scan(
-		&order.OrderUID, &order.TrackNumber, &order.Entry, &order.Locale, &order.InternalSignature,
-		&order.CustomerID, &order.DeliveryService, &order.Shardkey, &order.SmID, &order.DateCreated, &order.OofShard,
-	)
+func (s *Storage) GetOrderByUID(ctx context.Context, orderUID string) (*models.Order, error) {
+	orders, err := s.getOrdersByUIDs(ctx, []string{orderUID})
 	if err != nil {
 		return nil, fmt.Errorf("get order: %w", err)
 	}
-
-	err = s.db.QueryRow(`
-        SELECT name, phone, zip, city, address, region, email
-        FROM deliveries WHERE order_uid = $1
-    `, orderUID).Scan(
-		&order.Delivery.Name, &order.Delivery.Phone, &order.Delivery.Zip, &order.Delivery.City,
-		&order.Delivery.Address, &order.Delivery.Region, &order.Delivery.Email,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("get delivery: %w", err)
-	}
-
-	err = s.db.QueryRow(`
-        SELECT request_id, currency, provider, amount, payment_dt, 
-               bank, delivery_cost, goods_total, custom_fee
-        FROM payments WHERE order_uid = $1
-    `, orderUID).Scan(
-		&order.Payment.RequestID, &order.Payment.Currency, &order.Payment.Provider, &order.Payment.Amount,
-		&order.Payment.PaymentDt, &order.Payment.Bank, &order.Payment.DeliveryCost, &order.Payment.GoodsTotal,
-		&order.Payment.CustomFee,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("get payment: %w", err)
-	}
-	order.Payment.Transaction = orderUID
-
-	rows, err := s.db.Query(`
-        SELECT chrt_id, track_number, price, rid, name, sale, size, 
-               total_price, nm_id, brand, status
-        FROM items WHERE order_uid = $1
-    `, orderUID)
-	if err != nil {
-		return nil, fmt.Errorf("get items: %w", err)
-	}
-	defer rows.Close()
-
-	order.Items = make([]models.Item, 0)
-	for rows.Next() {
-		var item models.Item
-		err := rows.Scan(
-			&item.ChrtID, &item.TrackNumber, &item.Price, &item.Rid, &item.Name, &item.Sale, &item.Size,
-			&item.TotalPrice, &item.NmID, &item.Brand, &item.Status,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("scan item: %w", err)
-		}
-		order.Items = append(order.Items, item)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows error: %w", err)
+	if len(orders) == 0 {
+		return nil, fmt.Errorf("get order: %w", storage.ErrOrderNotFound)
 	}
 
-	return &order, nil
+	return orders[0], nil
 }
 
-func (s *Storage) OrderExists(orderUID string) (bool, error) {
+// OrderExists reads from the primary: it guards writes, and a lagging
+// replica would report orders that were just saved as missing.
+func (s *Storage) OrderExists(ctx context.Context, orderUID string) (bool, error) {
 	var exists bool
-	err := s.db.QueryRow(`
-        SELECT EXISTS(SELECT 1 FROM orders WHERE order_uid = $1)
+	err := s.pool.QueryRow(ctx, `
+        SELECT EXISTS(
+            SELECT 1 FROM order_uids u `+joinOrders+`
+            WHERE u.order_uid = $1 AND o.deleted_at IS NULL
+        )
     `, orderUID).Scan(&exists)
 	return exists, err
 }
 
-func (s *Storage) GetAllLimitOrderUIDs(limit int) ([]string, error) {
-	var query string
-	var args []interface{}
-
-	if limit > 0 {
-		query = "SELECT order_uid FROM orders ORDER BY date_created DESC LIMIT $1"
-		args = []interface{}{limit}
-	} else {
-		query = "SELECT order_uid FROM orders ORDER BY date_created DESC"
-	}
-
-	rows, err := s.db.Query(query, args...)
+// DeleteOrder removes an order by its order_uids entry; the order,
+// delivery, payment, items and status history are removed by ON DELETE
+// CASCADE.
+func (s *Storage) DeleteOrder(ctx context.Context, orderUID string) error {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM order_uids WHERE order_uid = $1`, orderUID)
 	if err != nil {
-		return nil, fmt.Errorf("query order UIDs: %w", err)
+		return fmt.Errorf("delete order: %w", err)
 	}
-	defer rows.Close()
 
-	var uids []string
-	for rows.Next() {
-		var uid string
-		if err := rows.Scan(&uid); err != nil {
-			return nil, fmt.Errorf("scan order UID: %w", err)
-		}
-		uids = append(uids, uid)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows error: %w", err)
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("delete order: %w", storage.ErrOrderNotFound)
 	}
 
-	return uids, nil
+	return nil
 }
@@ -0,0 +1,404 @@
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+	"wb-examples-l0/internal/config"
+	"wb-examples-l0/internal/lib/fieldcrypt"
+	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/storage"
+	"wb-examples-l0/internal/storage/migrator"
+	"wb-examples-l0/internal/storage/storagetest"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestStorage connects to the database from TEST_POSTGRES_DSN, applies
+// migrations and empties all tables. The test is skipped without a DSN.
+func newTestStorage(t testing.TB) *Storage {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+
+	m, err := migrator.New(dsn, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	require.NoError(t, m.Up())
+	require.NoError(t, m.Close())
+
+	var cfg config.Config
+	cfg.Storage.Postgres.Dsn = dsn
+	cfg.Storage.Postgres.MaxOpenConns = 10
+	cfg.Storage.Postgres.MaxIdleTime = "1m"
+
+	s, err := New(&cfg)
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+
+	_, err = s.pool.Exec(context.Background(), `
+        TRUNCATE order_uids, orders_archive, deliveries_archive, payments_archive,
+                 items_archive, status_history_archive, pii_audit, shard_directory CASCADE
+    `)
+	require.NoError(t, err)
+
+	return s
+}
+
+func TestStorage_Contract(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.OrderRepository {
+		return newTestStorage(t)
+	})
+}
+
+func TestStorage_StatusContract(t *testing.T) {
+	storagetest.RunStatus(t, func(t *testing.T) storage.StatusRepository {
+		return newTestStorage(t)
+	})
+}
+
+func TestStorage_Retention(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	old := storagetest.NewOrder("retention-old", 0)
+	fresh := storagetest.NewOrder("retention-fresh", 60)
+	require.NoError(t, s.SaveOrder(ctx, old))
+	require.NoError(t, s.SaveOrder(ctx, fresh))
+
+	cutoff := fresh.DateCreated.Add(-time.Minute)
+
+	uids, err := s.SoftDeleteExpired(ctx, cutoff, 10)
+	require.NoError(t, err)
+	require.Equal(t, []string{old.OrderUID}, uids)
+
+	// Soft-deleted orders are hidden from reads.
+	_, err = s.GetOrderByUID(ctx, old.OrderUID)
+	require.ErrorIs(t, err, storage.ErrOrderNotFound)
+	page, err := s.ListOrders(ctx, storage.OrderFilter{})
+	require.NoError(t, err)
+	require.Len(t, page.Orders, 1)
+
+	n, err := s.ArchiveDeleted(ctx, 10)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	var archivedItems int
+	err = s.pool.QueryRow(ctx, `SELECT count(*) FROM items_archive WHERE order_uid = $1`, old.OrderUID).Scan(&archivedItems)
+	require.NoError(t, err)
+	require.Equal(t, len(old.Items), archivedItems)
+
+	// An archived order is not saved again on redelivery.
+	require.ErrorIs(t, s.SaveOrder(ctx, old), storage.ErrOrderExists)
+
+	n, err = s.ArchiveDeleted(ctx, 10)
+	require.NoError(t, err)
+	require.Zero(t, n)
+}
+
+func TestStorage_ErasePII(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	a := storagetest.NewOrder("erase-a", 0)
+	b := storagetest.NewOrder("erase-b", 1)
+	other := storagetest.NewOrder("erase-other", 2)
+	other.CustomerID = "other"
+	other.Delivery.Email = "other@example.com"
+	for _, o := range []*models.Order{a, b, other} {
+		require.NoError(t, s.SaveOrder(ctx, o))
+	}
+
+	// One of the orders is archived already.
+	_, err := s.SoftDeleteExpired(ctx, b.DateCreated, 10)
+	require.NoError(t, err)
+	_, err = s.ArchiveDeleted(ctx, 10)
+	require.NoError(t, err)
+
+	// archivedMatches reports whether the archived delivery of a is still
+	// found by a's name.
+	archivedMatches := func() bool {
+		var found bool
+		err := s.pool.QueryRow(ctx, `
+            SELECT coalesce(search_vector @@ plainto_tsquery('simple', $2), false)
+            FROM deliveries_archive WHERE order_uid = $1
+        `, a.OrderUID, a.Delivery.Name).Scan(&found)
+		require.NoError(t, err)
+		return found
+	}
+	require.True(t, archivedMatches())
+
+	req := models.ErasureRequest{CustomerID: a.CustomerID, Mode: models.ErasureErase, Actor: "test"}
+	result, err := s.ErasePII(ctx, req)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{a.OrderUID, b.OrderUID}, result.OrderUIDs)
+
+	got, err := s.GetOrderByUID(ctx, b.OrderUID)
+	require.NoError(t, err)
+	require.Equal(t, models.Redacted, got.Delivery.Email)
+	require.Equal(t, models.Redacted, got.Delivery.Phone)
+	require.Equal(t, b.Delivery.City, got.Delivery.City)
+	require.Equal(t, b.Payment, got.Payment)
+
+	var archivedEmail string
+	err = s.pool.QueryRow(ctx, `SELECT email FROM deliveries_archive WHERE order_uid = $1`, a.OrderUID).Scan(&archivedEmail)
+	require.NoError(t, err)
+	require.Equal(t, models.Redacted, archivedEmail)
+	require.False(t, archivedMatches(), "erased name is still searchable in the archive")
+
+	got, err = s.GetOrderByUID(ctx, other.OrderUID)
+	require.NoError(t, err)
+	require.Equal(t, other.Delivery, got.Delivery)
+
+	_, hash := req.Subject()
+	var affected int
+	err = s.pool.QueryRow(ctx, `SELECT orders_affected FROM pii_audit WHERE subject_hash = $1`, hash).Scan(&affected)
+	require.NoError(t, err)
+	require.Equal(t, 2, affected)
+}
+
+func TestStorage_Encryption(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	key := func(b byte) string {
+		return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+	}
+	keyring := func(primary string) *fieldcrypt.Keyring {
+		k, err := fieldcrypt.New(primary, map[string]string{"k1": key(1), "k2": key(2)}, key(9))
+		require.NoError(t, err)
+		return k
+	}
+
+	// An order written before encryption was enabled.
+	plain := storagetest.NewOrder("crypt-plain", 0)
+	require.NoError(t, s.SaveOrder(ctx, plain))
+
+	s.keys = keyring("k1")
+	sealed := storagetest.NewOrder("crypt-sealed", 1)
+	sealed.CustomerID = "crypt"
+	sealed.Delivery.Email = "Crypt@Example.com"
+	require.NoError(t, s.SaveOrder(ctx, sealed))
+
+	var phone, email, address string
+	err := s.pool.QueryRow(ctx, `SELECT phone, email, address FROM deliveries WHERE order_uid = $1`,
+		sealed.OrderUID).Scan(&phone, &email, &address)
+	require.NoError(t, err)
+	require.NotEqual(t, sealed.Delivery.Phone, phone)
+	require.NotEqual(t, sealed.Delivery.Email, email)
+	require.NotEqual(t, sealed.Delivery.Address, address)
+
+	// Both plain text and encrypted rows are read transparently.
+	for _, want := range []*models.Order{plain, sealed} {
+		got, err := s.GetOrderByUID(ctx, want.OrderUID)
+		require.NoError(t, err)
+		require.Equal(t, want.Delivery, got.Delivery)
+	}
+
+	// Rotate to k2 and migrate all rows.
+	s.keys = keyring("k2")
+	n, err := s.ReencryptDeliveries(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	n, err = s.ReencryptDeliveries(ctx, 10)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	n, err = s.ReencryptDeliveries(ctx, 10)
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	rows, err := s.pool.Query(ctx, `SELECT enc_key_id FROM deliveries`)
+	require.NoError(t, err)
+	keyIDs, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	require.NoError(t, err)
+	require.Equal(t, []string{"k2", "k2"}, keyIDs)
+
+	// k1 is no longer needed.
+	k2only, err := fieldcrypt.New("k2", map[string]string{"k2": key(2)}, key(9))
+	require.NoError(t, err)
+	s.keys = k2only
+	for _, want := range []*models.Order{plain, sealed} {
+		got, err := s.GetOrderByUID(ctx, want.OrderUID)
+		require.NoError(t, err)
+		require.Equal(t, want.Delivery, got.Delivery)
+	}
+
+	// Encrypted emails are found for erasure by their blind index.
+	result, err := s.ErasePII(ctx, models.ErasureRequest{
+		Email: "crypt@example.com", Mode: models.ErasureErase, Actor: "test",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{sealed.OrderUID}, result.OrderUIDs)
+
+	// Without a keyring encrypted rows can't be read.
+	s.keys = nil
+	_, err = s.GetOrderByUID(ctx, plain.OrderUID)
+	require.ErrorIs(t, err, ErrNoKeyring)
+}
+
+func TestStorage_ReencryptArchived(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	// An order archived before encryption was enabled.
+	order := storagetest.NewOrder("crypt-archived", 0)
+	require.NoError(t, s.SaveOrder(ctx, order))
+	_, err := s.SoftDeleteExpired(ctx, order.DateCreated.Add(time.Minute), 10)
+	require.NoError(t, err)
+	_, err = s.ArchiveDeleted(ctx, 10)
+	require.NoError(t, err)
+
+	// archivedMatches reports whether the archived delivery is found by text.
+	archivedMatches := func(text string) bool {
+		var found bool
+		err := s.pool.QueryRow(ctx, `
+            SELECT coalesce(search_vector @@ plainto_tsquery('simple', $2), false)
+            FROM deliveries_archive WHERE order_uid = $1
+        `, order.OrderUID, text).Scan(&found)
+		require.NoError(t, err)
+		return found
+	}
+	require.True(t, archivedMatches(order.Delivery.Address))
+
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	keys, err := fieldcrypt.New("k1", map[string]string{"k1": key}, key)
+	require.NoError(t, err)
+	s.keys = keys
+
+	n, err := s.ReencryptDeliveries(ctx, 10)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	var address string
+	err = s.pool.QueryRow(ctx, `SELECT address FROM deliveries_archive WHERE order_uid = $1`,
+		order.OrderUID).Scan(&address)
+	require.NoError(t, err)
+	require.NotEqual(t, order.Delivery.Address, address)
+	require.False(t, archivedMatches(order.Delivery.Address), "encrypted address is still searchable in the archive")
+	require.True(t, archivedMatches(order.Delivery.Name))
+}
+
+func TestStorage_DecryptDeliveries(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	keys, err := fieldcrypt.New("k1", map[string]string{"k1": key}, key)
+	require.NoError(t, err)
+	s.keys = keys
+
+	archived := storagetest.NewOrder("decrypt-archived", 0)
+	live := storagetest.NewOrder("decrypt-live", 1)
+	live.DateCreated = time.Now().UTC().Truncate(time.Microsecond)
+	require.NoError(t, s.SaveOrder(ctx, archived))
+	require.NoError(t, s.SaveOrder(ctx, live))
+	_, err = s.SoftDeleteExpired(ctx, archived.DateCreated.Add(time.Minute), 10)
+	require.NoError(t, err)
+	_, err = s.ArchiveDeleted(ctx, 10)
+	require.NoError(t, err)
+
+	n, err := s.DecryptDeliveries(ctx, 10)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	n, err = s.DecryptDeliveries(ctx, 10)
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	for table, order := range map[string]*models.Order{"deliveries": live, "deliveries_archive": archived} {
+		var (
+			phone, email, address string
+			encrypted             bool
+			found                 bool
+		)
+		err := s.pool.QueryRow(ctx, `
+            SELECT phone, email, address,
+                   enc_key_id IS NOT NULL OR enc_dek IS NOT NULL OR email_hash IS NOT NULL,
+                   coalesce(search_vector @@ plainto_tsquery('simple', address), false)
+            FROM `+table+` WHERE order_uid = $1
+        `, order.OrderUID).Scan(&phone, &email, &address, &encrypted, &found)
+		require.NoError(t, err, table)
+		require.Equal(t, order.Delivery.Phone, phone, table)
+		require.Equal(t, order.Delivery.Email, email, table)
+		require.Equal(t, order.Delivery.Address, address, table)
+		require.False(t, encrypted, table)
+		require.True(t, found, "%s: decrypted address is not searchable", table)
+	}
+
+	// Plain rows are read without a keyring again.
+	s.keys = nil
+	got, err := s.GetOrderByUID(ctx, live.OrderUID)
+	require.NoError(t, err)
+	require.Equal(t, live.Delivery, got.Delivery)
+}
+
+func TestStorage_Partitions(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	t.Cleanup(func() {
+		_, _ = s.pool.Exec(context.Background(), `DROP TABLE IF EXISTS orders_2099_01, items_2099_01`)
+	})
+
+	// No partition exists for the month yet, so the order goes to the
+	// default partitions.
+	order := storagetest.NewOrder("partition-future", 0)
+	order.DateCreated = time.Date(2099, 1, 15, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, s.SaveOrder(ctx, order))
+
+	partitionOf := func(table string) string {
+		var name string
+		err := s.pool.QueryRow(ctx, `SELECT tableoid::regclass::text FROM `+table+` WHERE order_uid = $1 LIMIT 1`,
+			order.OrderUID).Scan(&name)
+		require.NoError(t, err)
+		return name
+	}
+	require.Equal(t, "orders_default", partitionOf("orders"))
+	require.Equal(t, "items_default", partitionOf("items"))
+
+	created, err := s.CreatePartitions(ctx, order.DateCreated, 1)
+	require.NoError(t, err)
+	require.Equal(t, 2, created)
+
+	// The rows were moved to the new partitions.
+	require.Equal(t, "orders_2099_01", partitionOf("orders"))
+	require.Equal(t, "items_2099_01", partitionOf("items"))
+
+	got, err := s.GetOrderByUID(ctx, order.OrderUID)
+	require.NoError(t, err)
+	require.Equal(t, order.Items, got.Items)
+
+	created, err = s.CreatePartitions(ctx, order.DateCreated, 1)
+	require.NoError(t, err)
+	require.Zero(t, created)
+
+	// order_uid stays unique across partitions.
+	dup := storagetest.NewOrder(order.OrderUID, 0)
+	require.ErrorIs(t, s.SaveOrder(ctx, dup), storage.ErrOrderExists)
+}
+
+func TestStorage_ShardDirectory(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	_, err := s.LookupOrderShard(ctx, "dir-order")
+	require.ErrorIs(t, err, storage.ErrOrderNotFound)
+
+	require.NoError(t, s.ClaimOrderShard(ctx, "dir-order", "a"))
+	require.NoError(t, s.ClaimOrderShard(ctx, "dir-order", "a"))
+	require.ErrorIs(t, s.ClaimOrderShard(ctx, "dir-order", "b"), storage.ErrOrderExists)
+
+	shard, err := s.LookupOrderShard(ctx, "dir-order")
+	require.NoError(t, err)
+	require.Equal(t, "a", shard)
+
+	require.NoError(t, s.ReleaseOrderShard(ctx, "dir-order"))
+	require.NoError(t, s.ClaimOrderShard(ctx, "dir-order", "b"))
+}
@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"wb-examples-l0/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErasePII redacts the delivery data of every live and archived order of
+// the subject and records an audit entry, in one transaction. Payments and
+// items are left intact. Redacted deliveries are stored in plain text.
+func (s *Storage) ErasePII(ctx context.Context, req models.ErasureRequest) (*models.ErasureResult, error) {
+	const op = "storage.postgres.ErasePII"
+
+	var redacted models.Delivery
+	req.RedactDelivery(&redacted)
+
+	kind, hash := req.Subject()
+
+	var match, archiveMatch, subject string
+	if req.CustomerID != "" {
+		subject = req.CustomerID
+		match = `order_uid IN (SELECT order_uid FROM orders WHERE customer_id = $1)`
+		archiveMatch = `order_uid IN (SELECT order_uid FROM orders_archive WHERE customer_id = $1)`
+	} else {
+		// Encrypted emails are matched by their blind index, plain text
+		// ones directly.
+		subject = req.Email
+		match = `(email_hash = $7 OR (enc_key_id IS NULL AND lower(email) = lower($1)))`
+		archiveMatch = match
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	result := &models.ErasureResult{
+		OrderUIDs: make([]string, 0),
+		ErasedAt:  time.Now().UTC(),
+	}
+
+	for _, q := range []string{
+		`UPDATE deliveries SET name = $2, phone = $3, zip = $4, address = $5, email = $6,
+                               enc_key_id = NULL, enc_dek = NULL, email_hash = NULL
+         WHERE ` + match + ` RETURNING order_uid`,
+		// search_vector is a plain copy in the archive, not generated, and
+		// would keep the erased name and address; archived orders are not
+		// searched, so it is cleared.
+		`UPDATE deliveries_archive SET name = $2, phone = $3, zip = $4, address = $5, email = $6,
+                                       enc_key_id = NULL, enc_dek = NULL, email_hash = NULL,
+                                       search_vector = NULL
+         WHERE ` + archiveMatch + ` RETURNING order_uid`,
+	} {
+		args := []any{subject,
+			redacted.Name, redacted.Phone, redacted.Zip, redacted.Address, redacted.Email}
+		if req.CustomerID == "" {
+			args = append(args, s.emailBlindIndex(req.Email))
+		}
+
+		rows, err := tx.Query(ctx, q, args...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: redact deliveries: %w", op, err)
+		}
+
+		uids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			return nil, fmt.Errorf("%s: redact deliveries: %w", op, err)
+		}
+		result.OrderUIDs = append(result.OrderUIDs, uids...)
+	}
+
+	_, err = tx.Exec(ctx, `
+        INSERT INTO pii_audit (subject_kind, subject_hash, mode, actor, reason, orders_affected, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+    `, kind, hash, req.Mode, req.Actor, req.Reason, len(result.OrderUIDs), result.ErasedAt)
+	if err != nil {
+		return nil, fmt.Errorf("%s: insert audit entry: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: commit transaction: %w", op, err)
+	}
+	s.reads.wrote(result.OrderUIDs...)
+
+	return result, nil
+}
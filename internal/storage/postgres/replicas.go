@@ -0,0 +1,261 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	defaultMaxLag        = 5 * time.Second
+	defaultCheckInterval = 2 * time.Second
+)
+
+// replica is a read replica with the state of its last health check.
+type replica struct {
+	// name is host:port, used as metrics label.
+	name    string
+	pool    *pgxpool.Pool
+	healthy atomic.Bool
+	lag     atomic.Int64 // nanoseconds
+}
+
+// replicaRouter picks the pool for reads. Reads go round-robin to healthy
+// replicas, i.e. those that answered the last check streaming from the
+// primary with a lag of at most maxLag, and to the primary when there are
+// none. Writes always use the
+// primary pool directly.
+type replicaRouter struct {
+	primary  *pgxpool.Pool
+	replicas []*replica
+	next     atomic.Uint64
+	maxLag   time.Duration
+	interval time.Duration
+
+	// fallbacks counts reads sent to the primary because no replica was
+	// healthy.
+	fallbacks atomic.Uint64
+
+	// written holds the write time of orders saved by this instance, if
+	// read-your-writes is on. Until they are pinFor old, they are read
+	// from the primary.
+	readYourWrites bool
+	mu             sync.Mutex
+	written        map[string]time.Time
+
+	stop chan struct{}
+	done chan struct{}
+	now  func() time.Time
+}
+
+func newReplicaRouter(primary *pgxpool.Pool, replicas []*replica, maxLag, interval time.Duration, readYourWrites bool) *replicaRouter {
+	if maxLag <= 0 {
+		maxLag = defaultMaxLag
+	}
+	if interval <= 0 {
+		interval = defaultCheckInterval
+	}
+
+	return &replicaRouter{
+		primary:        primary,
+		replicas:       replicas,
+		maxLag:         maxLag,
+		interval:       interval,
+		readYourWrites: readYourWrites && len(replicas) > 0,
+		written:        make(map[string]time.Time),
+		stop:           make(chan struct{}),
+		done:           make(chan struct{}),
+		now:            time.Now,
+	}
+}
+
+// newReplica creates a pool for dsn with the pool settings of the primary.
+func newReplica(dsn string, primary *pgxpool.Config) (*replica, error) {
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.MaxConns = primary.MaxConns
+	cfg.MinConns = primary.MinConns
+	cfg.MaxConnIdleTime = primary.MaxConnIdleTime
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	name := net.JoinHostPort(cfg.ConnConfig.Host, strconv.Itoa(int(cfg.ConnConfig.Port)))
+	return &replica{name: name, pool: pool}, nil
+}
+
+// reader returns the pool for a read.
+func (r *replicaRouter) reader() *pgxpool.Pool {
+	n := len(r.replicas)
+	if n == 0 {
+		return r.primary
+	}
+
+	start := r.next.Add(1)
+	for i := range n {
+		rep := r.replicas[(start+uint64(i))%uint64(n)]
+		if rep.healthy.Load() {
+			return rep.pool
+		}
+	}
+
+	r.fallbacks.Add(1)
+	return r.primary
+}
+
+// readerFor returns the pool for a read of the given orders: the primary
+// if any of them was written recently, see wrote.
+func (r *replicaRouter) readerFor(uids ...string) *pgxpool.Pool {
+	if r.readYourWrites && r.recentlyWritten(uids) {
+		return r.primary
+	}
+	return r.reader()
+}
+
+// wrote records writes of the given orders for read-your-writes.
+func (r *replicaRouter) wrote(uids ...string) {
+	if !r.readYourWrites {
+		return
+	}
+
+	now := r.now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, uid := range uids {
+		r.written[uid] = now
+	}
+}
+
+// pinFor is how long a write is read from the primary. A replica was at
+// most maxLag behind at its last check, which may be an interval ago, so
+// only writes older than both have reached every healthy replica.
+func (r *replicaRouter) pinFor() time.Duration {
+	return r.maxLag + r.interval
+}
+
+func (r *replicaRouter) recentlyWritten(uids []string) bool {
+	cutoff := r.now().Add(-r.pinFor())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, uid := range uids {
+		if at, ok := r.written[uid]; ok && at.After(cutoff) {
+			return true
+		}
+	}
+	return false
+}
+
+// forgetWrites drops writes that every healthy replica has by now.
+func (r *replicaRouter) forgetWrites() {
+	cutoff := r.now().Add(-r.pinFor())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for uid, at := range r.written {
+		if !at.After(cutoff) {
+			delete(r.written, uid)
+		}
+	}
+}
+
+// start checks the replicas once and then every interval until close.
+func (r *replicaRouter) start() {
+	if len(r.replicas) == 0 {
+		close(r.done)
+		return
+	}
+
+	r.checkAll()
+
+	go func() {
+		defer close(r.done)
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+				r.checkAll()
+				r.forgetWrites()
+			}
+		}
+	}()
+}
+
+func (r *replicaRouter) close() {
+	close(r.stop)
+	<-r.done
+
+	for _, rep := range r.replicas {
+		rep.pool.Close()
+	}
+}
+
+func (r *replicaRouter) checkAll() {
+	var wg sync.WaitGroup
+	for _, rep := range r.replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), r.interval)
+			defer cancel()
+
+			lag, err := replicationLag(ctx, rep.pool)
+			rep.lag.Store(int64(lag))
+			rep.healthy.Store(err == nil && lag <= r.maxLag)
+		}()
+	}
+	wg.Wait()
+}
+
+// errNotStreaming is returned for a replica that is not receiving WAL from
+// the primary. Such a replica may have replayed all it received and still
+// be arbitrarily far behind.
+var errNotStreaming = errors.New("replica is not streaming from the primary")
+
+// replicationLag returns how far the replica is behind the primary. A
+// replica that streams from the primary and has replayed everything it
+// received has no lag, even if the primary has been idle for a while.
+func replicationLag(ctx context.Context, pool *pgxpool.Pool) (time.Duration, error) {
+	var (
+		streaming bool
+		seconds   float64
+	)
+	err := pool.QueryRow(ctx, `
+        SELECT NOT pg_is_in_recovery()
+                   OR coalesce((SELECT status = 'streaming' FROM pg_stat_wal_receiver), false),
+               CASE
+                   WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+                   ELSE coalesce(extract(epoch FROM now() - pg_last_xact_replay_timestamp()), 0)
+               END::float8
+    `).Scan(&streaming, &seconds)
+	if err != nil {
+		return 0, fmt.Errorf("check replication lag: %w", err)
+	}
+	if !streaming {
+		return 0, errNotStreaming
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRouter returns a router over fake pools that are only compared,
+// never used.
+func newTestRouter(n int, readYourWrites bool) (*replicaRouter, []*replica) {
+	replicas := make([]*replica, n)
+	for i := range replicas {
+		replicas[i] = &replica{pool: new(pgxpool.Pool)}
+		replicas[i].healthy.Store(true)
+	}
+
+	return newReplicaRouter(new(pgxpool.Pool), replicas, time.Second, time.Second, readYourWrites), replicas
+}
+
+func TestReplicaRouter_RoundRobin(t *testing.T) {
+	r, replicas := newTestRouter(3, false)
+
+	seen := make(map[*pgxpool.Pool]int)
+	for range 6 {
+		seen[r.reader()]++
+	}
+
+	require.Len(t, seen, 3)
+	for _, rep := range replicas {
+		require.Equal(t, 2, seen[rep.pool])
+	}
+}
+
+func TestReplicaRouter_Failover(t *testing.T) {
+	r, replicas := newTestRouter(2, false)
+
+	replicas[0].healthy.Store(false)
+	for range 4 {
+		require.Same(t, replicas[1].pool, r.reader())
+	}
+
+	replicas[1].healthy.Store(false)
+	require.Same(t, r.primary, r.reader())
+	require.EqualValues(t, 1, r.fallbacks.Load())
+}
+
+func TestReplicaRouter_NoReplicas(t *testing.T) {
+	r, _ := newTestRouter(0, true)
+
+	require.Same(t, r.primary, r.reader())
+	require.Zero(t, r.fallbacks.Load())
+
+	// Read-your-writes is moot without replicas.
+	r.wrote("order-1")
+	require.Empty(t, r.written)
+}
+
+func TestReplicaRouter_ReadYourWrites(t *testing.T) {
+	r, replicas := newTestRouter(1, true)
+
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+
+	r.wrote("order-1")
+	require.Same(t, r.primary, r.readerFor("order-1"))
+	require.Same(t, r.primary, r.readerFor("order-2", "order-1"))
+	require.Same(t, replicas[0].pool, r.readerFor("order-2"))
+
+	// A replica within maxLag at its last check may have fallen behind
+	// since, so the order stays on the primary for another interval.
+	now = now.Add(r.maxLag)
+	require.Same(t, r.primary, r.readerFor("order-1"))
+	r.forgetWrites()
+	require.Len(t, r.written, 1)
+
+	now = now.Add(r.interval)
+	require.Same(t, replicas[0].pool, r.readerFor("order-1"))
+
+	r.forgetWrites()
+	require.Empty(t, r.written)
+}
+
+func TestReplicaRouter_ReadYourWritesDisabled(t *testing.T) {
+	r, replicas := newTestRouter(1, false)
+
+	r.wrote("order-1")
+	require.Same(t, replicas[0].pool, r.readerFor("order-1"))
+}
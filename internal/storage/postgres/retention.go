@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// SoftDeleteExpired marks up to limit live orders created before cutoff
+// as deleted and returns their UIDs. Rows locked by concurrent writers
+// are skipped and picked up by a later call. Only partitions older than
+// cutoff are scanned.
+func (s *Storage) SoftDeleteExpired(ctx context.Context, cutoff time.Time, limit int) ([]string, error) {
+	const op = "storage.postgres.SoftDeleteExpired"
+
+	rows, err := s.pool.Query(ctx, `
+        UPDATE orders SET deleted_at = now()
+        WHERE date_created < $1 AND order_uid IN (
+            SELECT order_uid FROM orders
+            WHERE deleted_at IS NULL AND date_created < $1
+            ORDER BY date_created
+            LIMIT $2
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING order_uid
+    `, cutoff, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	uids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return uids, nil
+}
+
+// ArchiveDeleted moves up to limit soft-deleted orders with their
+// deliveries, payments, items and status history to the archive tables
+// and returns how many orders were moved. Every call is one transaction,
+// so an interrupted run loses nothing and the next call carries on.
+func (s *Storage) ArchiveDeleted(ctx context.Context, limit int) (int, error) {
+	const op = "storage.postgres.ArchiveDeleted"
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+        SELECT order_uid FROM orders
+        WHERE deleted_at IS NOT NULL
+        ORDER BY deleted_at
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED
+    `, limit)
+	if err != nil {
+		return 0, fmt.Errorf("%s: select orders: %w", op, err)
+	}
+
+	uids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return 0, fmt.Errorf("%s: select orders: %w", op, err)
+	}
+	if len(uids) == 0 {
+		return 0, nil
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(`INSERT INTO orders_archive SELECT * FROM orders WHERE order_uid = ANY($1)`, uids)
+	batch.Queue(`INSERT INTO deliveries_archive SELECT * FROM deliveries WHERE order_uid = ANY($1)`, uids)
+	batch.Queue(`INSERT INTO payments_archive SELECT * FROM payments WHERE order_uid = ANY($1)`, uids)
+	batch.Queue(`INSERT INTO items_archive SELECT * FROM items WHERE order_uid = ANY($1)`, uids)
+	batch.Queue(`INSERT INTO status_history_archive SELECT * FROM status_history WHERE order_uid = ANY($1)`, uids)
+	batch.Queue(`DELETE FROM order_uids WHERE order_uid = ANY($1)`, uids)
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return 0, fmt.Errorf("%s: move orders: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%s: commit transaction: %w", op, err)
+	}
+
+	return len(uids), nil
+}
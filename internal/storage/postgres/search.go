@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+	"wb-examples-l0/internal/models"
+)
+
+// SearchOrders runs a full-text search over item names and brands and
+// delivery names, cities and addresses. Every word of the query must match,
+// as a prefix, in the same item or delivery. Results are ranked by the sum
+// of ts_rank over all matched rows of an order. Encrypted addresses are
+// neither searched nor highlighted.
+func (s *Storage) SearchOrders(ctx context.Context, query string, limit int) ([]models.SearchResult, error) {
+	const op = "storage.postgres.SearchOrders"
+
+	results := make([]models.SearchResult, 0)
+
+	tsQuery := prefixTSQuery(query)
+	if tsQuery == "" {
+		return results, nil
+	}
+
+	rows, err := s.reads.reader().Query(ctx, `
+        WITH q AS (SELECT to_tsquery('simple', $1) AS query),
+        matches AS (
+            SELECT i.order_uid,
+                   ts_rank(i.search_vector, q.query) AS rank,
+                   ts_headline('simple', i.name || ' ' || coalesce(i.brand, ''), q.query) AS highlight
+            FROM items i, q
+            WHERE i.search_vector @@ q.query
+            UNION ALL
+            SELECT d.order_uid,
+                   ts_rank(d.search_vector, q.query),
+                   ts_headline('simple', concat_ws(', ', d.name, d.city,
+                       CASE WHEN d.enc_key_id IS NULL THEN d.address END), q.query)
+            FROM deliveries d, q
+            WHERE d.search_vector @@ q.query
+        )
+        SELECT o.order_uid, o.track_number, o.customer_id, o.date_created,
+               sum(m.rank) AS rank, array_agg(DISTINCT m.highlight)
+        FROM matches m
+        JOIN orders o ON o.order_uid = m.order_uid
+        WHERE o.deleted_at IS NULL
+        GROUP BY o.order_uid, o.track_number, o.customer_id, o.date_created
+        ORDER BY rank DESC, o.date_created DESC
+        LIMIT $2
+    `, tsQuery, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var res models.SearchResult
+		err := rows.Scan(
+			&res.OrderUID, &res.TrackNumber, &res.CustomerID, &res.DateCreated,
+			&res.Rank, &res.Highlights,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan result: %w", op, err)
+		}
+		results = append(results, res)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return results, nil
+}
+
+// prefixTSQuery turns free text into a to_tsquery expression where every
+// word is a prefix match, e.g. "Ploshad Mi" -> "ploshad:* & mi:*".
+// Anything but letters and digits is treated as a separator, so user input
+// can't inject tsquery operators.
+func prefixTSQuery(query string) string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+
+	return strings.Join(words, " & ")
+}
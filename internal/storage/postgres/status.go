@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/storage"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// UpdateOrderStatus moves an order, or its items with update.ChrtID, to
+// update.Status and records the transition in status_history. The order
+// row is locked for the duration of the check, so concurrent updates of
+// one order are serialized.
+func (s *Storage) UpdateOrderStatus(ctx context.Context, update models.StatusUpdate) error {
+	const op = "storage.postgres.UpdateOrderStatus"
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		current     models.OrderStatus
+		dateCreated time.Time
+	)
+	err = tx.QueryRow(ctx, `
+        SELECT o.status, o.date_created FROM order_uids u `+joinOrders+`
+        WHERE u.order_uid = $1 AND o.deleted_at IS NULL
+        FOR UPDATE OF o
+    `, update.OrderUID).Scan(&current, &dateCreated)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%s: %w", op, storage.ErrOrderNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: get status: %w", op, err)
+	}
+
+	batch := &pgx.Batch{}
+	if update.ChrtID != 0 {
+		rows, err := tx.Query(ctx, `
+            SELECT status FROM items
+            WHERE order_uid = $1 AND date_created = $2 AND chrt_id = $3
+        `, update.OrderUID, dateCreated, update.ChrtID)
+		if err != nil {
+			return fmt.Errorf("%s: get item status: %w", op, err)
+		}
+		codes, err := pgx.CollectRows(rows, pgx.RowTo[int])
+		if err != nil {
+			return fmt.Errorf("%s: scan item status: %w", op, err)
+		}
+
+		changed, err := storage.CheckItemTransition(codes, update.Status)
+		if err != nil {
+			return fmt.Errorf("%s: chrt_id %d: %w", op, update.ChrtID, err)
+		}
+		if !changed {
+			return nil
+		}
+
+		batch.Queue(`
+            UPDATE items SET status = $4
+            WHERE order_uid = $1 AND date_created = $2 AND chrt_id = $3
+        `, update.OrderUID, dateCreated, update.ChrtID, update.Status.ItemCode())
+	} else {
+		if current == update.Status {
+			return nil
+		}
+		if !current.CanTransitionTo(update.Status) {
+			return fmt.Errorf("%s: %s -> %s: %w", op, current, update.Status, storage.ErrIllegalTransition)
+		}
+
+		batch.Queue(`UPDATE orders SET status = $2 WHERE order_uid = $1 AND date_created = $3`,
+			update.OrderUID, update.Status, dateCreated)
+	}
+	batch.Queue(`
+        INSERT INTO status_history (order_uid, status, source, changed_at, chrt_id)
+        VALUES ($1, $2, $3, $4, nullif($5, 0))
+    `, update.OrderUID, update.Status, update.Source, update.ChangedAt, update.ChrtID)
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("%s: update status: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: commit transaction: %w", op, err)
+	}
+	s.reads.wrote(update.OrderUID)
+
+	return nil
+}
+
+// GetOrderTimeline returns the current status of an order and its
+// transitions, oldest first.
+func (s *Storage) GetOrderTimeline(ctx context.Context, orderUID string) (*models.OrderTimeline, error) {
+	const op = "storage.postgres.GetOrderTimeline"
+
+	timeline := &models.OrderTimeline{OrderUID: orderUID}
+
+	batch := &pgx.Batch{}
+	batch.Queue(`
+        SELECT o.status FROM order_uids u `+joinOrders+`
+        WHERE u.order_uid = $1 AND o.deleted_at IS NULL
+    `, orderUID)
+	batch.Queue(`
+        SELECT status, source, changed_at, coalesce(chrt_id, 0) FROM status_history
+        WHERE order_uid = $1
+        ORDER BY id
+    `, orderUID)
+
+	results := s.reads.readerFor(orderUID).SendBatch(ctx, batch)
+	defer results.Close()
+
+	err := results.QueryRow().Scan(&timeline.Status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrOrderNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: get status: %w", op, err)
+	}
+
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("%s: get history: %w", op, err)
+	}
+
+	timeline.History, err = pgx.CollectRows(rows, pgx.RowToStructByPos[models.StatusChange])
+	if err != nil {
+		return nil, fmt.Errorf("%s: scan history: %w", op, err)
+	}
+
+	return timeline, nil
+}
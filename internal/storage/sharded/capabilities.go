@@ -0,0 +1,190 @@
+package sharded
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/storage"
+)
+
+// UpdateOrderStatus updates the order on its shard.
+func (s *Storage) UpdateOrderStatus(ctx context.Context, update models.StatusUpdate) error {
+	shard, err := s.shardOf(ctx, update.OrderUID)
+	if err != nil {
+		return fmt.Errorf("storage.sharded.UpdateOrderStatus: %w", err)
+	}
+
+	repo, ok := shard.Repo.(storage.StatusRepository)
+	if !ok {
+		return fmt.Errorf("storage.sharded.UpdateOrderStatus: %w", ErrUnsupported)
+	}
+
+	return repo.UpdateOrderStatus(ctx, update)
+}
+
+// GetOrderTimeline reads the timeline from the order's shard.
+func (s *Storage) GetOrderTimeline(ctx context.Context, orderUID string) (*models.OrderTimeline, error) {
+	shard, err := s.shardOf(ctx, orderUID)
+	if err != nil {
+		return nil, fmt.Errorf("storage.sharded.GetOrderTimeline: %w", err)
+	}
+
+	repo, ok := shard.Repo.(storage.StatusRepository)
+	if !ok {
+		return nil, fmt.Errorf("storage.sharded.GetOrderTimeline: %w", ErrUnsupported)
+	}
+
+	return repo.GetOrderTimeline(ctx, orderUID)
+}
+
+type archiver interface {
+	SoftDeleteExpired(ctx context.Context, cutoff time.Time, limit int) ([]string, error)
+	ArchiveDeleted(ctx context.Context, limit int) (int, error)
+}
+
+// SoftDeleteExpired goes through the shards one by one until limit orders
+// are soft-deleted. Archived orders keep their directory entries, so their
+// UIDs stay taken, as on a single backend.
+func (s *Storage) SoftDeleteExpired(ctx context.Context, cutoff time.Time, limit int) ([]string, error) {
+	uids := make([]string, 0)
+	for _, shard := range s.shards {
+		a, ok := shard.Repo.(archiver)
+		if !ok {
+			return nil, fmt.Errorf("storage.sharded.SoftDeleteExpired: shard %s: %w", shard.Name, ErrUnsupported)
+		}
+
+		deleted, err := a.SoftDeleteExpired(ctx, cutoff, limit-len(uids))
+		if err != nil {
+			return uids, fmt.Errorf("storage.sharded.SoftDeleteExpired: shard %s: %w", shard.Name, err)
+		}
+		uids = append(uids, deleted...)
+		if len(uids) >= limit {
+			break
+		}
+	}
+
+	return uids, nil
+}
+
+// ArchiveDeleted goes through the shards one by one until limit orders
+// are archived.
+func (s *Storage) ArchiveDeleted(ctx context.Context, limit int) (int, error) {
+	var total int
+	for _, shard := range s.shards {
+		a, ok := shard.Repo.(archiver)
+		if !ok {
+			return total, fmt.Errorf("storage.sharded.ArchiveDeleted: shard %s: %w", shard.Name, ErrUnsupported)
+		}
+
+		n, err := a.ArchiveDeleted(ctx, limit-total)
+		if err != nil {
+			return total, fmt.Errorf("storage.sharded.ArchiveDeleted: shard %s: %w", shard.Name, err)
+		}
+		total += n
+		if total >= limit {
+			break
+		}
+	}
+
+	return total, nil
+}
+
+// CreatePartitions creates the partitions on every shard.
+func (s *Storage) CreatePartitions(ctx context.Context, since time.Time, months int) (int, error) {
+	type creator interface {
+		CreatePartitions(ctx context.Context, since time.Time, months int) (int, error)
+	}
+
+	created, err := fanOut(ctx, s.shards, func(ctx context.Context, shard *Shard) (int, error) {
+		c, ok := shard.Repo.(creator)
+		if !ok {
+			return 0, ErrUnsupported
+		}
+		return c.CreatePartitions(ctx, since, months)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("storage.sharded.CreatePartitions: %w", err)
+	}
+
+	return sum(created), nil
+}
+
+// ErasePII erases the subject's data on every shard. Every shard records
+// its own audit entry.
+func (s *Storage) ErasePII(ctx context.Context, req models.ErasureRequest) (*models.ErasureResult, error) {
+	type eraser interface {
+		ErasePII(ctx context.Context, req models.ErasureRequest) (*models.ErasureResult, error)
+	}
+
+	results, err := fanOut(ctx, s.shards, func(ctx context.Context, shard *Shard) (*models.ErasureResult, error) {
+		e, ok := shard.Repo.(eraser)
+		if !ok {
+			return nil, ErrUnsupported
+		}
+		return e.ErasePII(ctx, req)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage.sharded.ErasePII: %w", err)
+	}
+
+	merged := &models.ErasureResult{OrderUIDs: make([]string, 0)}
+	for _, res := range results {
+		merged.OrderUIDs = append(merged.OrderUIDs, res.OrderUIDs...)
+		if res.ErasedAt.After(merged.ErasedAt) {
+			merged.ErasedAt = res.ErasedAt
+		}
+	}
+
+	return merged, nil
+}
+
+// ReencryptDeliveries re-encrypts up to limit deliveries per table on
+// every shard and returns how many were changed in total.
+func (s *Storage) ReencryptDeliveries(ctx context.Context, limit int) (int, error) {
+	type reencrypter interface {
+		ReencryptDeliveries(ctx context.Context, limit int) (int, error)
+	}
+
+	changed, err := fanOut(ctx, s.shards, func(ctx context.Context, shard *Shard) (int, error) {
+		r, ok := shard.Repo.(reencrypter)
+		if !ok {
+			return 0, ErrUnsupported
+		}
+		return r.ReencryptDeliveries(ctx, limit)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("storage.sharded.ReencryptDeliveries: %w", err)
+	}
+
+	return sum(changed), nil
+}
+
+// DecryptDeliveries decrypts up to limit deliveries per table on every
+// shard and returns how many were changed in total.
+func (s *Storage) DecryptDeliveries(ctx context.Context, limit int) (int, error) {
+	type decrypter interface {
+		DecryptDeliveries(ctx context.Context, limit int) (int, error)
+	}
+
+	changed, err := fanOut(ctx, s.shards, func(ctx context.Context, shard *Shard) (int, error) {
+		d, ok := shard.Repo.(decrypter)
+		if !ok {
+			return 0, ErrUnsupported
+		}
+		return d.DecryptDeliveries(ctx, limit)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("storage.sharded.DecryptDeliveries: %w", err)
+	}
+
+	return sum(changed), nil
+}
+
+func sum(values []int) int {
+	var total int
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
@@ -0,0 +1,275 @@
+package sharded
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/storage"
+)
+
+// SaveOrder claims the order UID for the shard of the order's shardkey
+// and saves the order there. A claim left by an interrupted save doesn't
+// block a retry, since the same order always maps to the same shard.
+func (s *Storage) SaveOrder(ctx context.Context, order *models.Order) error {
+	shard := s.shardFor(order.Shardkey)
+
+	if err := s.dir.ClaimOrderShard(ctx, order.OrderUID, shard.Name); err != nil {
+		return fmt.Errorf("claim order: %w", err)
+	}
+
+	return shard.Repo.SaveOrder(ctx, order)
+}
+
+func (s *Storage) GetOrderByUID(ctx context.Context, orderUID string) (*models.Order, error) {
+	shard, err := s.shardOf(ctx, orderUID)
+	if err != nil {
+		return nil, fmt.Errorf("get order: %w", err)
+	}
+
+	return shard.Repo.GetOrderByUID(ctx, orderUID)
+}
+
+func (s *Storage) OrderExists(ctx context.Context, orderUID string) (bool, error) {
+	shard, err := s.shardOf(ctx, orderUID)
+	if errors.Is(err, storage.ErrOrderNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return shard.Repo.OrderExists(ctx, orderUID)
+}
+
+// DeleteOrder deletes the order from its shard and then frees its UID.
+func (s *Storage) DeleteOrder(ctx context.Context, orderUID string) error {
+	shard, err := s.shardOf(ctx, orderUID)
+	if err != nil {
+		return fmt.Errorf("delete order: %w", err)
+	}
+
+	if err := shard.Repo.DeleteOrder(ctx, orderUID); err != nil {
+		return err
+	}
+
+	if err := s.dir.ReleaseOrderShard(ctx, orderUID); err != nil {
+		return fmt.Errorf("delete order: release: %w", err)
+	}
+
+	return nil
+}
+
+// ListOrders requests the page from every shard and merges the results.
+// The keyset cursor is global, so every shard continues from it on its
+// own and the first limit merged orders are the page.
+func (s *Storage) ListOrders(ctx context.Context, filter storage.OrderFilter) (*storage.OrderPage, error) {
+	const op = "storage.sharded.ListOrders"
+
+	limit := filter.PageLimit()
+	filter.Limit = limit
+
+	pages, err := fanOut(ctx, s.shards, func(ctx context.Context, shard *Shard) (*storage.OrderPage, error) {
+		return shard.Repo.ListOrders(ctx, filter)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	orders := make([]*models.Order, 0)
+	var hasMore bool
+	for _, page := range pages {
+		orders = append(orders, page.Orders...)
+		hasMore = hasMore || page.NextCursor != ""
+	}
+
+	orders = mergeNewestFirst(orders)
+	if len(orders) > limit {
+		orders = orders[:limit]
+		hasMore = true
+	}
+
+	page := &storage.OrderPage{Orders: orders}
+	if hasMore && len(orders) > 0 {
+		last := orders[len(orders)-1]
+		page.NextCursor = storage.EncodeCursor(last.DateCreated, last.OrderUID)
+	}
+
+	return page, nil
+}
+
+// lookuper is implemented by shards that support order lookups.
+type lookuper interface {
+	GetOrdersByTrackNumber(ctx context.Context, trackNumber string) ([]*models.Order, error)
+	GetOrdersByPayment(ctx context.Context, requestID, transaction string) ([]*models.Order, error)
+	GetOrdersByItem(ctx context.Context, rid string, chrtID int) ([]*models.Order, error)
+}
+
+func (s *Storage) GetOrdersByTrackNumber(ctx context.Context, trackNumber string) ([]*models.Order, error) {
+	return s.lookup(ctx, "storage.sharded.GetOrdersByTrackNumber", func(ctx context.Context, l lookuper) ([]*models.Order, error) {
+		return l.GetOrdersByTrackNumber(ctx, trackNumber)
+	})
+}
+
+func (s *Storage) GetOrdersByPayment(ctx context.Context, requestID, transaction string) ([]*models.Order, error) {
+	return s.lookup(ctx, "storage.sharded.GetOrdersByPayment", func(ctx context.Context, l lookuper) ([]*models.Order, error) {
+		return l.GetOrdersByPayment(ctx, requestID, transaction)
+	})
+}
+
+func (s *Storage) GetOrdersByItem(ctx context.Context, rid string, chrtID int) ([]*models.Order, error) {
+	return s.lookup(ctx, "storage.sharded.GetOrdersByItem", func(ctx context.Context, l lookuper) ([]*models.Order, error) {
+		return l.GetOrdersByItem(ctx, rid, chrtID)
+	})
+}
+
+// lookup runs fn on every shard and merges the results newest first, up
+// to storage.MaxPageLimit orders like a single backend returns.
+func (s *Storage) lookup(ctx context.Context, op string, fn func(context.Context, lookuper) ([]*models.Order, error)) ([]*models.Order, error) {
+	results, err := fanOut(ctx, s.shards, func(ctx context.Context, shard *Shard) ([]*models.Order, error) {
+		l, ok := shard.Repo.(lookuper)
+		if !ok {
+			return nil, ErrUnsupported
+		}
+		return fn(ctx, l)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	orders := make([]*models.Order, 0)
+	for _, res := range results {
+		orders = append(orders, res...)
+	}
+
+	orders = mergeNewestFirst(orders)
+	if len(orders) > storage.MaxPageLimit {
+		orders = orders[:storage.MaxPageLimit]
+	}
+
+	return orders, nil
+}
+
+// mergeNewestFirst sorts orders by date_created and then order_uid, both
+// descending, the order of every listing.
+func mergeNewestFirst(orders []*models.Order) []*models.Order {
+	slices.SortFunc(orders, func(a, b *models.Order) int {
+		if c := b.DateCreated.Compare(a.DateCreated); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.OrderUID, a.OrderUID)
+	})
+	return orders
+}
+
+// SearchOrders searches every shard and merges the results by rank, then
+// date_created, both descending, up to limit results.
+func (s *Storage) SearchOrders(ctx context.Context, query string, limit int) ([]models.SearchResult, error) {
+	type searcher interface {
+		SearchOrders(ctx context.Context, query string, limit int) ([]models.SearchResult, error)
+	}
+
+	found, err := fanOut(ctx, s.shards, func(ctx context.Context, shard *Shard) ([]models.SearchResult, error) {
+		sr, ok := shard.Repo.(searcher)
+		if !ok {
+			return nil, ErrUnsupported
+		}
+		return sr.SearchOrders(ctx, query, limit)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage.sharded.SearchOrders: %w", err)
+	}
+
+	results := make([]models.SearchResult, 0)
+	for _, res := range found {
+		results = append(results, res...)
+	}
+
+	slices.SortFunc(results, func(a, b models.SearchResult) int {
+		if c := cmp.Compare(b.Rank, a.Rank); c != 0 {
+			return c
+		}
+		if c := b.DateCreated.Compare(a.DateCreated); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.OrderUID, a.OrderUID)
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+// GetCustomerSummary merges the summaries of the customer's orders on
+// every shard. Top brands are ranked again by their counts summed over the
+// shards; a brand that is in no shard's top list is left out.
+func (s *Storage) GetCustomerSummary(ctx context.Context, customerID string) (*models.CustomerSummary, error) {
+	type summarizer interface {
+		GetCustomerSummary(ctx context.Context, customerID string) (*models.CustomerSummary, error)
+	}
+
+	summaries, err := fanOut(ctx, s.shards, func(ctx context.Context, shard *Shard) (*models.CustomerSummary, error) {
+		sm, ok := shard.Repo.(summarizer)
+		if !ok {
+			return nil, ErrUnsupported
+		}
+		return sm.GetCustomerSummary(ctx, customerID)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage.sharded.GetCustomerSummary: %w", err)
+	}
+
+	merged := &models.CustomerSummary{
+		CustomerID: customerID,
+		TotalSpent: make(map[string]int),
+		TopBrands:  make([]models.BrandCount, 0),
+	}
+
+	brands := make(map[string]int)
+	var itemCount float64
+	for _, sm := range summaries {
+		if sm.OrderCount == 0 {
+			continue
+		}
+
+		if merged.OrderCount == 0 || sm.FirstOrderAt.Before(merged.FirstOrderAt) {
+			merged.FirstOrderAt = sm.FirstOrderAt
+		}
+		if sm.LastOrderAt.After(merged.LastOrderAt) {
+			merged.LastOrderAt = sm.LastOrderAt
+		}
+		merged.OrderCount += sm.OrderCount
+		itemCount += sm.AvgBasketSize * float64(sm.OrderCount)
+
+		for currency, total := range sm.TotalSpent {
+			merged.TotalSpent[currency] += total
+		}
+		for _, bc := range sm.TopBrands {
+			brands[bc.Brand] += bc.Count
+		}
+	}
+	if merged.OrderCount == 0 {
+		return merged, nil
+	}
+
+	merged.AvgBasketSize = math.Round(itemCount) / float64(merged.OrderCount)
+
+	for brand, count := range brands {
+		merged.TopBrands = append(merged.TopBrands, models.BrandCount{Brand: brand, Count: count})
+	}
+	slices.SortFunc(merged.TopBrands, func(a, b models.BrandCount) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Brand, b.Brand)
+	})
+	if len(merged.TopBrands) > storage.CustomerTopBrands {
+		merged.TopBrands = merged.TopBrands[:storage.CustomerTopBrands]
+	}
+
+	return merged, nil
+}
@@ -0,0 +1,150 @@
+// Package sharded spreads orders over several storage backends by their
+// shardkey.
+//
+// Every order lives on exactly one shard, chosen by the shard map: a
+// shardkey listed for a shard goes to that shard, any other key is hashed
+// over all shards. The shard of every order_uid is recorded in a
+// Directory, which keeps order_uid unique across shards and routes lookups
+// by order_uid to a single shard. Listings and searches are sent to all
+// shards and the results merged.
+package sharded
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"wb-examples-l0/internal/storage"
+)
+
+// Directory maps order UIDs to the names of their shards.
+//
+// ClaimOrderShard records the shard of an order before it is saved there.
+// Claiming a UID again for the same shard succeeds, so an interrupted save
+// can be retried; a claim by another shard returns storage.ErrOrderExists.
+// LookupOrderShard returns storage.ErrOrderNotFound for unknown UIDs.
+type Directory interface {
+	ClaimOrderShard(ctx context.Context, orderUID, shard string) error
+	LookupOrderShard(ctx context.Context, orderUID string) (string, error)
+	ReleaseOrderShard(ctx context.Context, orderUID string) error
+}
+
+// Shard is one backend of a sharded storage.
+type Shard struct {
+	Name string
+	Repo storage.OrderRepository
+	// Keys are the shardkey values stored on this shard.
+	Keys []string
+}
+
+var ErrUnsupported = errors.New("operation not supported by shard")
+
+// Storage implements storage.OrderRepository and the optional storage
+// capabilities on top of the shards. Capabilities a shard backend lacks
+// fail with ErrUnsupported.
+type Storage struct {
+	shards []Shard
+	byName map[string]*Shard
+	byKey  map[string]*Shard
+	dir    Directory
+}
+
+func New(shards []Shard, dir Directory) (*Storage, error) {
+	const op = "storage.sharded.New"
+
+	if len(shards) == 0 {
+		return nil, fmt.Errorf("%s: no shards configured", op)
+	}
+
+	s := &Storage{
+		shards: shards,
+		byName: make(map[string]*Shard, len(shards)),
+		byKey:  make(map[string]*Shard),
+		dir:    dir,
+	}
+
+	for i := range s.shards {
+		shard := &s.shards[i]
+		if _, ok := s.byName[shard.Name]; ok || shard.Name == "" {
+			return nil, fmt.Errorf("%s: invalid or duplicate shard name %q", op, shard.Name)
+		}
+		s.byName[shard.Name] = shard
+
+		for _, key := range shard.Keys {
+			if other, ok := s.byKey[key]; ok {
+				return nil, fmt.Errorf("%s: shardkey %q is mapped to %s and %s", op, key, other.Name, shard.Name)
+			}
+			s.byKey[key] = shard
+		}
+	}
+
+	return s, nil
+}
+
+// shardFor returns the shard for a shardkey.
+func (s *Storage) shardFor(shardkey string) *Shard {
+	if shard, ok := s.byKey[shardkey]; ok {
+		return shard
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(shardkey))
+	return &s.shards[h.Sum32()%uint32(len(s.shards))]
+}
+
+// shardOf returns the shard holding an order.
+func (s *Storage) shardOf(ctx context.Context, orderUID string) (*Shard, error) {
+	name, err := s.dir.LookupOrderShard(ctx, orderUID)
+	if err != nil {
+		return nil, err
+	}
+
+	shard, ok := s.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("order %s is on unknown shard %q", orderUID, name)
+	}
+	return shard, nil
+}
+
+// Close closes the shards that can be closed.
+func (s *Storage) Close() {
+	for _, shard := range s.shards {
+		if c, ok := shard.Repo.(interface{ Close() }); ok {
+			c.Close()
+		}
+	}
+}
+
+// fanOut calls fn for every shard concurrently and returns the results in
+// shard order, or the first error.
+func fanOut[T any](ctx context.Context, shards []Shard, fn func(ctx context.Context, shard *Shard) (T, error)) ([]T, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]T, len(shards))
+	errs := make(chan error, len(shards))
+
+	for i := range shards {
+		go func() {
+			res, err := fn(ctx, &shards[i])
+			if err != nil {
+				err = fmt.Errorf("shard %s: %w", shards[i].Name, err)
+			}
+			results[i] = res
+			errs <- err
+		}()
+	}
+
+	var firstErr error
+	for range shards {
+		if err := <-errs; err != nil && firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return results, nil
+}
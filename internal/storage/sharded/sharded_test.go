@@ -0,0 +1,251 @@
+package sharded
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/storage"
+	"wb-examples-l0/internal/storage/memory"
+	"wb-examples-l0/internal/storage/storagetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memDirectory is an in-memory Directory.
+type memDirectory struct {
+	mu     sync.Mutex
+	shards map[string]string
+}
+
+func (d *memDirectory) ClaimOrderShard(_ context.Context, orderUID, shard string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if owner, ok := d.shards[orderUID]; ok && owner != shard {
+		return storage.ErrOrderExists
+	}
+	d.shards[orderUID] = shard
+	return nil
+}
+
+func (d *memDirectory) LookupOrderShard(_ context.Context, orderUID string) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	shard, ok := d.shards[orderUID]
+	if !ok {
+		return "", storage.ErrOrderNotFound
+	}
+	return shard, nil
+}
+
+func (d *memDirectory) ReleaseOrderShard(_ context.Context, orderUID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.shards, orderUID)
+	return nil
+}
+
+// newTestStorage returns three in-memory shards; a holds shardkey "1",
+// b holds "2" and "9", c gets only hashed keys.
+func newTestStorage(t *testing.T) (*Storage, map[string]*memory.Storage) {
+	t.Helper()
+
+	mems := map[string]*memory.Storage{"a": memory.New(), "b": memory.New(), "c": memory.New()}
+	s, err := New([]Shard{
+		{Name: "a", Repo: mems["a"], Keys: []string{"1"}},
+		{Name: "b", Repo: mems["b"], Keys: []string{"2", "9"}},
+		{Name: "c", Repo: mems["c"]},
+	}, &memDirectory{shards: make(map[string]string)})
+	require.NoError(t, err)
+
+	return s, mems
+}
+
+func TestStorage_Contract(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.OrderRepository {
+		s, _ := newTestStorage(t)
+		return s
+	})
+}
+
+func TestStorage_StatusContract(t *testing.T) {
+	storagetest.RunStatus(t, func(t *testing.T) storage.StatusRepository {
+		s, _ := newTestStorage(t)
+		return s
+	})
+}
+
+func TestNew_Invalid(t *testing.T) {
+	dir := &memDirectory{shards: make(map[string]string)}
+
+	_, err := New(nil, dir)
+	require.Error(t, err)
+
+	_, err = New([]Shard{{Name: "a", Repo: memory.New()}, {Name: "a", Repo: memory.New()}}, dir)
+	require.Error(t, err)
+
+	_, err = New([]Shard{
+		{Name: "a", Repo: memory.New(), Keys: []string{"1"}},
+		{Name: "b", Repo: memory.New(), Keys: []string{"1"}},
+	}, dir)
+	require.Error(t, err)
+}
+
+func TestStorage_Routing(t *testing.T) {
+	s, mems := newTestStorage(t)
+	ctx := context.Background()
+
+	for key, shard := range map[string]string{"1": "a", "2": "b", "9": "b"} {
+		order := storagetest.NewOrder("route-"+key, 0)
+		order.Shardkey = key
+		require.NoError(t, s.SaveOrder(ctx, order))
+
+		exists, err := mems[shard].OrderExists(ctx, order.OrderUID)
+		require.NoError(t, err)
+		assert.True(t, exists, "shardkey %s must be on shard %s", key, shard)
+	}
+
+	// Unlisted keys always hash to the same shard.
+	require.Same(t, s.shardFor("42"), s.shardFor("42"))
+}
+
+func TestStorage_DuplicateAcrossShards(t *testing.T) {
+	s, _ := newTestStorage(t)
+	ctx := context.Background()
+
+	order := storagetest.NewOrder("dup", 0)
+	order.Shardkey = "1"
+	require.NoError(t, s.SaveOrder(ctx, order))
+
+	// The same UID with a shardkey of another shard is still a duplicate.
+	other := storagetest.NewOrder("dup", 0)
+	other.Shardkey = "2"
+	require.ErrorIs(t, s.SaveOrder(ctx, other), storage.ErrOrderExists)
+}
+
+func TestStorage_RetryAfterInterruptedSave(t *testing.T) {
+	s, _ := newTestStorage(t)
+	ctx := context.Background()
+
+	order := storagetest.NewOrder("retry", 0)
+	order.Shardkey = "1"
+
+	// A claim without the order, as left by a crash between the two writes.
+	require.NoError(t, s.dir.ClaimOrderShard(ctx, order.OrderUID, "a"))
+
+	exists, err := s.OrderExists(ctx, order.OrderUID)
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	require.NoError(t, s.SaveOrder(ctx, order))
+
+	got, err := s.GetOrderByUID(ctx, order.OrderUID)
+	require.NoError(t, err)
+	require.Equal(t, order.OrderUID, got.OrderUID)
+}
+
+func TestStorage_ListAcrossShards(t *testing.T) {
+	s, _ := newTestStorage(t)
+	ctx := context.Background()
+
+	keys := []string{"1", "2", "3", "4"}
+	var want []string
+	for i := 9; i >= 0; i-- {
+		want = append(want, fmt.Sprintf("list-%d", i))
+	}
+	for i := range 10 {
+		order := storagetest.NewOrder(fmt.Sprintf("list-%d", i), i)
+		order.Shardkey = keys[i%len(keys)]
+		require.NoError(t, s.SaveOrder(ctx, order))
+	}
+
+	var got []string
+	filter := storage.OrderFilter{Limit: 3}
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 10, "pagination does not terminate")
+
+		page, err := s.ListOrders(ctx, filter)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(page.Orders), 3)
+
+		for _, o := range page.Orders {
+			got = append(got, o.OrderUID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+
+	require.Equal(t, want, got)
+}
+
+func TestStorage_LookupAcrossShards(t *testing.T) {
+	s, _ := newTestStorage(t)
+	ctx := context.Background()
+
+	a := storagetest.NewOrder("lookup-a", 0)
+	a.Shardkey = "1"
+	b := storagetest.NewOrder("lookup-b", 1)
+	b.Shardkey = "2"
+	for _, o := range []*models.Order{a, b} {
+		require.NoError(t, s.SaveOrder(ctx, o))
+	}
+
+	orders, err := s.GetOrdersByTrackNumber(ctx, a.TrackNumber)
+	require.NoError(t, err)
+	require.Len(t, orders, 2)
+	require.Equal(t, b.OrderUID, orders[0].OrderUID)
+	require.Equal(t, a.OrderUID, orders[1].OrderUID)
+
+	// b2 matches in two items, so it ranks first; the others tie on rank
+	// and are ordered newest first.
+	b2 := storagetest.NewOrder("lookup-b2", 1)
+	b2.Shardkey = "2"
+	b2.Items = append(b2.Items, b2.Items[0])
+	b2.Items[1].ChrtID++
+	c := storagetest.NewOrder("lookup-c", 2)
+	c.Shardkey = "9"
+	c.CustomerID = "other"
+	for _, o := range []*models.Order{b2, c} {
+		require.NoError(t, s.SaveOrder(ctx, o))
+	}
+
+	results, err := s.SearchOrders(ctx, "mascar", 10)
+	require.NoError(t, err)
+	uids := make([]string, len(results))
+	for i, res := range results {
+		uids[i] = res.OrderUID
+	}
+	require.Equal(t, []string{"lookup-b2", "lookup-c", "lookup-b", "lookup-a"}, uids)
+
+	results, err = s.SearchOrders(ctx, "mascar", 2)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	// a, b and b2 belong to "test" and are on shards a and b.
+	summary, err := s.GetCustomerSummary(ctx, "test")
+	require.NoError(t, err)
+	require.Equal(t, 3, summary.OrderCount)
+	require.Equal(t, map[string]int{"USD": 3 * a.Payment.Amount}, summary.TotalSpent)
+	require.InDelta(t, 4.0/3.0, summary.AvgBasketSize, 1e-9)
+	require.Equal(t, []models.BrandCount{{Brand: a.Items[0].Brand, Count: 4}}, summary.TopBrands)
+	require.Equal(t, a.DateCreated, summary.FirstOrderAt)
+	require.Equal(t, b2.DateCreated, summary.LastOrderAt)
+
+	summary, err = s.GetCustomerSummary(ctx, "nobody")
+	require.NoError(t, err)
+	require.Zero(t, summary.OrderCount)
+}
+
+func TestStorage_Unsupported(t *testing.T) {
+	s, _ := newTestStorage(t)
+
+	_, err := s.ArchiveDeleted(context.Background(), 10)
+	require.ErrorIs(t, err, ErrUnsupported)
+}
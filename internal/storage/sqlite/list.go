@@ -0,0 +1,292 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/storage"
+)
+
+func (s *Storage) ListOrders(ctx context.Context, filter storage.OrderFilter) (*storage.OrderPage, error) {
+	const op = "storage.sqlite.ListOrders"
+
+	limit := filter.PageLimit()
+
+	query, args, err := buildListQuery(filter, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	uids, err := s.queryUIDs(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	hasMore := len(uids) > limit
+	if hasMore {
+		uids = uids[:limit]
+	}
+
+	orders, err := s.getOrdersByUIDs(ctx, uids)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	page := &storage.OrderPage{Orders: orders}
+	if hasMore && len(orders) > 0 {
+		last := orders[len(orders)-1]
+		page.NextCursor = storage.EncodeCursor(last.DateCreated, last.OrderUID)
+	}
+
+	return page, nil
+}
+
+func (s *Storage) GetOrdersByTrackNumber(ctx context.Context, trackNumber string) ([]*models.Order, error) {
+	const op = "storage.sqlite.GetOrdersByTrackNumber"
+
+	orders, err := s.findOrders(ctx, `
+        SELECT o.order_uid FROM orders o
+        WHERE o.track_number = ?1
+           OR EXISTS (SELECT 1 FROM items i WHERE i.order_uid = o.order_uid AND i.track_number = ?1)
+        ORDER BY o.date_created DESC, o.order_uid DESC
+        LIMIT ?2
+    `, trackNumber, storage.MaxPageLimit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return orders, nil
+}
+
+func (s *Storage) GetOrdersByPayment(ctx context.Context, requestID, transaction string) ([]*models.Order, error) {
+	const op = "storage.sqlite.GetOrdersByPayment"
+
+	if requestID == "" && transaction == "" {
+		return nil, fmt.Errorf("%s: request_id or transaction must be provided", op)
+	}
+
+	orders, err := s.findOrders(ctx, `
+        SELECT o.order_uid FROM orders o
+        JOIN payments p ON p.order_uid = o.order_uid
+        WHERE (?1 = '' OR p.request_id = ?1)
+          AND (?2 = '' OR p.order_uid = ?2)
+        ORDER BY o.date_created DESC, o.order_uid DESC
+        LIMIT ?3
+    `, requestID, transaction, storage.MaxPageLimit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return orders, nil
+}
+
+func (s *Storage) GetOrdersByItem(ctx context.Context, rid string, chrtID int) ([]*models.Order, error) {
+	const op = "storage.sqlite.GetOrdersByItem"
+
+	if rid == "" && chrtID == 0 {
+		return nil, fmt.Errorf("%s: rid or chrt_id must be provided", op)
+	}
+
+	orders, err := s.findOrders(ctx, `
+        SELECT o.order_uid FROM orders o
+        WHERE EXISTS (
+            SELECT 1 FROM items i
+            WHERE i.order_uid = o.order_uid
+              AND (?1 = '' OR i.rid = ?1)
+              AND (?2 = 0 OR i.chrt_id = ?2)
+        )
+        ORDER BY o.date_created DESC, o.order_uid DESC
+        LIMIT ?3
+    `, rid, chrtID, storage.MaxPageLimit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return orders, nil
+}
+
+func buildListQuery(filter storage.OrderFilter, limit int) (string, []interface{}, error) {
+	var (
+		where []string
+		args  []interface{}
+	)
+
+	eq := func(column, value string) {
+		if value != "" {
+			where = append(where, column+" = ?")
+			args = append(args, value)
+		}
+	}
+
+	eq("o.customer_id", filter.CustomerID)
+	eq("o.track_number", filter.TrackNumber)
+	eq("o.delivery_service", filter.DeliveryService)
+	eq("o.locale", filter.Locale)
+
+	if !filter.CreatedFrom.IsZero() {
+		where = append(where, "o.date_created >= ?")
+		args = append(args, filter.CreatedFrom.UnixNano())
+	}
+	if !filter.CreatedTo.IsZero() {
+		where = append(where, "o.date_created < ?")
+		args = append(args, filter.CreatedTo.UnixNano())
+	}
+
+	joinPayments := filter.Currency != "" || filter.Provider != "" || filter.Bank != ""
+	eq("p.currency", filter.Currency)
+	eq("p.provider", filter.Provider)
+	eq("p.bank", filter.Bank)
+
+	if filter.Brand != "" {
+		where = append(where, "EXISTS (SELECT 1 FROM items i WHERE i.order_uid = o.order_uid AND i.brand = ?)")
+		args = append(args, filter.Brand)
+	}
+
+	if filter.Cursor != "" {
+		dateCreated, uid, err := storage.DecodeCursor(filter.Cursor)
+		if err != nil {
+			return "", nil, err
+		}
+		where = append(where, "(o.date_created, o.order_uid) < (?, ?)")
+		args = append(args, dateCreated.UnixNano(), uid)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("SELECT o.order_uid FROM orders o")
+	if joinPayments {
+		sb.WriteString(" JOIN payments p ON p.order_uid = o.order_uid")
+	}
+	if len(where) > 0 {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(strings.Join(where, " AND "))
+	}
+	sb.WriteString(" ORDER BY o.date_created DESC, o.order_uid DESC LIMIT ?")
+	args = append(args, limit)
+
+	return sb.String(), args, nil
+}
+
+func (s *Storage) findOrders(ctx context.Context, query string, args ...interface{}) ([]*models.Order, error) {
+	uids, err := s.queryUIDs(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getOrdersByUIDs(ctx, uids)
+}
+
+func (s *Storage) queryUIDs(ctx context.Context, query string, args ...interface{}) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query order UIDs: %w", err)
+	}
+	defer rows.Close()
+
+	var uids []string
+	for rows.Next() {
+		var uid string
+		if err := rows.Scan(&uid); err != nil {
+			return nil, fmt.Errorf("scan order UID: %w", err)
+		}
+		uids = append(uids, uid)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return uids, nil
+}
+
+// getOrdersByUIDs loads full orders for the given UIDs and returns them in
+// the order of uids. Unknown UIDs are skipped.
+func (s *Storage) getOrdersByUIDs(ctx context.Context, uids []string) ([]*models.Order, error) {
+	orders := make([]*models.Order, 0, len(uids))
+	if len(uids) == 0 {
+		return orders, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(uids)), ", ")
+	args := make([]interface{}, len(uids))
+	for i, uid := range uids {
+		args[i] = uid
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT o.order_uid, o.track_number, o.entry, o.locale, o.internal_signature,
+               o.customer_id, o.delivery_service, o.shardkey, o.sm_id, o.date_created, o.oof_shard,
+               d.name, d.phone, d.zip, d.city, d.address, d.region, d.email,
+               p.request_id, p.currency, p.provider, p.amount, p.payment_dt,
+               p.bank, p.delivery_cost, p.goods_total, p.custom_fee
+        FROM orders o
+        JOIN deliveries d ON d.order_uid = o.order_uid
+        JOIN payments p ON p.order_uid = o.order_uid
+        WHERE o.order_uid IN (`+placeholders+`)
+    `, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get orders: %w", err)
+	}
+	defer rows.Close()
+
+	byUID := make(map[string]*models.Order, len(uids))
+	for rows.Next() {
+		var order models.Order
+		var dateCreated int64
+		err := rows.Scan(
+			&order.OrderUID, &order.TrackNumber, &order.Entry, &order.Locale, &order.InternalSignature,
+			&order.CustomerID, &order.DeliveryService, &order.Shardkey, &order.SmID, &dateCreated, &order.OofShard,
+			&order.Delivery.Name, &order.Delivery.Phone, &order.Delivery.Zip, &order.Delivery.City,
+			&order.Delivery.Address, &order.Delivery.Region, &order.Delivery.Email,
+			&order.Payment.RequestID, &order.Payment.Currency, &order.Payment.Provider, &order.Payment.Amount,
+			&order.Payment.PaymentDt, &order.Payment.Bank, &order.Payment.DeliveryCost, &order.Payment.GoodsTotal,
+			&order.Payment.CustomFee,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan order: %w", err)
+		}
+		order.DateCreated = time.Unix(0, dateCreated).UTC()
+		order.Payment.Transaction = order.OrderUID
+		order.Items = make([]models.Item, 0)
+		byUID[order.OrderUID] = &order
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	itemRows, err := s.db.QueryContext(ctx, `
+        SELECT order_uid, chrt_id, track_number, price, rid, name, sale, size,
+               total_price, nm_id, brand, status
+        FROM items WHERE order_uid IN (`+placeholders+`)
+        ORDER BY id
+    `, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get items: %w", err)
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var item models.Item
+		err := itemRows.Scan(
+			&item.OrderUID, &item.ChrtID, &item.TrackNumber, &item.Price, &item.Rid, &item.Name, &item.Sale, &item.Size,
+			&item.TotalPrice, &item.NmID, &item.Brand, &item.Status,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan item: %w", err)
+		}
+		if order, ok := byUID[item.OrderUID]; ok {
+			order.Items = append(order.Items, item)
+		}
+	}
+	if err := itemRows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	for _, uid := range uids {
+		if order, ok := byUID[uid]; ok {
+			orders = append(orders, order)
+		}
+	}
+
+	return orders, nil
+}
@@ -0,0 +1,94 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"wb-examples-l0/migrations"
+)
+
+// migrate applies the pending migrations from migrations.SQLiteFS. It keeps
+// the schema_migrations layout of golang-migrate, so the database can be
+// handed over to the migrate CLI later. Each migration runs in its own
+// transaction; SQLite supports transactional DDL.
+func migrate(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `
+        CREATE TABLE IF NOT EXISTS schema_migrations (
+            version INTEGER NOT NULL PRIMARY KEY,
+            dirty INTEGER NOT NULL
+        )
+    `)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	var current int
+	err = db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current)
+	if err != nil {
+		return fmt.Errorf("get schema version: %w", err)
+	}
+
+	files, err := fs.Glob(migrations.SQLiteFS, "sqlite/*.up.sql")
+	if err != nil {
+		return fmt.Errorf("list migrations: %w", err)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		version, err := migrationVersion(file)
+		if err != nil {
+			return err
+		}
+		if version <= current {
+			continue
+		}
+
+		body, err := fs.ReadFile(migrations.SQLiteFS, file)
+		if err != nil {
+			return fmt.Errorf("read migration %s: %w", file, err)
+		}
+
+		if err := applyMigration(ctx, db, version, string(body)); err != nil {
+			return fmt.Errorf("apply migration %s: %w", file, err)
+		}
+	}
+
+	return nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, version int, body string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, body); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES (?, 0)`, version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// migrationVersion parses the version prefix of a golang-migrate file name,
+// e.g. 1 for "sqlite/000001_create_tables.up.sql".
+func migrationVersion(file string) (int, error) {
+	prefix, _, _ := strings.Cut(path.Base(file), "_")
+	version, err := strconv.Atoi(prefix)
+	if err != nil {
+		return 0, fmt.Errorf("invalid migration file name %s", file)
+	}
+	return version, nil
+}
@@ -0,0 +1,211 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"wb-examples-l0/internal/config"
+	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/storage"
+)
+
+// driverName is the database/sql driver registered by modernc.org/sqlite,
+// a pure-Go SQLite port (see driver.go).
+const driverName = "sqlite"
+
+// dsnSchemes are the DSN prefixes that select SQLite, see IsDSN.
+var dsnSchemes = []string{"file:", "sqlite:"}
+
+// defaultPragmas are applied to every connection unless the DSN sets
+// its own: foreign keys for ON DELETE CASCADE, WAL so reads don't block
+// on the writer, and a busy timeout instead of immediate SQLITE_BUSY.
+var defaultPragmas = []string{
+	"_pragma=foreign_keys(1)",
+	"_pragma=journal_mode(WAL)",
+	"_pragma=busy_timeout(5000)",
+}
+
+// Storage is a single-node SQLite backend with the same semantics
+// as postgres.Storage.
+type Storage struct {
+	db *sql.DB
+}
+
+func New(cfg *config.Config) (*Storage, error) {
+	const op = "storage.sqlite.New"
+
+	db, err := sql.Open(driverName, withPragmas(normalizeDSN(cfg.Storage.SQLite.Dsn)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Storage{
+		db,
+	}, nil
+}
+
+func (s *Storage) Close() error {
+	return s.db.Close()
+}
+
+func (s *Storage) SaveOrder(ctx context.Context, order *models.Order) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+        INSERT INTO orders (order_uid, track_number, entry, locale, internal_signature,
+                          customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        ON CONFLICT (order_uid) DO NOTHING
+    `, order.OrderUID, order.TrackNumber, order.Entry, order.Locale, order.InternalSignature,
+		order.CustomerID, order.DeliveryService, order.Shardkey, order.SmID, order.DateCreated.UnixNano(), order.OofShard)
+	if err != nil {
+		return fmt.Errorf("insert order: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("insert order: %w", err)
+	} else if n == 0 {
+		return fmt.Errorf("insert order: %w", storage.ErrOrderExists)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        INSERT INTO deliveries (order_uid, name, phone, zip, city, address, region, email)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+    `, order.OrderUID, order.Delivery.Name, order.Delivery.Phone, order.Delivery.Zip,
+		order.Delivery.City, order.Delivery.Address, order.Delivery.Region, order.Delivery.Email)
+	if err != nil {
+		return fmt.Errorf("insert delivery: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        INSERT INTO payments (order_uid, request_id, currency, provider, amount,
+                             payment_dt, bank, delivery_cost, goods_total, custom_fee)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `, order.OrderUID, order.Payment.RequestID, order.Payment.Currency, order.Payment.Provider,
+		order.Payment.Amount, order.Payment.PaymentDt, order.Payment.Bank, order.Payment.DeliveryCost,
+		order.Payment.GoodsTotal, order.Payment.CustomFee)
+	if err != nil {
+		return fmt.Errorf("insert payment: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        INSERT INTO status_history (order_uid, status, source, changed_at)
+        VALUES (?, ?, ?, ?)
+    `, order.OrderUID, models.StatusCreated, models.StatusSourceOrder, order.DateCreated.UnixNano())
+	if err != nil {
+		return fmt.Errorf("insert status: %w", err)
+	}
+
+	for _, item := range order.Items {
+		_, err = tx.ExecContext(ctx, `
+            INSERT INTO items (order_uid, chrt_id, track_number, price, rid, name,
+                              sale, size, total_price, nm_id, brand, status)
+            VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        `, order.OrderUID, item.ChrtID, item.TrackNumber, item.Price, item.Rid, item.Name,
+			item.Sale, item.Size, item.TotalPrice, item.NmID, item.Brand, item.Status)
+		if err != nil {
+			return fmt.Errorf("insert item: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetOrderByUID(ctx context.Context, orderUID string) (*models.Order, error) {
+	orders, err := s.getOrdersByUIDs(ctx, []string{orderUID})
+	if err != nil {
+		return nil, fmt.Errorf("get order: %w", err)
+	}
+	if len(orders) == 0 {
+		return nil, fmt.Errorf("get order: %w", storage.ErrOrderNotFound)
+	}
+
+	return orders[0], nil
+}
+
+func (s *Storage) OrderExists(ctx context.Context, orderUID string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `
+        SELECT EXISTS(SELECT 1 FROM orders WHERE order_uid = ?)
+    `, orderUID).Scan(&exists)
+	return exists, err
+}
+
+// DeleteOrder removes an order; deliveries, payments and items are
+// removed by ON DELETE CASCADE.
+func (s *Storage) DeleteOrder(ctx context.Context, orderUID string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM orders WHERE order_uid = ?`, orderUID)
+	if err != nil {
+		return fmt.Errorf("delete order: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete order: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("delete order: %w", storage.ErrOrderNotFound)
+	}
+
+	return nil
+}
+
+// IsDSN reports whether dsn names a SQLite database: a file: URI or a
+// sqlite: path such as "sqlite:orders.db".
+func IsDSN(dsn string) bool {
+	for _, scheme := range dsnSchemes {
+		if strings.HasPrefix(dsn, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeDSN turns a sqlite: DSN into the file: URI the driver expects.
+func normalizeDSN(dsn string) string {
+	if path, ok := strings.CutPrefix(dsn, "sqlite:"); ok {
+		return "file:" + strings.TrimPrefix(path, "//")
+	}
+	return dsn
+}
+
+func withPragmas(dsn string) string {
+	var missing []string
+	for _, p := range defaultPragmas {
+		name, _, _ := strings.Cut(strings.TrimPrefix(p, "_pragma="), "(")
+		if !strings.Contains(dsn, name) {
+			missing = append(missing, p)
+		}
+	}
+	if len(missing) == 0 {
+		return dsn
+	}
+
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	return dsn + sep + strings.Join(missing, "&")
+}
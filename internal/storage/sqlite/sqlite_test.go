@@ -0,0 +1,66 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"testing"
+	"wb-examples-l0/internal/config"
+	"wb-examples-l0/internal/storage"
+	"wb-examples-l0/internal/storage/storagetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStorage(t *testing.T) *Storage {
+	t.Helper()
+
+	var cfg config.Config
+	cfg.Storage.SQLite.Dsn = "file:" + filepath.Join(t.TempDir(), "orders.db")
+
+	s, err := New(&cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+
+	return s
+}
+
+func TestStorage_Contract(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.OrderRepository {
+		return newTestStorage(t)
+	})
+}
+
+func TestStorage_Status(t *testing.T) {
+	storagetest.RunStatus(t, func(t *testing.T) storage.StatusRepository {
+		return newTestStorage(t)
+	})
+}
+
+func TestStorage_MigrateIsIdempotent(t *testing.T) {
+	s := newTestStorage(t)
+
+	require.NoError(t, migrate(t.Context(), s.db))
+}
+
+func TestIsDSN(t *testing.T) {
+	for dsn, want := range map[string]bool{
+		"file:orders.db":                   true,
+		"sqlite:orders.db":                 true,
+		"sqlite:///data/o.db":              true,
+		"postgres://u:p@localhost:5432/db": false,
+		"host=localhost dbname=db":         false,
+	} {
+		assert.Equal(t, want, IsDSN(dsn), dsn)
+	}
+}
+
+func TestNew_SQLiteScheme(t *testing.T) {
+	var cfg config.Config
+	cfg.Storage.SQLite.Dsn = "sqlite:" + filepath.Join(t.TempDir(), "orders.db")
+
+	s, err := New(&cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+
+	require.NoError(t, s.SaveOrder(t.Context(), storagetest.NewOrder("a", 0)))
+}
@@ -0,0 +1,149 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/storage"
+)
+
+// UpdateOrderStatus moves an order, or its items with update.ChrtID, to
+// update.Status and records the transition in status_history. SQLite has a single writer, so the check
+// and the update can't interleave with another update.
+func (s *Storage) UpdateOrderStatus(ctx context.Context, update models.StatusUpdate) error {
+	const op = "storage.sqlite.UpdateOrderStatus"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var current models.OrderStatus
+	err = tx.QueryRowContext(ctx, `SELECT status FROM orders WHERE order_uid = ?`, update.OrderUID).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s: %w", op, storage.ErrOrderNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: get status: %w", op, err)
+	}
+
+	if update.ChrtID != 0 {
+		changed, err := updateItemStatus(ctx, tx, update)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if !changed {
+			return nil
+		}
+	} else {
+		if current == update.Status {
+			return nil
+		}
+		if !current.CanTransitionTo(update.Status) {
+			return fmt.Errorf("%s: %s -> %s: %w", op, current, update.Status, storage.ErrIllegalTransition)
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE orders SET status = ? WHERE order_uid = ?`,
+			update.Status, update.OrderUID); err != nil {
+			return fmt.Errorf("%s: update status: %w", op, err)
+		}
+	}
+	_, err = tx.ExecContext(ctx, `
+        INSERT INTO status_history (order_uid, status, source, changed_at, chrt_id)
+        VALUES (?, ?, ?, ?, nullif(?, 0))
+    `, update.OrderUID, update.Status, update.Source, update.ChangedAt.UnixNano(), update.ChrtID)
+	if err != nil {
+		return fmt.Errorf("%s: insert history: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// updateItemStatus moves the items of update.OrderUID with update.ChrtID
+// to update.Status. It reports false if they are all in it already.
+func updateItemStatus(ctx context.Context, tx *sql.Tx, update models.StatusUpdate) (bool, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT status FROM items WHERE order_uid = ? AND chrt_id = ?`,
+		update.OrderUID, update.ChrtID)
+	if err != nil {
+		return false, fmt.Errorf("get item status: %w", err)
+	}
+	defer rows.Close()
+
+	var codes []int
+	for rows.Next() {
+		var code int
+		if err := rows.Scan(&code); err != nil {
+			return false, fmt.Errorf("scan item status: %w", err)
+		}
+		codes = append(codes, code)
+	}
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("scan item status: %w", err)
+	}
+
+	changed, err := storage.CheckItemTransition(codes, update.Status)
+	if err != nil {
+		return false, fmt.Errorf("chrt_id %d: %w", update.ChrtID, err)
+	}
+	if !changed {
+		return false, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE items SET status = ? WHERE order_uid = ? AND chrt_id = ?`,
+		update.Status.ItemCode(), update.OrderUID, update.ChrtID); err != nil {
+		return false, fmt.Errorf("update item status: %w", err)
+	}
+
+	return true, nil
+}
+
+// GetOrderTimeline returns the current status of an order and its
+// transitions, oldest first.
+func (s *Storage) GetOrderTimeline(ctx context.Context, orderUID string) (*models.OrderTimeline, error) {
+	const op = "storage.sqlite.GetOrderTimeline"
+
+	timeline := &models.OrderTimeline{OrderUID: orderUID}
+
+	err := s.db.QueryRowContext(ctx, `SELECT status FROM orders WHERE order_uid = ?`, orderUID).Scan(&timeline.Status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrOrderNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: get status: %w", op, err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT status, source, changed_at, coalesce(chrt_id, 0) FROM status_history
+        WHERE order_uid = ?
+        ORDER BY id
+    `, orderUID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: get history: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			change    models.StatusChange
+			changedAt int64
+		)
+		if err := rows.Scan(&change.Status, &change.Source, &changedAt, &change.ChrtID); err != nil {
+			return nil, fmt.Errorf("%s: scan history: %w", op, err)
+		}
+		change.ChangedAt = time.Unix(0, changedAt).UTC()
+		timeline.History = append(timeline.History, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: scan history: %w", op, err)
+	}
+
+	return timeline, nil
+}
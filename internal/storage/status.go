@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"wb-examples-l0/internal/models"
+)
+
+var (
+	ErrIllegalTransition = errors.New("illegal status transition")
+	ErrItemNotFound      = errors.New("item not found")
+)
+
+// StatusRepository is implemented by backends that track the order status
+// lifecycle. Saved orders start in models.StatusCreated.
+// UpdateOrderStatus returns ErrIllegalTransition for a transition that
+// models.OrderStatus.CanTransitionTo does not allow and ErrOrderNotFound
+// for an unknown order; an update to the current status is a no-op, so
+// redelivered messages are harmless. An update with a ChrtID moves the
+// order's items with that chrt_id the same way and returns ErrItemNotFound
+// if the order has none.
+type StatusRepository interface {
+	OrderRepository
+	UpdateOrderStatus(ctx context.Context, update models.StatusUpdate) error
+	GetOrderTimeline(ctx context.Context, orderUID string) (*models.OrderTimeline, error)
+}
+
+// CheckItemTransition checks that items with the status codes codes may
+// move to next and reports whether any of them is not in next yet.
+func CheckItemTransition(codes []int, next models.OrderStatus) (bool, error) {
+	if len(codes) == 0 {
+		return false, ErrItemNotFound
+	}
+
+	changed := false
+	for _, code := range codes {
+		current, ok := models.ItemStatus(code)
+		if current == next {
+			continue
+		}
+		if !ok || !current.CanTransitionTo(next) {
+			return false, fmt.Errorf("item status %d -> %s: %w", code, next, ErrIllegalTransition)
+		}
+		changed = true
+	}
+
+	return changed, nil
+}
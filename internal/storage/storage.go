@@ -1,8 +1,23 @@
 package storage
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"wb-examples-l0/internal/models"
+)
 
 var (
-	ErrURLNotFound = errors.New("order not found")
-	ErrURLExists   = errors.New("order already exists")
+	ErrOrderNotFound = errors.New("order not found")
+	ErrOrderExists   = errors.New("order already exists")
 )
+
+// OrderRepository is implemented by every storage backend.
+// SaveOrder returns ErrOrderExists for a duplicate order_uid;
+// GetOrderByUID and DeleteOrder return ErrOrderNotFound for an unknown one.
+type OrderRepository interface {
+	SaveOrder(ctx context.Context, order *models.Order) error
+	GetOrderByUID(ctx context.Context, orderUID string) (*models.Order, error)
+	OrderExists(ctx context.Context, orderUID string) (bool, error)
+	ListOrders(ctx context.Context, filter OrderFilter) (*OrderPage, error)
+	DeleteOrder(ctx context.Context, orderUID string) error
+}
@@ -0,0 +1,186 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// StatusFactory returns an empty repository with status tracking.
+type StatusFactory func(t *testing.T) storage.StatusRepository
+
+// RunStatus runs the status lifecycle suite against repositories built
+// by newRepo.
+func RunStatus(t *testing.T, newRepo StatusFactory) {
+	t.Run("InitialStatus", func(t *testing.T) { testInitialStatus(t, newRepo(t)) })
+	t.Run("Lifecycle", func(t *testing.T) { testLifecycle(t, newRepo(t)) })
+	t.Run("IllegalTransition", func(t *testing.T) { testIllegalTransition(t, newRepo(t)) })
+	t.Run("RepeatedUpdate", func(t *testing.T) { testRepeatedUpdate(t, newRepo(t)) })
+	t.Run("UnknownOrder", func(t *testing.T) { testStatusUnknownOrder(t, newRepo(t)) })
+	t.Run("ItemLifecycle", func(t *testing.T) { testItemLifecycle(t, newRepo(t)) })
+	t.Run("ItemIllegalTransition", func(t *testing.T) { testItemIllegalTransition(t, newRepo(t)) })
+	t.Run("UnknownItem", func(t *testing.T) { testUnknownItem(t, newRepo(t)) })
+}
+
+func itemUpdate(uid string, chrtID int, status models.OrderStatus, n int) models.StatusUpdate {
+	update := statusUpdate(uid, status, n)
+	update.ChrtID = chrtID
+	return update
+}
+
+func statusUpdate(uid string, status models.OrderStatus, n int) models.StatusUpdate {
+	return models.StatusUpdate{
+		OrderUID:  uid,
+		Status:    status,
+		Source:    "test",
+		ChangedAt: baseTime.Add(time.Duration(n) * time.Hour),
+	}
+}
+
+func testInitialStatus(t *testing.T, repo storage.StatusRepository) {
+	ctx := context.Background()
+	order := NewOrder("contract-status-initial", 0)
+	require.NoError(t, repo.SaveOrder(ctx, order))
+
+	timeline, err := repo.GetOrderTimeline(ctx, order.OrderUID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusCreated, timeline.Status)
+	require.Len(t, timeline.History, 1)
+	assert.Equal(t, models.StatusCreated, timeline.History[0].Status)
+	assert.Equal(t, models.StatusSourceOrder, timeline.History[0].Source)
+	assert.True(t, order.DateCreated.Equal(timeline.History[0].ChangedAt))
+}
+
+func testLifecycle(t *testing.T, repo storage.StatusRepository) {
+	ctx := context.Background()
+	order := NewOrder("contract-status-lifecycle", 0)
+	require.NoError(t, repo.SaveOrder(ctx, order))
+
+	steps := []models.OrderStatus{
+		models.StatusPaid, models.StatusAssembled, models.StatusShipped,
+		models.StatusDelivered, models.StatusReturned,
+	}
+	for i, status := range steps {
+		require.NoError(t, repo.UpdateOrderStatus(ctx, statusUpdate(order.OrderUID, status, i+1)), status)
+	}
+
+	timeline, err := repo.GetOrderTimeline(ctx, order.OrderUID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusReturned, timeline.Status)
+
+	got := make([]models.OrderStatus, 0, len(timeline.History))
+	for _, change := range timeline.History {
+		got = append(got, change.Status)
+	}
+	assert.Equal(t, append([]models.OrderStatus{models.StatusCreated}, steps...), got)
+}
+
+func testIllegalTransition(t *testing.T, repo storage.StatusRepository) {
+	ctx := context.Background()
+	order := NewOrder("contract-status-illegal", 0)
+	require.NoError(t, repo.SaveOrder(ctx, order))
+
+	err := repo.UpdateOrderStatus(ctx, statusUpdate(order.OrderUID, models.StatusShipped, 1))
+	assert.ErrorIs(t, err, storage.ErrIllegalTransition)
+
+	require.NoError(t, repo.UpdateOrderStatus(ctx, statusUpdate(order.OrderUID, models.StatusCancelled, 2)))
+
+	err = repo.UpdateOrderStatus(ctx, statusUpdate(order.OrderUID, models.StatusPaid, 3))
+	assert.ErrorIs(t, err, storage.ErrIllegalTransition)
+
+	timeline, err := repo.GetOrderTimeline(ctx, order.OrderUID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusCancelled, timeline.Status)
+	assert.Len(t, timeline.History, 2)
+}
+
+func testRepeatedUpdate(t *testing.T, repo storage.StatusRepository) {
+	ctx := context.Background()
+	order := NewOrder("contract-status-repeated", 0)
+	require.NoError(t, repo.SaveOrder(ctx, order))
+
+	update := statusUpdate(order.OrderUID, models.StatusPaid, 1)
+	require.NoError(t, repo.UpdateOrderStatus(ctx, update))
+	require.NoError(t, repo.UpdateOrderStatus(ctx, update))
+
+	timeline, err := repo.GetOrderTimeline(ctx, order.OrderUID)
+	require.NoError(t, err)
+	assert.Len(t, timeline.History, 2)
+}
+
+func testStatusUnknownOrder(t *testing.T, repo storage.StatusRepository) {
+	ctx := context.Background()
+
+	err := repo.UpdateOrderStatus(ctx, statusUpdate("contract-missing", models.StatusPaid, 1))
+	assert.ErrorIs(t, err, storage.ErrOrderNotFound)
+
+	_, err = repo.GetOrderTimeline(ctx, "contract-missing")
+	assert.ErrorIs(t, err, storage.ErrOrderNotFound)
+}
+
+func testItemLifecycle(t *testing.T, repo storage.StatusRepository) {
+	ctx := context.Background()
+	order := NewOrder("contract-status-item", 0)
+	order.Items = append(order.Items, order.Items[0])
+	order.Items[1].ChrtID++
+	require.NoError(t, repo.SaveOrder(ctx, order))
+	chrtID := order.Items[0].ChrtID
+
+	require.NoError(t, repo.UpdateOrderStatus(ctx, itemUpdate(order.OrderUID, chrtID, models.StatusPaid, 1)))
+	require.NoError(t, repo.UpdateOrderStatus(ctx, itemUpdate(order.OrderUID, chrtID, models.StatusCancelled, 2)))
+	require.NoError(t, repo.UpdateOrderStatus(ctx, itemUpdate(order.OrderUID, chrtID, models.StatusCancelled, 2)))
+
+	got, err := repo.GetOrderByUID(ctx, order.OrderUID)
+	require.NoError(t, err)
+	require.Len(t, got.Items, 2)
+	codes := map[int]int{got.Items[0].ChrtID: got.Items[0].Status, got.Items[1].ChrtID: got.Items[1].Status}
+	assert.Equal(t, map[int]int{chrtID: 207, chrtID + 1: 202}, codes)
+
+	// Item updates leave the order status alone.
+	timeline, err := repo.GetOrderTimeline(ctx, order.OrderUID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusCreated, timeline.Status)
+	require.Len(t, timeline.History, 3)
+	assert.Zero(t, timeline.History[0].ChrtID)
+	assert.Equal(t, models.StatusChange{
+		Status:    models.StatusCancelled,
+		Source:    "test",
+		ChangedAt: baseTime.Add(2 * time.Hour),
+		ChrtID:    chrtID,
+	}, timeline.History[2])
+}
+
+func testItemIllegalTransition(t *testing.T, repo storage.StatusRepository) {
+	ctx := context.Background()
+	order := NewOrder("contract-status-item-illegal", 0)
+	require.NoError(t, repo.SaveOrder(ctx, order))
+	chrtID := order.Items[0].ChrtID
+
+	err := repo.UpdateOrderStatus(ctx, itemUpdate(order.OrderUID, chrtID, models.StatusDelivered, 1))
+	assert.ErrorIs(t, err, storage.ErrIllegalTransition)
+
+	got, err := repo.GetOrderByUID(ctx, order.OrderUID)
+	require.NoError(t, err)
+	assert.Equal(t, 202, got.Items[0].Status)
+
+	timeline, err := repo.GetOrderTimeline(ctx, order.OrderUID)
+	require.NoError(t, err)
+	assert.Len(t, timeline.History, 1)
+}
+
+func testUnknownItem(t *testing.T, repo storage.StatusRepository) {
+	ctx := context.Background()
+	order := NewOrder("contract-status-item-missing", 0)
+	require.NoError(t, repo.SaveOrder(ctx, order))
+
+	err := repo.UpdateOrderStatus(ctx, itemUpdate(order.OrderUID, order.Items[0].ChrtID+1, models.StatusPaid, 1))
+	assert.ErrorIs(t, err, storage.ErrItemNotFound)
+
+	err = repo.UpdateOrderStatus(ctx, itemUpdate("contract-missing", 1, models.StatusPaid, 1))
+	assert.ErrorIs(t, err, storage.ErrOrderNotFound)
+}
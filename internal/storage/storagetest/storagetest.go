@@ -0,0 +1,251 @@
+// Package storagetest is a contract test suite for storage.OrderRepository
+// implementations. Every backend runs it from its own tests.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+	"wb-examples-l0/internal/models"
+	"wb-examples-l0/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory returns an empty repository. It is called once per subtest.
+type Factory func(t *testing.T) storage.OrderRepository
+
+// Run runs the contract suite against repositories built by newRepo.
+func Run(t *testing.T, newRepo Factory) {
+	t.Run("SaveAndGet", func(t *testing.T) { testSaveAndGet(t, newRepo(t)) })
+	t.Run("SaveDuplicate", func(t *testing.T) { testSaveDuplicate(t, newRepo(t)) })
+	t.Run("GetNotFound", func(t *testing.T) { testGetNotFound(t, newRepo(t)) })
+	t.Run("Exists", func(t *testing.T) { testExists(t, newRepo(t)) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, newRepo(t)) })
+	t.Run("ListPagination", func(t *testing.T) { testListPagination(t, newRepo(t)) })
+	t.Run("ListFilters", func(t *testing.T) { testListFilters(t, newRepo(t)) })
+	t.Run("ListInvalidCursor", func(t *testing.T) { testListInvalidCursor(t, newRepo(t)) })
+}
+
+// baseTime is truncated to microseconds, the precision of Postgres timestamps.
+var baseTime = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// NewOrder returns a valid order with the given UID created n minutes
+// after a fixed base time.
+func NewOrder(uid string, n int) *models.Order {
+	return &models.Order{
+		OrderUID:    uid,
+		TrackNumber: "WBILMTESTTRACK",
+		Entry:       "WBIL",
+		Delivery: models.Delivery{
+			Name:    "Test Testov",
+			Phone:   "+9720000000",
+			Zip:     "2639809",
+			City:    "Kiryat Mozkin",
+			Address: "Ploshad Mira 15",
+			Region:  "Kraiot",
+			Email:   "test@gmail.com",
+		},
+		Payment: models.Payment{
+			Transaction:  uid,
+			RequestID:    "",
+			Currency:     "USD",
+			Provider:     "wbpay",
+			Amount:       1817,
+			PaymentDt:    1637907727,
+			Bank:         "alpha",
+			DeliveryCost: 1500,
+			GoodsTotal:   317,
+			CustomFee:    0,
+		},
+		Items: []models.Item{
+			{
+				ChrtID:      9934930,
+				TrackNumber: "WBILMTESTTRACK",
+				Price:       453,
+				Rid:         "ab4219087a764ae0btest-" + uid,
+				Name:        "Mascaras",
+				Sale:        30,
+				Size:        "0",
+				TotalPrice:  317,
+				NmID:        2389212,
+				Brand:       "Vivienne Sabo",
+				Status:      202,
+			},
+		},
+		Locale:            "en",
+		InternalSignature: "",
+		CustomerID:        "test",
+		DeliveryService:   "meest",
+		Shardkey:          "9",
+		SmID:              99,
+		DateCreated:       baseTime.Add(time.Duration(n) * time.Minute),
+		OofShard:          "1",
+	}
+}
+
+func testSaveAndGet(t *testing.T, repo storage.OrderRepository) {
+	ctx := context.Background()
+	order := NewOrder("contract-save-get", 0)
+
+	require.NoError(t, repo.SaveOrder(ctx, order))
+
+	got, err := repo.GetOrderByUID(ctx, order.OrderUID)
+	require.NoError(t, err)
+	assert.Equal(t, normalize(order), normalize(got))
+}
+
+func testSaveDuplicate(t *testing.T, repo storage.OrderRepository) {
+	ctx := context.Background()
+	order := NewOrder("contract-duplicate", 0)
+
+	require.NoError(t, repo.SaveOrder(ctx, order))
+
+	err := repo.SaveOrder(ctx, order)
+	assert.ErrorIs(t, err, storage.ErrOrderExists)
+}
+
+func testGetNotFound(t *testing.T, repo storage.OrderRepository) {
+	_, err := repo.GetOrderByUID(context.Background(), "contract-missing")
+	assert.ErrorIs(t, err, storage.ErrOrderNotFound)
+}
+
+func testExists(t *testing.T, repo storage.OrderRepository) {
+	ctx := context.Background()
+	order := NewOrder("contract-exists", 0)
+
+	exists, err := repo.OrderExists(ctx, order.OrderUID)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	require.NoError(t, repo.SaveOrder(ctx, order))
+
+	exists, err = repo.OrderExists(ctx, order.OrderUID)
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func testDelete(t *testing.T, repo storage.OrderRepository) {
+	ctx := context.Background()
+	order := NewOrder("contract-delete", 0)
+
+	require.NoError(t, repo.SaveOrder(ctx, order))
+	require.NoError(t, repo.DeleteOrder(ctx, order.OrderUID))
+
+	_, err := repo.GetOrderByUID(ctx, order.OrderUID)
+	assert.ErrorIs(t, err, storage.ErrOrderNotFound)
+
+	err = repo.DeleteOrder(ctx, order.OrderUID)
+	assert.ErrorIs(t, err, storage.ErrOrderNotFound)
+
+	// The UID is free again after deletion.
+	assert.NoError(t, repo.SaveOrder(ctx, order))
+}
+
+func testListPagination(t *testing.T, repo storage.OrderRepository) {
+	ctx := context.Background()
+
+	const total = 7
+	for i := 0; i < total; i++ {
+		require.NoError(t, repo.SaveOrder(ctx, NewOrder(fmt.Sprintf("contract-list-%d", i), i)))
+	}
+	// Same date_created as contract-list-6: order_uid breaks the tie.
+	require.NoError(t, repo.SaveOrder(ctx, NewOrder("contract-list-6b", total-1)))
+
+	var uids []string
+	filter := storage.OrderFilter{Limit: 3}
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, total, "pagination does not terminate")
+
+		page, err := repo.ListOrders(ctx, filter)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(page.Orders), 3)
+
+		for _, o := range page.Orders {
+			uids = append(uids, o.OrderUID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+
+	assert.Equal(t, []string{
+		"contract-list-6b", "contract-list-6", "contract-list-5", "contract-list-4",
+		"contract-list-3", "contract-list-2", "contract-list-1", "contract-list-0",
+	}, uids)
+}
+
+func testListFilters(t *testing.T, repo storage.OrderRepository) {
+	ctx := context.Background()
+
+	a := NewOrder("contract-filter-a", 0)
+
+	b := NewOrder("contract-filter-b", 10)
+	b.CustomerID = "other"
+	b.Payment.Currency = "RUB"
+
+	c := NewOrder("contract-filter-c", 20)
+	c.Items[0].Brand = "Other Brand"
+	c.DeliveryService = "dhl"
+
+	for _, o := range []*models.Order{a, b, c} {
+		require.NoError(t, repo.SaveOrder(ctx, o))
+	}
+
+	cases := []struct {
+		name   string
+		filter storage.OrderFilter
+		want   []string
+	}{
+		{"no filter", storage.OrderFilter{}, []string{c.OrderUID, b.OrderUID, a.OrderUID}},
+		{"customer", storage.OrderFilter{CustomerID: "other"}, []string{b.OrderUID}},
+		{"currency", storage.OrderFilter{Currency: "USD"}, []string{c.OrderUID, a.OrderUID}},
+		{"brand", storage.OrderFilter{Brand: "Other Brand"}, []string{c.OrderUID}},
+		{"delivery service", storage.OrderFilter{DeliveryService: "meest"}, []string{b.OrderUID, a.OrderUID}},
+		{"date range", storage.OrderFilter{
+			CreatedFrom: baseTime.Add(5 * time.Minute),
+			CreatedTo:   baseTime.Add(20 * time.Minute),
+		}, []string{b.OrderUID}},
+		{"combined", storage.OrderFilter{CustomerID: "test", Currency: "USD", Bank: "alpha"}, []string{c.OrderUID, a.OrderUID}},
+		{"no match", storage.OrderFilter{Locale: "ru"}, []string{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			page, err := repo.ListOrders(ctx, tc.filter)
+			require.NoError(t, err)
+
+			uids := make([]string, 0, len(page.Orders))
+			for _, o := range page.Orders {
+				uids = append(uids, o.OrderUID)
+			}
+			assert.Equal(t, tc.want, uids)
+			assert.Empty(t, page.NextCursor)
+		})
+	}
+}
+
+func testListInvalidCursor(t *testing.T, repo storage.OrderRepository) {
+	_, err := repo.ListOrders(context.Background(), storage.OrderFilter{Cursor: "not-a-cursor"})
+	assert.True(t, errors.Is(err, storage.ErrInvalidCursor), "got %v", err)
+}
+
+// normalize clears fields that are not part of the stored order and
+// brings timestamps to UTC, so orders from any backend compare equal.
+func normalize(o *models.Order) models.Order {
+	n := *o
+	n.DateCreated = n.DateCreated.UTC()
+	n.Delivery.OrderUID = ""
+	n.Payment.OrderUID = ""
+	n.Items = make([]models.Item, len(o.Items))
+	for i, item := range o.Items {
+		item.ID = 0
+		item.OrderUID = ""
+		n.Items[i] = item
+	}
+	return n
+}
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_orders_date_created_uid;
+DROP INDEX IF EXISTS idx_orders_customer_id_date_created;
+DROP INDEX IF EXISTS idx_payments_currency;
+DROP INDEX IF EXISTS idx_payments_provider;
+DROP INDEX IF EXISTS idx_payments_bank;
+DROP INDEX IF EXISTS idx_items_brand;
\ No newline at end of file
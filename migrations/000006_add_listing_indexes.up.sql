@@ -0,0 +1,6 @@
+CREATE INDEX IF NOT EXISTS idx_orders_date_created_uid ON orders(date_created DESC, order_uid DESC);
+CREATE INDEX IF NOT EXISTS idx_orders_customer_id_date_created ON orders(customer_id, date_created DESC, order_uid DESC);
+CREATE INDEX IF NOT EXISTS idx_payments_currency ON payments(currency);
+CREATE INDEX IF NOT EXISTS idx_payments_provider ON payments(provider);
+CREATE INDEX IF NOT EXISTS idx_payments_bank ON payments(bank);
+CREATE INDEX IF NOT EXISTS idx_items_brand ON items(brand, order_uid);
\ No newline at end of file
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_payments_request_id;
+DROP INDEX IF EXISTS idx_items_rid;
+DROP INDEX IF EXISTS idx_items_chrt_id;
+DROP INDEX IF EXISTS idx_items_track_number;
\ No newline at end of file
@@ -0,0 +1,4 @@
+CREATE INDEX IF NOT EXISTS idx_payments_request_id ON payments(request_id);
+CREATE INDEX IF NOT EXISTS idx_items_rid ON items(rid);
+CREATE INDEX IF NOT EXISTS idx_items_chrt_id ON items(chrt_id);
+CREATE INDEX IF NOT EXISTS idx_items_track_number ON items(track_number);
\ No newline at end of file
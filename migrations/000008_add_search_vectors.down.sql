@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_items_search_vector;
+DROP INDEX IF EXISTS idx_deliveries_search_vector;
+ALTER TABLE items DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE deliveries DROP COLUMN IF EXISTS search_vector;
\ No newline at end of file
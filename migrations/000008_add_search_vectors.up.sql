@@ -0,0 +1,14 @@
+ALTER TABLE items ADD COLUMN IF NOT EXISTS search_vector TSVECTOR;
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS search_vector TSVECTOR;
+
+UPDATE items SET search_vector =
+    setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(brand, '')), 'A');
+
+UPDATE deliveries SET search_vector =
+    setweight(to_tsvector('simple', coalesce(name, '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce(city, '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce(address, '')), 'C');
+
+CREATE INDEX IF NOT EXISTS idx_items_search_vector ON items USING GIN(search_vector);
+CREATE INDEX IF NOT EXISTS idx_deliveries_search_vector ON deliveries USING GIN(search_vector);
\ No newline at end of file
@@ -0,0 +1,19 @@
+DROP INDEX IF EXISTS idx_items_search_vector;
+DROP INDEX IF EXISTS idx_deliveries_search_vector;
+ALTER TABLE items DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE deliveries DROP COLUMN IF EXISTS search_vector;
+
+ALTER TABLE items ADD COLUMN search_vector TSVECTOR;
+ALTER TABLE deliveries ADD COLUMN search_vector TSVECTOR;
+
+UPDATE items SET search_vector =
+    setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(brand, '')), 'A');
+
+UPDATE deliveries SET search_vector =
+    setweight(to_tsvector('simple', coalesce(name, '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce(city, '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce(address, '')), 'C');
+
+CREATE INDEX IF NOT EXISTS idx_items_search_vector ON items USING GIN(search_vector);
+CREATE INDEX IF NOT EXISTS idx_deliveries_search_vector ON deliveries USING GIN(search_vector);
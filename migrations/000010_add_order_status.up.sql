@@ -0,0 +1,14 @@
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS status VARCHAR(32) NOT NULL DEFAULT 'created';
+
+CREATE TABLE IF NOT EXISTS status_history(
+    id BIGSERIAL PRIMARY KEY,
+    order_uid VARCHAR(255) NOT NULL REFERENCES orders(order_uid) ON DELETE CASCADE,
+    status VARCHAR(32) NOT NULL,
+    source VARCHAR(255) NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_status_history_order_uid ON status_history(order_uid, id);
+
+INSERT INTO status_history (order_uid, status, source, changed_at)
+SELECT order_uid, 'created', 'order', coalesce(date_created, now()) FROM orders;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS status_history_archive;
+DROP TABLE IF EXISTS items_archive;
+DROP TABLE IF EXISTS payments_archive;
+DROP TABLE IF EXISTS deliveries_archive;
+DROP TABLE IF EXISTS orders_archive;
+DROP INDEX IF EXISTS idx_orders_deleted_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,23 @@
+-- Soft-deleted orders are hidden from all reads and wait for the retention
+-- job to move them to the archive tables below.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_orders_deleted_at ON orders(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- The archive tables have the columns of the live tables in the same order,
+-- rows are copied with INSERT ... SELECT *. Migrations that change a live
+-- table must change its archive table as well.
+CREATE TABLE IF NOT EXISTS orders_archive (LIKE orders);
+ALTER TABLE orders_archive ADD PRIMARY KEY (order_uid);
+ALTER TABLE orders_archive ADD COLUMN archived_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE TABLE IF NOT EXISTS deliveries_archive (LIKE deliveries);
+ALTER TABLE deliveries_archive ADD PRIMARY KEY (order_uid);
+
+CREATE TABLE IF NOT EXISTS payments_archive (LIKE payments);
+ALTER TABLE payments_archive ADD PRIMARY KEY (order_uid);
+
+CREATE TABLE IF NOT EXISTS items_archive (LIKE items);
+CREATE INDEX IF NOT EXISTS idx_items_archive_order_uid ON items_archive(order_uid);
+
+CREATE TABLE IF NOT EXISTS status_history_archive (LIKE status_history);
+CREATE INDEX IF NOT EXISTS idx_status_history_archive_order_uid ON status_history_archive(order_uid);
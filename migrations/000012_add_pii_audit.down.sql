@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_orders_archive_customer_id;
+DROP INDEX IF EXISTS idx_deliveries_email_lower;
+DROP TABLE IF EXISTS pii_audit;
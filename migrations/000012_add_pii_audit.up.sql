@@ -0,0 +1,16 @@
+-- Audit of personal data erasures. The subject is stored as a hash only.
+CREATE TABLE IF NOT EXISTS pii_audit(
+    id BIGSERIAL PRIMARY KEY,
+    subject_kind VARCHAR(32) NOT NULL,
+    subject_hash CHAR(64) NOT NULL,
+    mode VARCHAR(32) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    orders_affected INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_pii_audit_subject_hash ON pii_audit(subject_hash);
+
+CREATE INDEX IF NOT EXISTS idx_deliveries_email_lower ON deliveries(lower(email));
+CREATE INDEX IF NOT EXISTS idx_orders_archive_customer_id ON orders_archive(customer_id);
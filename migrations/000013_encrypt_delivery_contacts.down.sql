@@ -0,0 +1,41 @@
+-- Encrypted rows must be decrypted first with `wb-examples-l0 reencrypt
+-- -decrypt`; the down migration refuses to run while any are left, since
+-- the keys are not available to SQL.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM deliveries WHERE enc_key_id IS NOT NULL)
+        OR EXISTS (SELECT 1 FROM deliveries_archive WHERE enc_key_id IS NOT NULL) THEN
+        RAISE EXCEPTION 'deliveries contain encrypted rows, run reencrypt -decrypt first';
+    END IF;
+END $$;
+
+DROP INDEX IF EXISTS idx_deliveries_enc_key_id;
+DROP INDEX IF EXISTS idx_deliveries_email_hash;
+DROP INDEX IF EXISTS idx_deliveries_email_lower;
+DROP INDEX IF EXISTS idx_deliveries_search_vector;
+
+ALTER TABLE deliveries
+    DROP COLUMN IF EXISTS search_vector,
+    DROP COLUMN IF EXISTS email_hash,
+    DROP COLUMN IF EXISTS enc_dek,
+    DROP COLUMN IF EXISTS enc_key_id,
+    ALTER COLUMN phone TYPE VARCHAR(50),
+    ALTER COLUMN email TYPE VARCHAR(255);
+
+ALTER TABLE deliveries_archive
+    DROP COLUMN IF EXISTS search_vector,
+    DROP COLUMN IF EXISTS email_hash,
+    DROP COLUMN IF EXISTS enc_dek,
+    DROP COLUMN IF EXISTS enc_key_id,
+    ALTER COLUMN phone TYPE VARCHAR(50),
+    ALTER COLUMN email TYPE VARCHAR(255);
+
+ALTER TABLE deliveries ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(name, '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce(city, '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce(address, '')), 'C')
+) STORED;
+ALTER TABLE deliveries_archive ADD COLUMN search_vector TSVECTOR;
+
+CREATE INDEX IF NOT EXISTS idx_deliveries_search_vector ON deliveries USING GIN(search_vector);
+CREATE INDEX IF NOT EXISTS idx_deliveries_email_lower ON deliveries(lower(email));
@@ -0,0 +1,35 @@
+-- Phone, email and address may hold ciphertext: enc_key_id names the key
+-- that wraps the row's data key enc_dek, rows with NULL enc_key_id are in
+-- plain text. email_hash is a blind index for exact email lookups.
+-- search_vector no longer covers encrypted addresses.
+DROP INDEX IF EXISTS idx_deliveries_search_vector;
+DROP INDEX IF EXISTS idx_deliveries_email_lower;
+ALTER TABLE deliveries DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE deliveries_archive DROP COLUMN IF EXISTS search_vector;
+
+ALTER TABLE deliveries
+    ALTER COLUMN phone TYPE TEXT,
+    ALTER COLUMN email TYPE TEXT,
+    ADD COLUMN enc_key_id VARCHAR(64),
+    ADD COLUMN enc_dek BYTEA,
+    ADD COLUMN email_hash CHAR(64);
+
+-- Keep the column order of deliveries, see 000011_add_retention.
+ALTER TABLE deliveries_archive
+    ALTER COLUMN phone TYPE TEXT,
+    ALTER COLUMN email TYPE TEXT,
+    ADD COLUMN enc_key_id VARCHAR(64),
+    ADD COLUMN enc_dek BYTEA,
+    ADD COLUMN email_hash CHAR(64),
+    ADD COLUMN search_vector TSVECTOR;
+
+ALTER TABLE deliveries ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(name, '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce(city, '')), 'B') ||
+    setweight(to_tsvector('simple', CASE WHEN enc_key_id IS NULL THEN coalesce(address, '') ELSE '' END), 'C')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_deliveries_search_vector ON deliveries USING GIN(search_vector);
+CREATE INDEX IF NOT EXISTS idx_deliveries_email_lower ON deliveries(lower(email)) WHERE enc_key_id IS NULL;
+CREATE INDEX IF NOT EXISTS idx_deliveries_email_hash ON deliveries(email_hash);
+CREATE INDEX IF NOT EXISTS idx_deliveries_enc_key_id ON deliveries(enc_key_id);
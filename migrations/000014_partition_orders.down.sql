@@ -0,0 +1,94 @@
+ALTER TABLE items_archive DROP COLUMN IF EXISTS date_created;
+
+ALTER TABLE items RENAME TO items_partitioned;
+ALTER INDEX items_pkey RENAME TO items_partitioned_pkey;
+ALTER TABLE orders RENAME TO orders_partitioned;
+ALTER INDEX orders_pkey RENAME TO orders_partitioned_pkey;
+ALTER SEQUENCE items_id_seq OWNED BY NONE;
+
+DROP INDEX IF EXISTS idx_orders_track_number;
+DROP INDEX IF EXISTS idx_orders_customer_id;
+DROP INDEX IF EXISTS idx_orders_date_created;
+DROP INDEX IF EXISTS idx_orders_date_created_uid;
+DROP INDEX IF EXISTS idx_orders_customer_id_date_created;
+DROP INDEX IF EXISTS idx_orders_deleted_at;
+DROP INDEX IF EXISTS idx_items_order_uid;
+DROP INDEX IF EXISTS idx_items_brand;
+DROP INDEX IF EXISTS idx_items_rid;
+DROP INDEX IF EXISTS idx_items_chrt_id;
+DROP INDEX IF EXISTS idx_items_track_number;
+DROP INDEX IF EXISTS idx_items_search_vector;
+
+CREATE TABLE orders(
+    order_uid VARCHAR(255) PRIMARY KEY,
+    track_number VARCHAR(255),
+    entry VARCHAR(50),
+    locale VARCHAR(10),
+    internal_signature VARCHAR(255),
+    customer_id VARCHAR(255),
+    delivery_service VARCHAR(100),
+    shardkey VARCHAR(10),
+    sm_id INT,
+    date_created TIMESTAMPTZ,
+    oof_shard VARCHAR(10),
+    status VARCHAR(32) NOT NULL DEFAULT 'created',
+    deleted_at TIMESTAMPTZ
+);
+
+INSERT INTO orders SELECT * FROM orders_partitioned;
+
+CREATE TABLE items(
+    id INT PRIMARY KEY DEFAULT nextval('items_id_seq'),
+    order_uid VARCHAR(255) NOT NULL REFERENCES orders(order_uid) ON DELETE CASCADE,
+    chrt_id BIGINT NOT NULL,
+    track_number VARCHAR(255),
+    price INT NOT NULL,
+    rid VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    sale INT,
+    size VARCHAR(50),
+    total_price INT NOT NULL,
+    nm_id BIGINT,
+    brand VARCHAR(255),
+    status INT NOT NULL,
+    search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(brand, '')), 'A')
+    ) STORED
+);
+
+INSERT INTO items (id, order_uid, chrt_id, track_number, price, rid, name, sale, size,
+                   total_price, nm_id, brand, status)
+SELECT id, order_uid, chrt_id, track_number, price, rid, name, sale, size,
+       total_price, nm_id, brand, status
+FROM items_partitioned;
+
+ALTER SEQUENCE items_id_seq OWNED BY items.id;
+
+ALTER TABLE deliveries
+    DROP CONSTRAINT deliveries_order_uid_fkey,
+    ADD CONSTRAINT deliveries_order_uid_fkey FOREIGN KEY (order_uid) REFERENCES orders(order_uid) ON DELETE CASCADE;
+ALTER TABLE payments
+    DROP CONSTRAINT payments_order_uid_fkey,
+    ADD CONSTRAINT payments_order_uid_fkey FOREIGN KEY (order_uid) REFERENCES orders(order_uid) ON DELETE CASCADE;
+ALTER TABLE status_history
+    DROP CONSTRAINT status_history_order_uid_fkey,
+    ADD CONSTRAINT status_history_order_uid_fkey FOREIGN KEY (order_uid) REFERENCES orders(order_uid) ON DELETE CASCADE;
+
+DROP TABLE items_partitioned;
+DROP TABLE orders_partitioned;
+DROP TABLE order_uids;
+DROP FUNCTION IF EXISTS create_order_partitions(TIMESTAMPTZ, INT);
+
+CREATE INDEX IF NOT EXISTS idx_orders_track_number ON orders(track_number);
+CREATE INDEX IF NOT EXISTS idx_orders_customer_id ON orders(customer_id);
+CREATE INDEX IF NOT EXISTS idx_orders_date_created ON orders(date_created);
+CREATE INDEX IF NOT EXISTS idx_orders_date_created_uid ON orders(date_created DESC, order_uid DESC);
+CREATE INDEX IF NOT EXISTS idx_orders_customer_id_date_created ON orders(customer_id, date_created DESC, order_uid DESC);
+CREATE INDEX IF NOT EXISTS idx_orders_deleted_at ON orders(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_items_order_uid ON items(order_uid);
+CREATE INDEX IF NOT EXISTS idx_items_brand ON items(brand, order_uid);
+CREATE INDEX IF NOT EXISTS idx_items_rid ON items(rid);
+CREATE INDEX IF NOT EXISTS idx_items_chrt_id ON items(chrt_id);
+CREATE INDEX IF NOT EXISTS idx_items_track_number ON items(track_number);
+CREATE INDEX IF NOT EXISTS idx_items_search_vector ON items USING GIN(search_vector);
@@ -0,0 +1,188 @@
+-- orders and items are partitioned by date_created month. A primary key of
+-- a partitioned table must contain the partition key, so order_uid is kept
+-- unique by the order_uids directory instead. It is also the foreign key
+-- target of the child tables (deleting from it removes the whole order) and
+-- gives lookups by order_uid the date_created needed to prune partitions.
+UPDATE orders SET date_created = now() WHERE date_created IS NULL;
+
+CREATE TABLE IF NOT EXISTS order_uids(
+    order_uid VARCHAR(255) PRIMARY KEY,
+    date_created TIMESTAMPTZ NOT NULL
+);
+
+INSERT INTO order_uids (order_uid, date_created) SELECT order_uid, date_created FROM orders;
+
+ALTER TABLE deliveries
+    DROP CONSTRAINT deliveries_order_uid_fkey,
+    ADD CONSTRAINT deliveries_order_uid_fkey FOREIGN KEY (order_uid) REFERENCES order_uids(order_uid) ON DELETE CASCADE;
+ALTER TABLE payments
+    DROP CONSTRAINT payments_order_uid_fkey,
+    ADD CONSTRAINT payments_order_uid_fkey FOREIGN KEY (order_uid) REFERENCES order_uids(order_uid) ON DELETE CASCADE;
+ALTER TABLE status_history
+    DROP CONSTRAINT status_history_order_uid_fkey,
+    ADD CONSTRAINT status_history_order_uid_fkey FOREIGN KEY (order_uid) REFERENCES order_uids(order_uid) ON DELETE CASCADE;
+
+-- Move the old tables aside; their indexes are recreated on the new ones.
+DROP INDEX IF EXISTS idx_orders_track_number;
+DROP INDEX IF EXISTS idx_orders_customer_id;
+DROP INDEX IF EXISTS idx_orders_date_created;
+DROP INDEX IF EXISTS idx_orders_date_created_uid;
+DROP INDEX IF EXISTS idx_orders_customer_id_date_created;
+DROP INDEX IF EXISTS idx_orders_deleted_at;
+DROP INDEX IF EXISTS idx_items_order_uid;
+DROP INDEX IF EXISTS idx_items_brand;
+DROP INDEX IF EXISTS idx_items_rid;
+DROP INDEX IF EXISTS idx_items_chrt_id;
+DROP INDEX IF EXISTS idx_items_track_number;
+DROP INDEX IF EXISTS idx_items_search_vector;
+
+ALTER TABLE items RENAME TO items_unpartitioned;
+ALTER INDEX items_pkey RENAME TO items_unpartitioned_pkey;
+ALTER TABLE orders RENAME TO orders_unpartitioned;
+ALTER INDEX orders_pkey RENAME TO orders_unpartitioned_pkey;
+ALTER SEQUENCE items_id_seq OWNED BY NONE;
+
+-- Column order is unchanged, see 000011_add_retention.
+CREATE TABLE orders(
+    order_uid VARCHAR(255) NOT NULL REFERENCES order_uids(order_uid) ON DELETE CASCADE,
+    track_number VARCHAR(255),
+    entry VARCHAR(50),
+    locale VARCHAR(10),
+    internal_signature VARCHAR(255),
+    customer_id VARCHAR(255),
+    delivery_service VARCHAR(100),
+    shardkey VARCHAR(10),
+    sm_id INT,
+    date_created TIMESTAMPTZ NOT NULL,
+    oof_shard VARCHAR(10),
+    status VARCHAR(32) NOT NULL DEFAULT 'created',
+    deleted_at TIMESTAMPTZ,
+    PRIMARY KEY (order_uid, date_created)
+) PARTITION BY RANGE (date_created);
+
+-- items get the date_created of their order as partition key.
+CREATE TABLE items(
+    id INT NOT NULL DEFAULT nextval('items_id_seq'),
+    order_uid VARCHAR(255) NOT NULL REFERENCES order_uids(order_uid) ON DELETE CASCADE,
+    chrt_id BIGINT NOT NULL,
+    track_number VARCHAR(255),
+    price INT NOT NULL,
+    rid VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    sale INT,
+    size VARCHAR(50),
+    total_price INT NOT NULL,
+    nm_id BIGINT,
+    brand VARCHAR(255),
+    status INT NOT NULL,
+    search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(brand, '')), 'A')
+    ) STORED,
+    date_created TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (id, date_created)
+) PARTITION BY RANGE (date_created);
+
+ALTER SEQUENCE items_id_seq OWNED BY items.id;
+
+-- Rows outside of the monthly partitions, e.g. far in the future, land in
+-- the default partitions.
+CREATE TABLE IF NOT EXISTS orders_default PARTITION OF orders DEFAULT;
+CREATE TABLE IF NOT EXISTS items_default PARTITION OF items DEFAULT;
+
+-- create_order_partitions creates the orders and items partitions of
+-- months UTC months starting with the one containing since, e.g.
+-- orders_2025_03, and returns how many tables were created. Rows of those
+-- months already in a default partition are moved to the new one. The
+-- service calls it periodically to stay ahead of incoming orders.
+CREATE OR REPLACE FUNCTION create_order_partitions(since TIMESTAMPTZ, months INT) RETURNS INT
+LANGUAGE plpgsql AS $$
+DECLARE
+    m TIMESTAMP := date_trunc('month', since AT TIME ZONE 'UTC');
+    lo TIMESTAMPTZ;
+    hi TIMESTAMPTZ;
+    tbl TEXT;
+    part TEXT;
+    cols TEXT;
+    created INT := 0;
+BEGIN
+    -- Serializes concurrent callers, e.g. several replicas of the service.
+    PERFORM pg_advisory_xact_lock(hashtext('create_order_partitions'));
+
+    FOR i IN 1..months LOOP
+        lo := m AT TIME ZONE 'UTC';
+        hi := (m + interval '1 month') AT TIME ZONE 'UTC';
+
+        FOREACH tbl IN ARRAY ARRAY['orders', 'items'] LOOP
+            part := tbl || '_' || to_char(m, 'YYYY_MM');
+            CONTINUE WHEN to_regclass(part) IS NOT NULL;
+
+            -- A partition can't be created while the default partition
+            -- holds rows of its range, so they are set aside and put back.
+            SELECT string_agg(quote_ident(attname), ', ' ORDER BY attnum) INTO cols
+            FROM pg_attribute
+            WHERE attrelid = tbl::regclass AND attnum > 0 AND NOT attisdropped AND attgenerated = '';
+
+            EXECUTE format('CREATE TEMP TABLE moved_rows AS SELECT %s FROM %I WHERE false', cols, tbl);
+            EXECUTE format(
+                'WITH d AS (DELETE FROM %I WHERE date_created >= $1 AND date_created < $2 RETURNING *)
+                 INSERT INTO moved_rows SELECT %s FROM d', tbl || '_default', cols)
+            USING lo, hi;
+
+            EXECUTE format('CREATE TABLE %I PARTITION OF %I FOR VALUES FROM (%L) TO (%L)', part, tbl, lo, hi);
+            EXECUTE format('INSERT INTO %I (%s) SELECT %s FROM moved_rows', tbl, cols, cols);
+            EXECUTE 'DROP TABLE moved_rows';
+
+            created := created + 1;
+        END LOOP;
+
+        m := m + interval '1 month';
+    END LOOP;
+
+    RETURN created;
+END $$;
+
+-- Partitions for the existing orders and the next three months.
+DO $$
+DECLARE
+    first_month TIMESTAMP;
+    this_month TIMESTAMP := date_trunc('month', now() AT TIME ZONE 'UTC');
+BEGIN
+    SELECT date_trunc('month', coalesce(min(date_created), now()) AT TIME ZONE 'UTC')
+    INTO first_month FROM orders_unpartitioned;
+
+    first_month := least(first_month, this_month);
+    PERFORM create_order_partitions(first_month AT TIME ZONE 'UTC',
+        ((date_part('year', this_month) - date_part('year', first_month)) * 12
+         + date_part('month', this_month) - date_part('month', first_month))::int + 4);
+END $$;
+
+INSERT INTO orders SELECT * FROM orders_unpartitioned;
+
+INSERT INTO items (id, order_uid, chrt_id, track_number, price, rid, name, sale, size,
+                   total_price, nm_id, brand, status, date_created)
+SELECT i.id, i.order_uid, i.chrt_id, i.track_number, i.price, i.rid, i.name, i.sale, i.size,
+       i.total_price, i.nm_id, i.brand, i.status, o.date_created
+FROM items_unpartitioned i
+JOIN orders_unpartitioned o ON o.order_uid = i.order_uid;
+
+DROP TABLE items_unpartitioned;
+DROP TABLE orders_unpartitioned;
+
+CREATE INDEX IF NOT EXISTS idx_orders_track_number ON orders(track_number);
+CREATE INDEX IF NOT EXISTS idx_orders_customer_id ON orders(customer_id);
+CREATE INDEX IF NOT EXISTS idx_orders_date_created ON orders(date_created);
+CREATE INDEX IF NOT EXISTS idx_orders_date_created_uid ON orders(date_created DESC, order_uid DESC);
+CREATE INDEX IF NOT EXISTS idx_orders_customer_id_date_created ON orders(customer_id, date_created DESC, order_uid DESC);
+CREATE INDEX IF NOT EXISTS idx_orders_deleted_at ON orders(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_items_order_uid ON items(order_uid);
+CREATE INDEX IF NOT EXISTS idx_items_brand ON items(brand, order_uid);
+CREATE INDEX IF NOT EXISTS idx_items_rid ON items(rid);
+CREATE INDEX IF NOT EXISTS idx_items_chrt_id ON items(chrt_id);
+CREATE INDEX IF NOT EXISTS idx_items_track_number ON items(track_number);
+CREATE INDEX IF NOT EXISTS idx_items_search_vector ON items USING GIN(search_vector);
+
+-- Keep the column order of items, see 000011_add_retention.
+ALTER TABLE items_archive ADD COLUMN date_created TIMESTAMPTZ;
+UPDATE items_archive i SET date_created = o.date_created
+FROM orders_archive o WHERE o.order_uid = i.order_uid;
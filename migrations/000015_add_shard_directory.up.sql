@@ -0,0 +1,6 @@
+-- Maps order UIDs to shards when storage.driver is "sharded". Only the
+-- directory shard uses it; the table is empty everywhere else.
+CREATE TABLE IF NOT EXISTS shard_directory(
+    order_uid VARCHAR(255) PRIMARY KEY,
+    shard VARCHAR(64) NOT NULL
+);
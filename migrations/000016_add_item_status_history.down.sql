@@ -0,0 +1,2 @@
+ALTER TABLE status_history_archive DROP COLUMN IF EXISTS chrt_id;
+ALTER TABLE status_history DROP COLUMN IF EXISTS chrt_id;
@@ -0,0 +1,4 @@
+-- Item status transitions are recorded in status_history with the chrt_id
+-- of the items that moved; order transitions leave it NULL.
+ALTER TABLE status_history ADD COLUMN IF NOT EXISTS chrt_id INT;
+ALTER TABLE status_history_archive ADD COLUMN IF NOT EXISTS chrt_id INT;
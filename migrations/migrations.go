@@ -0,0 +1,14 @@
+// Package migrations embeds the schema migrations into the binary.
+package migrations
+
+import "embed"
+
+// FS holds the Postgres migrations.
+//
+//go:embed *.sql
+var FS embed.FS
+
+// SQLiteFS holds the SQLite migrations under sqlite/.
+//
+//go:embed sqlite/*.sql
+var SQLiteFS embed.FS
@@ -0,0 +1,66 @@
+-- SQLite counterpart of the Postgres migrations 000001-000007.
+-- date_created is stored as Unix time in nanoseconds (UTC).
+
+CREATE TABLE IF NOT EXISTS orders(
+     order_uid TEXT PRIMARY KEY,
+     track_number TEXT,
+     entry TEXT,
+     locale TEXT,
+     internal_signature TEXT,
+     customer_id TEXT,
+     delivery_service TEXT,
+     shardkey TEXT,
+     sm_id INTEGER,
+     date_created INTEGER,
+     oof_shard TEXT
+);
+
+CREATE TABLE IF NOT EXISTS deliveries(
+     order_uid TEXT PRIMARY KEY REFERENCES orders(order_uid) ON DELETE CASCADE,
+     name TEXT NOT NULL,
+     phone TEXT NOT NULL,
+     zip TEXT,
+     city TEXT NOT NULL,
+     address TEXT NOT NULL,
+     region TEXT,
+     email TEXT
+);
+
+CREATE TABLE IF NOT EXISTS payments(
+   order_uid TEXT PRIMARY KEY REFERENCES orders(order_uid) ON DELETE CASCADE,
+   request_id TEXT,
+   currency TEXT NOT NULL,
+   provider TEXT NOT NULL,
+   amount INTEGER NOT NULL,
+   payment_dt INTEGER NOT NULL,
+   bank TEXT,
+   delivery_cost INTEGER,
+   goods_total INTEGER NOT NULL,
+   custom_fee INTEGER
+);
+
+CREATE TABLE IF NOT EXISTS items(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    order_uid TEXT NOT NULL REFERENCES orders(order_uid) ON DELETE CASCADE,
+    chrt_id INTEGER NOT NULL,
+    track_number TEXT,
+    price INTEGER NOT NULL,
+    rid TEXT NOT NULL,
+    name TEXT NOT NULL,
+    sale INTEGER,
+    size TEXT,
+    total_price INTEGER NOT NULL,
+    nm_id INTEGER,
+    brand TEXT,
+    status INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_items_order_uid ON items(order_uid);
+CREATE INDEX IF NOT EXISTS idx_orders_track_number ON orders(track_number);
+CREATE INDEX IF NOT EXISTS idx_orders_customer_id_date_created ON orders(customer_id, date_created DESC, order_uid DESC);
+CREATE INDEX IF NOT EXISTS idx_orders_date_created_uid ON orders(date_created DESC, order_uid DESC);
+CREATE INDEX IF NOT EXISTS idx_payments_request_id ON payments(request_id);
+CREATE INDEX IF NOT EXISTS idx_items_brand ON items(brand, order_uid);
+CREATE INDEX IF NOT EXISTS idx_items_rid ON items(rid);
+CREATE INDEX IF NOT EXISTS idx_items_chrt_id ON items(chrt_id);
+CREATE INDEX IF NOT EXISTS idx_items_track_number ON items(track_number);
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS status_history;
+ALTER TABLE orders DROP COLUMN status;
@@ -0,0 +1,17 @@
+-- SQLite counterpart of the Postgres migration 000010.
+-- changed_at is stored as Unix time in nanoseconds (UTC), like date_created.
+
+ALTER TABLE orders ADD COLUMN status TEXT NOT NULL DEFAULT 'created';
+
+CREATE TABLE IF NOT EXISTS status_history(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    order_uid TEXT NOT NULL REFERENCES orders(order_uid) ON DELETE CASCADE,
+    status TEXT NOT NULL,
+    source TEXT NOT NULL,
+    changed_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_status_history_order_uid ON status_history(order_uid, id);
+
+INSERT INTO status_history (order_uid, status, source, changed_at)
+SELECT order_uid, 'created', 'order', coalesce(date_created, 0) FROM orders;
@@ -0,0 +1 @@
+ALTER TABLE status_history DROP COLUMN chrt_id;
@@ -0,0 +1,2 @@
+-- SQLite counterpart of the Postgres migration 000016.
+ALTER TABLE status_history ADD COLUMN chrt_id INTEGER;
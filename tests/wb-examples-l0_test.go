@@ -87,3 +87,176 @@ func TestGetOrder_ResponseSchema(t *testing.T) {
 		ContainsKey("payment").
 		Value("order_uid").String()
 }
+
+func TestListOrders_AgainstRunningServer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping functional test in short mode")
+	}
+
+	e := httpexpect.WithConfig(httpexpect.Config{
+		BaseURL:  baseURL,
+		Reporter: httpexpect.NewAssertReporter(t),
+	})
+
+	t.Run("first page", func(t *testing.T) {
+		e.GET("/orders").
+			WithQuery("limit", 2).
+			Expect().
+			Status(200).
+			JSON().
+			Object().
+			ContainsKey("orders")
+	})
+
+	t.Run("filter by customer", func(t *testing.T) {
+		orders := e.GET("/orders").
+			WithQuery("customer_id", "test").
+			WithQuery("currency", "USD").
+			Expect().
+			Status(200).
+			JSON().
+			Object().
+			Value("orders").Array()
+
+		for _, order := range orders.Iter() {
+			order.Object().HasValue("customer_id", "test")
+		}
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		e.GET("/orders").
+			WithQuery("cursor", "not-a-cursor").
+			Expect().
+			Status(400)
+	})
+
+	t.Run("invalid date", func(t *testing.T) {
+		e.GET("/orders").
+			WithQuery("created_from", "yesterday").
+			Expect().
+			Status(400)
+	})
+}
+
+func TestLookupOrders_AgainstRunningServer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping functional test in short mode")
+	}
+
+	e := httpexpect.WithConfig(httpexpect.Config{
+		BaseURL:  baseURL,
+		Reporter: httpexpect.NewAssertReporter(t),
+	})
+
+	t.Run("by track number", func(t *testing.T) {
+		e.GET("/orders/by-track/{track_number}", "WBILMTESTTRACK").
+			Expect().
+			Status(200).
+			JSON().
+			Object().
+			Value("orders").Array().NotEmpty()
+	})
+
+	t.Run("by transaction", func(t *testing.T) {
+		existingOrderUID := "b563feb7b2b84b6052695"
+
+		e.GET("/orders/by-payment").
+			WithQuery("transaction", existingOrderUID).
+			Expect().
+			Status(200).
+			JSON().
+			Object().
+			Value("orders").Array().Length().IsEqual(1)
+	})
+
+	t.Run("by unknown rid", func(t *testing.T) {
+		e.GET("/orders/by-item").
+			WithQuery("rid", "nonExistRid_84b6296329").
+			Expect().
+			Status(404)
+	})
+
+	t.Run("missing parameters", func(t *testing.T) {
+		e.GET("/orders/by-item").
+			Expect().
+			Status(400)
+	})
+}
+
+func TestCustomerHistory_AgainstRunningServer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping functional test in short mode")
+	}
+
+	e := httpexpect.WithConfig(httpexpect.Config{
+		BaseURL:  baseURL,
+		Reporter: httpexpect.NewAssertReporter(t),
+	})
+
+	t.Run("existing customer", func(t *testing.T) {
+		obj := e.GET("/customers/{customer_id}/orders", "test").
+			WithQuery("limit", 5).
+			Expect().
+			Status(200).
+			JSON().
+			Object()
+
+		obj.ContainsKey("orders")
+		obj.Value("summary").Object().
+			ContainsKey("order_count").
+			ContainsKey("total_spent").
+			ContainsKey("avg_basket_size").
+			ContainsKey("top_brands").
+			ContainsKey("first_order_at").
+			ContainsKey("last_order_at")
+	})
+
+	t.Run("unknown customer", func(t *testing.T) {
+		e.GET("/customers/{customer_id}/orders", "nonExistCustomer_84b6296329").
+			Expect().
+			Status(404)
+	})
+}
+
+func TestSearchOrders_AgainstRunningServer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping functional test in short mode")
+	}
+
+	e := httpexpect.WithConfig(httpexpect.Config{
+		BaseURL:  baseURL,
+		Reporter: httpexpect.NewAssertReporter(t),
+	})
+
+	t.Run("by brand prefix", func(t *testing.T) {
+		results := e.GET("/search").
+			WithQuery("q", "vivien").
+			Expect().
+			Status(200).
+			JSON().
+			Object().
+			Value("results").Array()
+
+		results.NotEmpty()
+		results.Value(0).Object().
+			ContainsKey("order_uid").
+			ContainsKey("rank").
+			Value("highlights").Array().NotEmpty()
+	})
+
+	t.Run("by part of address", func(t *testing.T) {
+		e.GET("/search").
+			WithQuery("q", "ploshad mira").
+			Expect().
+			Status(200).
+			JSON().
+			Object().
+			Value("results").Array().NotEmpty()
+	})
+
+	t.Run("empty query", func(t *testing.T) {
+		e.GET("/search").
+			Expect().
+			Status(400)
+	})
+}